@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+var (
+	addProjectDir string
+	addFields     string
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add parts to a generated project",
+}
+
+var addDomainCmd = &cobra.Command{
+	Use:   "domain <name>",
+	Short: "Add a domain to a generated project",
+	Long: `Add a domain's handler, service, repository, queries, migration and tests to a
+generated project without regenerating it.
+
+The module, domains, features and template packs are read from the project's
+` + generator.ManifestFile + `, or from its layout for projects generated before it.
+The domain's files are created, and its wiring is merged into the shared files
+such as cmd/serve.go and the routes, keeping the changes made to them. Changes
+that clash with edits are left as conflict markers to resolve by hand.
+
+--fields defines the domain's entity as name:type pairs, a ? after the type
+making a field optional; the default is ` + generator.DefaultFields + `.
+
+Examples:
+  go-app-gen add domain invoice
+  go-app-gen add domain invoice --fields "number:string,total:decimal,due_at:timestamp?"
+  go-app-gen add domain billing.payment --project ./orders`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectDir, err := filepath.Abs(addProjectDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve project directory: %w", err)
+		}
+
+		var fields []generator.FieldSpec
+		if addFields != "" {
+			if fields, err = generator.ParseFields(addFields); err != nil {
+				return fmt.Errorf("failed to add domain: %w", err)
+			}
+		}
+
+		result, err := generator.New(filepath.Dir(projectDir)).AddDomain(projectDir, args[0], fields)
+		if result != nil {
+			printAddDomain(result)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add domain: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	addDomainCmd.Flags().StringVar(&addProjectDir, "project", ".", "Generated project to add the domain to")
+	addDomainCmd.Flags().StringVar(&addFields, "fields", "", "Fields of the domain's entity as name:type[?] pairs ("+strings.Join(generator.FieldTypeNames(), ", ")+")")
+	addCmd.AddCommand(addDomainCmd)
+}
+
+// printAddDomain lists the files adding a domain created and changed
+func printAddDomain(result *generator.AddDomainResult) {
+	fmt.Printf("✅ Added domain '%s': %d files created, %d merged\n", result.Domain, len(result.Created), len(result.Merged))
+	for _, p := range result.Merged {
+		fmt.Printf("   merged %s\n", p)
+	}
+	for _, p := range result.Conflicts {
+		fmt.Printf("⚠️  Conflict in %s: resolve the <<<<<<< markers by hand\n", p)
+	}
+}
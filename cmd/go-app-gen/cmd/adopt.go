@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+var (
+	adoptOptions  generator.AdoptOptions
+	adoptFeatures []string
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [project-dir]",
+	Short: "Write a manifest into an existing project so the incremental commands work on it",
+	Long: `Inspect an existing Go service that go-app-gen did not generate and write its
+` + generator.ManifestFile + `, so status, add domain and the other incremental commands work
+on it.
+
+The module is read from go.mod, the name and description from cmd/root.go, the
+domains from the repository queries and the features from the files that exist;
+--module, --domain and --features override what is inferred. The templates are
+rendered for the result and every project file at a rendered path is recorded
+with its current content, so status reports the changes made after adopting.
+
+Examples:
+  go-app-gen adopt
+  go-app-gen adopt services/orders --domain order,billing.invoice --dry-run`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAdopt,
+}
+
+func init() {
+	adoptCmd.Flags().StringVarP(&adoptOptions.Module, "module", "m", "", "Go module name (default: the module of go.mod)")
+	adoptCmd.Flags().StringSliceVarP(&adoptOptions.Domains, "domain", "d", []string{}, "Domains of the project, the first the primary one (default: those with repository queries)")
+	adoptCmd.Flags().StringSliceVar(&adoptFeatures, "features", []string{}, "Features of the project (default: those whose files exist)")
+	adoptCmd.Flags().BoolVar(&adoptOptions.DryRun, "dry-run", false, "Print what would be recorded without writing the manifest")
+	adoptCmd.Flags().BoolVar(&adoptOptions.Force, "force", false, "Replace an existing manifest")
+}
+
+func runAdopt(cmd *cobra.Command, args []string) error {
+	projectDir := "."
+	if len(args) > 0 {
+		projectDir = args[0]
+	}
+	if cmd.Flags().Changed("features") {
+		adoptOptions.Features = adoptFeatures
+	}
+
+	result, err := generator.Adopt(projectDir, adoptOptions)
+	if err != nil {
+		return fmt.Errorf("failed to adopt project: %w", err)
+	}
+
+	config := result.Config
+	fmt.Printf("📦 %s (%s)\n", config.ModuleName, config.AppName)
+	fmt.Printf("🗂️  Domains: %s\n", strings.Join(append([]string{config.Domain}, config.Domains...), ", "))
+	features := "none"
+	if len(config.Features) > 0 {
+		features = strings.Join(config.Features, ", ")
+	}
+	fmt.Printf("🧩 Features: %s\n", features)
+	fmt.Printf("📊 Rendered files: %d as generated, %d changed, %d missing\n", len(result.Matching), len(result.Differing), len(result.Missing))
+	for _, p := range result.Differing {
+		fmt.Printf("   changed %s\n", p)
+	}
+
+	manifest := filepath.Join(projectDir, generator.ManifestFile)
+	if adoptOptions.DryRun {
+		fmt.Printf("⏭️  Skipped writing %s (dry run)\n", manifest)
+		return nil
+	}
+	fmt.Printf("✅ Wrote %s; go-app-gen status and add domain now work on the project\n", manifest)
+	return nil
+}
@@ -1,15 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/nhalm/go-app-gen/internal/generator"
+	"github.com/nhalm/go-app-gen/internal/scm"
 )
 
 // Config holds the configuration for project generation
@@ -17,15 +21,56 @@ type Config struct {
 	AppName     string
 	ModuleName  string
 	Domain      string
+	Domains     []string
 	Description string
 	Author      string
 	OutputDir   string
 	Features    []string
+
+	DeployTarget    string
+	Database        string
+	Router          string
+	Logger          string
+	CI              string
+	Archetype       string
+	Template        string
+	TemplateDirs    []string
+	TemplateKeys    []string
+	AllowHooks      []string
+	DomainPlural    string
+	DomainTitle     string
+	Fields          []string
+	InflectionsFile string
+	SpecFile        string
+	OpenAPIFile     string
+	SQLFile         string
+	DatabaseURL     string
+	MakeTargets     []generator.MakeTarget
+	Guardrails      generator.Guardrails
+	Header          generator.HeaderSpec
+	Lang            string
+	MessageFiles    []string
+
+	// Layers are the template layers resolved from TemplateDirs by validateConfig
+	Layers *generator.Layers
+	// Remote is the checkout of Template, set by validateProject
+	Remote *generator.RemoteTemplates
+	// FieldSpecs are the entity fields parsed from Fields by validateProject
+	FieldSpecs map[string][]generator.FieldSpec
 }
 
 var (
 	config Config
 	interactive bool
+	useCache bool
+	dryRun bool
+	only []string
+	force bool
+	strict bool
+
+	createRepo string
+	repoPath   string
+	publicRepo bool
 )
 
 var createCmd = &cobra.Command{
@@ -36,21 +81,50 @@ var createCmd = &cobra.Command{
 This command generates a complete Go application structure including:
 - Cobra CLI with subcommands
 - Clean architecture (api/service/repository layers)
-- Database integration with migrations
+- Database integration with migrations, for PostgreSQL (the default), MySQL
+  or SQLite with --database
+- An HTTP API on chi (the default), net/http, Echo or Gin with --router
+- Structured logging with log/slog (the default), zerolog or zap with --logger
+- A CI pipeline for GitHub Actions, GitLab CI or CircleCI with --ci, linting,
+  testing against the database, building and publishing the Docker image
+- A specialized service shape with --archetype, such as a GitHub webhook
+  receiver with signature verification, idempotent processing and replay, an
+  API gateway with per-route upstreams, API keys and rate limits, or a batch
+  pipeline with checkpoints, parallel workers and backfills
 - Configuration management with Viper
 - Comprehensive testing setup
 - Docker and development tooling
 
+Post-processing tools that fail or are missing only warn, except with --strict,
+which fails the generation with the exit code of the failure class (see
+go-app-gen --help).
+
 Examples:
   go-app-gen create myapp
   go-app-gen create myapp --module github.com/myorg/myapp --domain product
+  go-app-gen create myapp --domain customer --domain billing.invoice,billing.payment
+  go-app-gen create myapp --domain product --fields "name:string,price:decimal,released_at:timestamp"
+  go-app-gen create --spec project.yaml
+  go-app-gen create --from-openapi api.yaml -m github.com/myorg/shop
+  go-app-gen create legacy --from-sql schema.sql
+  go-app-gen create myapp --database mysql
+  go-app-gen create myapp --router gin
+  go-app-gen create myapp --logger zerolog
+  go-app-gen create myapp --ci github
+  go-app-gen create hooks --archetype webhook-receiver
+  go-app-gen create edge --archetype gateway
+  go-app-gen create etl --archetype pipeline
+  go-app-gen create myapp --dry-run
+  go-app-gen create myapp --strict
+  go-app-gen create myapp --only migrations,docker
+  go-app-gen create myapp --template-dir ./my-templates
   go-app-gen create --interactive`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if interactive {
+		if interactive || config.SpecFile != "" || config.OpenAPIFile != "" {
 			return nil
 		}
 		if len(args) < 1 {
-			return errors.New("project name is required when not using --interactive")
+			return errors.New("project name is required when not using --interactive, --spec or --from-openapi")
 		}
 		return nil
 	},
@@ -58,47 +132,172 @@ Examples:
 }
 
 func init() {
-	createCmd.Flags().StringVarP(&config.ModuleName, "module", "m", "", "Go module name (e.g., github.com/user/project)")
-	createCmd.Flags().StringVarP(&config.Domain, "domain", "d", "", "Primary domain entity (e.g., user, product, order)")
-	createCmd.Flags().StringVar(&config.Description, "description", "", "Project description")
-	createCmd.Flags().StringVar(&config.Author, "author", "", "Author name")
+	addProjectFlags(createCmd)
 	createCmd.Flags().StringVarP(&config.OutputDir, "output", "o", ".", "Output directory")
-	createCmd.Flags().StringSliceVar(&config.Features, "features", []string{}, "Additional features to include")
 	createCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactive mode")
+	createCmd.Flags().StringSliceVar(&config.AllowHooks, "allow-hooks", []string{}, "Template packs whose post-processing hooks may run; hooks run commands on this machine, so only allow packs you trust")
+	createCmd.Flags().StringVar(&config.Guardrails.Secrets, "secrets", "", "What credentials found in the generated files do: warn (default), fail before post-processing, or off")
+	createCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the files that would be created, with their sizes, without writing them or running the post-processing")
+	createCmd.Flags().StringSliceVar(&only, "only", []string{}, "Write only these components ("+strings.Join(generator.ComponentNames(), ", ")+") or project paths into the directory, which may hold an existing codebase; skips the post-processing")
+	createCmd.Flags().BoolVar(&force, "force", false, "With --only, overwrite existing files that differ from the rendered ones")
+	createCmd.Flags().BoolVar(&strict, "strict", false, "Fail when any post-processing step fails, including sqlc, buf, gqlgen, goimports and the build, exiting with the code of its failure class")
+	createCmd.Flags().BoolVar(&useCache, "cache", false, "Reuse rendered templates from the user cache directory and leave unchanged files untouched")
+	createCmd.Flags().StringVar(&createRepo, "create-repo", "", "Create the remote repository, push the project and protect main ("+strings.Join(scm.ProviderNames(), ", ")+"; token from GITHUB_TOKEN or GITLAB_TOKEN)")
+	createCmd.Flags().StringVar(&repoPath, "repo", "", "Repository to create as owner/name (default: the module path without its host)")
+	createCmd.Flags().BoolVar(&publicRepo, "public-repo", false, "Make the created repository public instead of private")
+}
+
+// addProjectFlags adds the flags describing the project to a command that
+// takes a project configuration, as create and explain do
+func addProjectFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&config.ModuleName, "module", "m", "", "Go module name (e.g., github.com/user/project)")
+	cmd.Flags().StringSliceVarP(&config.Domains, "domain", "d", []string{}, "Domain entities, optionally namespaced into bounded contexts (e.g., product, purchase_order, billing.invoice); the first is the primary domain")
+	cmd.Flags().StringVar(&config.DomainPlural, "domain-plural", "", "Override the plural form of the domain (e.g., schemata)")
+	cmd.Flags().StringVar(&config.DomainTitle, "domain-title", "", "Override the title-cased domain used in Go identifiers (e.g., SKU)")
+	cmd.Flags().StringArrayVar(&config.Fields, "fields", []string{}, "Fields of the primary domain's entity as name:type pairs, a ? after the type making one optional (types: "+strings.Join(generator.FieldTypeNames(), ", ")+"; default "+generator.DefaultFields+"); repeat with domain=fields for other domains")
+	cmd.Flags().StringVar(&config.InflectionsFile, "inflections", "", "YAML file with custom pluralization rules")
+	cmd.Flags().StringVar(&config.SpecFile, "spec", "", "YAML project spec with the project settings and Makefile customizations; flags override it")
+	cmd.Flags().StringVar(&config.OpenAPIFile, "from-openapi", "", "OpenAPI 3 specification whose resource collections become the domains and whose schemas their fields, overriding the spec's; flags override it (the name defaults to its title)")
+	cmd.Flags().StringVar(&config.SQLFile, "from-sql", "", "PostgreSQL or MySQL schema, such as a pg_dump --schema-only or mysqldump --no-data dump, whose tables become the domains and whose columns their fields, overriding the spec's; flags override it")
+	cmd.Flags().StringVar(&config.DatabaseURL, "from-db", "", "PostgreSQL connection URL of a database to import like --from-sql, dumping its schema with pg_dump")
+	cmd.MarkFlagsMutuallyExclusive("from-openapi", "from-sql", "from-db")
+	cmd.Flags().StringVar(&config.Description, "description", "", "Project description")
+	cmd.Flags().StringVar(&config.Author, "author", "", "Author name, GitHub @handle or email (a handle or email seeds CODEOWNERS with repo-hygiene)")
+	cmd.Flags().StringSliceVar(&config.Features, "features", []string{}, "Additional features to include ("+strings.Join(generator.FeatureNames(), ", ")+")")
+	cmd.Flags().StringVar(&config.Template, "template", "", "Git repository of templates, URL[@branch, tag or commit], cloned to the user cache and used like a first --template-dir")
+	cmd.Flags().StringSliceVar(&config.TemplateDirs, "template-dir", []string{}, "Template pack to layer over the built-in templates; repeat to stack packs, later ones on top. A first directory without pack.yaml is a template tree replacing the built-in templates")
+	cmd.Flags().StringSliceVar(&config.TemplateKeys, "template-key", []string{}, "minisign or cosign public key the template packs must be signed with; repeat to trust several")
+	cmd.Flags().StringVar(&config.Lang, "lang", "", "Language of the README and code comments ("+strings.Join(generator.Languages(), ", ")+", or one a template pack or --messages translates)")
+	cmd.Flags().StringSliceVar(&config.MessageFiles, "messages", []string{}, "YAML message bundle with your own translations, layered over the built-in and template pack ones")
+	cmd.Flags().StringVar(&config.DeployTarget, "deploy-target", "", "Additional deploy target, experimental ("+strings.Join(generator.DeployTargetNames(), ", ")+")")
+	cmd.Flags().StringVar(&config.Database, "database", generator.DefaultDatabase, "Database engine the project stores its entities in ("+strings.Join(generator.DatabaseNames(), ", ")+")")
+	cmd.Flags().StringVar(&config.Router, "router", generator.DefaultRouter, "HTTP router of the API layer ("+strings.Join(generator.RouterNames(), ", ")+")")
+	cmd.Flags().StringVar(&config.Logger, "logger", generator.DefaultLogger, "Logging library the project logs with ("+strings.Join(generator.LoggerNames(), ", ")+")")
+	cmd.Flags().StringVar(&config.CI, "ci", "", "CI provider to generate a pipeline for ("+strings.Join(generator.CIProviderNames(), ", ")+")")
+	cmd.Flags().StringVar(&config.Archetype, "archetype", generator.DefaultArchetype, "Shape of service to generate ("+strings.Join(generator.ArchetypeNames(), ", ")+")")
 }
 
 func runCreate(cmd *cobra.Command, args []string) error {
 	var err error
 	
+	if config.SpecFile != "" {
+		if err := applySpec(cmd, config.SpecFile); err != nil {
+			return invalid(err)
+		}
+	}
+	if err := applyImport(cmd); err != nil {
+		return invalid(err)
+	}
+
 	if interactive {
 		err = runInteractiveMode()
 	} else {
-		config.AppName = args[0]
+		if len(args) > 0 {
+			config.AppName = args[0]
+		}
 		err = runDirectMode()
 	}
 	
 	if err != nil {
-		return fmt.Errorf("failed to create project: %w", err)
+		return fmt.Errorf("failed to create project: %w", invalid(err))
+	}
+
+	if createRepo != "" && len(only) > 0 {
+		return invalid(errors.New("--create-repo cannot be combined with --only"))
+	}
+	var repo scm.Repository
+	if createRepo != "" && !dryRun {
+		if repo, err = remoteRepository(); err != nil {
+			return fmt.Errorf("failed to create project: %w", err)
+		}
 	}
 
 	// Generate the project
 	gen := generator.New(config.OutputDir)
-	
-	projectConfig := &generator.ProjectConfig{
-		AppName:     config.AppName,
-		ModuleName:  config.ModuleName,
-		Domain:      config.Domain,
-		Description: config.Description,
-		Author:      config.Author,
-		Features:    config.Features,
+	if useCache {
+		if err := enableCache(gen); err != nil {
+			return err
+		}
 	}
 	
+	projectConfig := newProjectConfig()
+	
+	if config.Remote != nil {
+		fmt.Printf("📥 Templates: %s at %s\n", config.Remote.URL, config.Remote.Commit)
+		if config.Remote.Stale {
+			fmt.Printf("⚠️  Failed to fetch %s, using the cached clone\n", config.Remote.URL)
+		}
+	}
+	if config.Layers.Tree != "" {
+		fmt.Printf("🧩 Template tree: %s\n", config.Layers.Tree)
+	}
+	if len(config.Layers.Packs) > 0 {
+		fmt.Printf("🧩 Template layers: %s\n", strings.Join(config.Layers.Names(), " < "))
+		for _, p := range config.Layers.Packs {
+			verified := "unverified, no " + generator.PackChecksumsFile
+			switch p.Verified {
+			case "checksums":
+				verified = "checksums verified"
+			case "minisign", "cosign":
+				verified = "signature verified with " + p.Verified
+			}
+			fmt.Printf("   %s: %s (%s)\n", p.Manifest.Name, p.Digest, verified)
+		}
+		for _, p := range config.Layers.Packs {
+			for _, warning := range p.Warnings {
+				fmt.Printf("⚠️  %s\n", warning)
+			}
+		}
+		for _, o := range config.Layers.Overrides {
+			fmt.Printf("   %s: %s overrides %s\n", o.Path, o.Pack, o.Base)
+		}
+		printHooks(config.Layers, config.AllowHooks)
+	}
+
+	if len(only) > 0 {
+		result, err := gen.GeneratePartial(projectConfig, generator.PartialOptions{Only: only, Force: force, DryRun: dryRun})
+		if err != nil {
+			return fmt.Errorf("failed to generate project: %w", err)
+		}
+		printPartial(result)
+		return nil
+	}
+
+	if dryRun {
+		report, err := gen.DryRun(projectConfig)
+		if err != nil {
+			return fmt.Errorf("failed to generate project: %w", err)
+		}
+		return printPlan(report, config.Guardrails)
+	}
+
 	if err := gen.Generate(projectConfig); err != nil {
 		return fmt.Errorf("failed to generate project: %w", err)
 	}
+	if useCache {
+		printCacheStats(gen)
+	}
+	printAssets(gen.Report())
+	if err := printSummary(gen.Report(), config.Guardrails); err != nil {
+		return err
+	}
 
 	fmt.Printf("✅ Successfully created project '%s' in %s\n", config.AppName, filepath.Join(config.OutputDir, config.AppName))
 	fmt.Printf("📁 Project structure generated with module: %s\n", config.ModuleName)
+	if createRepo != "" {
+		result, err := scm.Bootstrap(context.Background(), filepath.Join(config.OutputDir, config.AppName), createRepo, repo, scm.Options{
+			Description: config.Description,
+			Public:      publicRepo,
+			Labels:      scm.DefaultLabels,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🌐 Created repository %s and pushed the initial commit\n", result.URL)
+		for _, warning := range result.Warnings {
+			fmt.Printf("⚠️  %s\n", warning)
+		}
+	}
 	fmt.Printf("🚀 To get started:\n")
 	fmt.Printf("   cd %s\n", config.AppName)
 	fmt.Printf("   go mod tidy\n")
@@ -107,22 +306,342 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// newProjectConfig returns the generator configuration of the validated config
+func newProjectConfig() *generator.ProjectConfig {
+	project := &generator.ProjectConfig{
+		AppName:     config.AppName,
+		ModuleName:  config.ModuleName,
+		Domain:      config.Domain,
+		Domains:     config.Domains[1:],
+		Description: config.Description,
+		Author:      config.Author,
+		Features:    config.Features,
+
+		TemplateDirs: config.TemplateDirs,
+		TemplateKeys: config.TemplateKeys,
+		AllowHooks:   config.AllowHooks,
+		DeployTarget: config.DeployTarget,
+		Database:     config.Database,
+		Router:       config.Router,
+		Logger:       config.Logger,
+		CI:           config.CI,
+		Archetype:    config.Archetype,
+		DomainPlural: config.DomainPlural,
+		DomainTitle:  config.DomainTitle,
+		Fields:       config.FieldSpecs,
+		MakeTargets:  config.MakeTargets,
+		Secrets:      config.Guardrails.Secrets,
+		Strict:       strict,
+
+		Header:           config.Header,
+		GeneratorVersion: version,
+		Lang:             config.Lang,
+		MessageFiles:     config.MessageFiles,
+	}
+	if config.Remote != nil {
+		project.TemplateSource = config.Remote.Source()
+	}
+	return project
+}
+
+// printHooks lists the hooks of the template packs: those that will run after
+// post-processing and those skipped because their pack is not allowed
+func printHooks(layers *generator.Layers, allowed []string) {
+	for _, p := range layers.Packs {
+		if len(p.Manifest.Hooks) == 0 {
+			continue
+		}
+		if slices.Contains(allowed, p.Manifest.Name) {
+			fmt.Printf("🪝 Hooks of %s will run after post-processing:\n", p.Manifest.Name)
+		} else {
+			fmt.Printf("⚠️  Skipping the hooks of %s; pass --allow-hooks %s to run them:\n", p.Manifest.Name, p.Manifest.Name)
+		}
+		for _, h := range p.Manifest.Hooks {
+			fmt.Printf("   %s: %s\n", h.Name, strings.Join(h.Run, " "))
+		}
+	}
+}
+
+// remoteRepository resolves the repository --create-repo creates and checks
+// that the provider's token is set, before anything is generated
+func remoteRepository() (scm.Repository, error) {
+	if _, err := scm.Token(createRepo); err != nil {
+		return scm.Repository{}, err
+	}
+	if repoPath != "" {
+		return scm.ParseRepository(repoPath)
+	}
+	return scm.ModuleRepository(config.ModuleName)
+}
+
+// enableCache points the generator at the default render cache
+func enableCache(gen *generator.Generator) error {
+	dir, err := generator.DefaultCacheDir()
+	if err != nil {
+		return err
+	}
+	return gen.UseCache(dir)
+}
+
+// printCacheStats reports how much of the generation the render cache saved
+func printCacheStats(gen *generator.Generator) {
+	stats := gen.CacheStats()
+	fmt.Printf("♻️  Render cache: %d of %d outputs reused, %d unchanged files left untouched\n", stats.Hits, stats.Hits+stats.Misses, stats.Unchanged)
+}
+
+// printAssets lists the static assets of template packs, which can be large, with their sizes
+func printAssets(report generator.GenerationReport) {
+	for _, f := range report.Files {
+		if f.Asset {
+			fmt.Printf("📦 %s (%s, static asset)\n", f.Path, generator.FormatSize(f.Size))
+		}
+	}
+}
+
+// printPartial lists the files a generation with --only wrote or left alone
+func printPartial(result *generator.PartialResult) {
+	targetDir := filepath.Join(config.OutputDir, config.AppName)
+	verb := "✅ Wrote"
+	if dryRun {
+		verb = "📝 Dry run: would write"
+	}
+	fmt.Printf("%s %d files into %s: %d created, %d overwritten, %d with updated regions, %d unchanged\n", verb, len(result.Created)+len(result.Updated)+len(result.Regions), targetDir, len(result.Created), len(result.Updated), len(result.Regions), len(result.Unchanged))
+	for _, p := range result.Created {
+		fmt.Printf("   created %s\n", p)
+	}
+	for _, p := range result.Updated {
+		fmt.Printf("   overwrote %s\n", p)
+	}
+	for _, p := range result.Regions {
+		fmt.Printf("   updated the generated regions of %s\n", p)
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Printf("⚠️  Left %d existing files that differ from the rendered ones; rerun with --force to overwrite them:\n", len(result.Skipped))
+		for _, p := range result.Skipped {
+			fmt.Printf("   %s\n", p)
+		}
+	}
+	for _, p := range result.Owned {
+		fmt.Printf("   kept %s (yours, never overwritten)\n", p)
+	}
+	fmt.Printf("⏭️  Skipped post-processing (--only): run go mod tidy and the code generators the files need\n")
+}
+
+// printPlan lists the files a dry run would create, with their sizes, and
+// what the generation would warn about
+func printPlan(report generator.GenerationReport, guardrails generator.Guardrails) error {
+	targetDir := filepath.Join(config.OutputDir, config.AppName)
+	fmt.Printf("📝 Dry run: files that would be created in %s\n", targetDir)
+	files := slices.Clone(report.Files)
+	slices.SortFunc(files, func(a, b generator.GeneratedFile) int { return strings.Compare(a.Path, b.Path) })
+	for _, f := range files {
+		fmt.Printf("   %10s  %s\n", generator.FormatSize(f.Size), f.Path)
+	}
+	if empty, err := isDirEmpty(targetDir); err == nil && !empty {
+		fmt.Printf("⚠️  %s already exists and contains files; creating the project would recreate it\n", targetDir)
+	}
+	if err := printSummary(report, guardrails); err != nil {
+		return err
+	}
+	fmt.Printf("⏭️  Skipped writing files and post-processing (dry run)\n")
+	return nil
+}
+
+// printSummary prints the files, size and lines generated, by language, and
+// warns about the guardrails the generation exceeded and the secrets it found
+func printSummary(report generator.GenerationReport, guardrails generator.Guardrails) error {
+	summary := report.Summary()
+	fmt.Printf("📊 Generated %d files, %s, %d lines\n", summary.Files, generator.FormatSize(summary.Size), summary.Lines)
+	for _, lang := range summary.Languages {
+		fmt.Printf("   %-14s %4d files %7d lines\n", lang.Language, lang.Files, lang.Lines)
+	}
+
+	warnings, err := report.Check(guardrails)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Printf("⚠️  Guardrail: %s; check the template packs and features, or raise it under guardrails in the spec\n", warning)
+	}
+	if len(report.Secrets) > 0 {
+		fmt.Printf("⚠️  Possible secrets in the generated files; remove them, or mark a false positive with a gitleaks:allow comment:\n")
+		for _, f := range report.Secrets {
+			fmt.Printf("   %s\n", f)
+		}
+	}
+	return nil
+}
+
+// applySpec loads a project spec file and takes every setting not given as a flag from it
+func applySpec(cmd *cobra.Command, path string) error {
+	spec, err := generator.LoadSpec(path)
+	if err != nil {
+		return err
+	}
+
+	flags := cmd.Flags()
+	config.AppName = spec.Name
+	if !flags.Changed("module") {
+		config.ModuleName = spec.Module
+	}
+	if !flags.Changed("domain") {
+		config.Domains = spec.Domains
+	}
+	if !flags.Changed("domain-plural") {
+		config.DomainPlural = spec.DomainPlural
+	}
+	if !flags.Changed("domain-title") {
+		config.DomainTitle = spec.DomainTitle
+	}
+	if !flags.Changed("fields") {
+		config.Fields = nil
+		for _, domain := range slices.Sorted(maps.Keys(spec.Fields)) {
+			config.Fields = append(config.Fields, domain+"="+spec.Fields[domain])
+		}
+	}
+	if !flags.Changed("description") {
+		config.Description = spec.Description
+	}
+	if !flags.Changed("author") {
+		config.Author = spec.Author
+	}
+	if !flags.Changed("features") {
+		config.Features = spec.Features
+	}
+	if !flags.Changed("deploy-target") {
+		config.DeployTarget = spec.DeployTarget
+	}
+	if !flags.Changed("database") && spec.Database != "" {
+		config.Database = spec.Database
+	}
+	if !flags.Changed("router") && spec.Router != "" {
+		config.Router = spec.Router
+	}
+	if !flags.Changed("logger") && spec.Logger != "" {
+		config.Logger = spec.Logger
+	}
+	if !flags.Changed("ci") {
+		config.CI = spec.CI
+	}
+	if !flags.Changed("archetype") && spec.Archetype != "" {
+		config.Archetype = spec.Archetype
+	}
+	if !flags.Changed("template") {
+		config.Template = spec.Template
+	}
+	if !flags.Changed("template-dir") {
+		// Packs in a spec are relative to the spec file
+		config.TemplateDirs = nil
+		for _, dir := range spec.TemplateDirs {
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(filepath.Dir(path), dir)
+			}
+			config.TemplateDirs = append(config.TemplateDirs, dir)
+		}
+	}
+	if !flags.Changed("template-key") {
+		config.TemplateKeys = nil
+		for _, key := range spec.TemplateKeys {
+			if !filepath.IsAbs(key) && !strings.Contains(key, "://") {
+				key = filepath.Join(filepath.Dir(path), key)
+			}
+			config.TemplateKeys = append(config.TemplateKeys, key)
+		}
+	}
+	if !flags.Changed("lang") {
+		config.Lang = spec.Lang
+	}
+	if !flags.Changed("messages") {
+		// Bundles in a spec are relative to the spec file
+		config.MessageFiles = nil
+		for _, file := range spec.Messages {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(path), file)
+			}
+			config.MessageFiles = append(config.MessageFiles, file)
+		}
+	}
+	config.MakeTargets = spec.Makefile.Targets
+	config.Header = spec.Header
+	secrets := config.Guardrails.Secrets
+	config.Guardrails = spec.Guardrails
+	if flags.Changed("secrets") {
+		config.Guardrails.Secrets = secrets
+	}
+	return nil
+}
+
+// applyImport derives the domains and their fields from the OpenAPI
+// specification or SQL schema given, taking those not given as flags, and
+// reports what the import left out
+func applyImport(cmd *cobra.Command) error {
+	var imported *generator.Import
+	var source string
+	var err error
+	switch {
+	case config.OpenAPIFile != "":
+		source = config.OpenAPIFile
+		imported, err = generator.ImportOpenAPI(config.OpenAPIFile)
+	case config.SQLFile != "":
+		source = config.SQLFile
+		imported, err = generator.ImportSQL(config.SQLFile)
+	case config.DatabaseURL != "":
+		source = "the database"
+		imported, err = generator.ImportDatabase(cmd.Context(), config.DatabaseURL)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	flags := cmd.Flags()
+	if config.AppName == "" {
+		config.AppName = imported.Name
+	}
+	if !flags.Changed("domain") {
+		config.Domains = imported.Domains
+		if !flags.Changed("domain-plural") {
+			config.DomainPlural = imported.DomainPlural
+		}
+	}
+	if !flags.Changed("fields") {
+		config.Fields = nil
+		for _, domain := range slices.Sorted(maps.Keys(imported.Fields)) {
+			config.Fields = append(config.Fields, domain+"="+imported.Fields[domain])
+		}
+	}
+	if !flags.Changed("description") && imported.Description != "" {
+		config.Description = imported.Description
+	}
+
+	fmt.Printf("📖 Imported %s: %s\n", source, strings.Join(imported.Domains, ", "))
+	for _, warning := range imported.Warnings {
+		fmt.Printf("⚠️  %s\n", warning)
+	}
+	return nil
+}
+
 func runDirectMode() error {
-	// Set defaults if not provided
+	applyDefaults()
+	return validateConfig()
+}
+
+// applyDefaults fills in the settings neither the flags nor the spec gave
+func applyDefaults() {
 	if config.ModuleName == "" {
 		config.ModuleName = fmt.Sprintf("github.com/user/%s", config.AppName)
 	}
-	if config.Domain == "" {
-		config.Domain = "item"
+	if len(config.Domains) == 0 {
+		config.Domains = []string{"item"}
 	}
+	config.Domain = config.Domains[0]
 	if config.Description == "" {
 		config.Description = fmt.Sprintf("A %s management API", config.Domain)
 	}
 	if config.Author == "" {
 		config.Author = "Developer"
 	}
-	
-	return validateConfig()
 }
 
 func runInteractiveMode() error {
@@ -137,8 +656,25 @@ func runInteractiveMode() error {
 	defaultModule := fmt.Sprintf("github.com/user/%s", config.AppName)
 	config.ModuleName = promptString("Go module name", defaultModule)
 	
-	// Get domain
-	config.Domain = promptString("Primary domain entity (e.g., user, product, order)", "item")
+	// Get domains
+	config.Domain = promptString("Primary domain entity (e.g., user, product, billing.invoice)", "item")
+	config.Domains = []string{config.Domain}
+	if extra := promptString("Additional domains, comma-separated (optional)", ""); extra != "" {
+		for _, d := range strings.Split(extra, ",") {
+			if d = strings.TrimSpace(d); d != "" {
+				config.Domains = append(config.Domains, d)
+			}
+		}
+	}
+	
+	// Get the entity fields of each domain
+	config.Fields = nil
+	for _, d := range config.Domains {
+		fields := promptString(fmt.Sprintf("Fields of %s as name:type pairs, ? for optional (%s)", d, strings.Join(generator.FieldTypeNames(), ", ")), generator.DefaultFields)
+		if fields != generator.DefaultFields {
+			config.Fields = append(config.Fields, d+"="+fields)
+		}
+	}
 	
 	// Get description
 	defaultDesc := fmt.Sprintf("A %s management API", config.Domain)
@@ -147,6 +683,29 @@ func runInteractiveMode() error {
 	// Get author
 	config.Author = promptString("Author name", "Developer")
 	
+	// Get database
+	config.Database = promptString(fmt.Sprintf("Database (%s)", strings.Join(generator.DatabaseNames(), ", ")), generator.DefaultDatabase)
+	
+	// Get router
+	config.Router = promptString(fmt.Sprintf("HTTP router (%s)", strings.Join(generator.RouterNames(), ", ")), generator.DefaultRouter)
+	
+	// Get logger
+	config.Logger = promptString(fmt.Sprintf("Logging library (%s)", strings.Join(generator.LoggerNames(), ", ")), generator.DefaultLogger)
+	
+	// Get CI provider
+	config.CI = promptString(fmt.Sprintf("CI provider (%s, or none)", strings.Join(generator.CIProviderNames(), ", ")), "none")
+	if config.CI == "none" {
+		config.CI = ""
+	}
+	
+	// Get archetype
+	config.Archetype = promptString(fmt.Sprintf("Archetype (%s)", strings.Join(generator.ArchetypeNames(), ", ")), generator.DefaultArchetype)
+	
+	// Get features
+	if err := promptFeatures(); err != nil {
+		return err
+	}
+	
 	// Get output directory
 	config.OutputDir = promptString("Output directory", ".")
 	
@@ -169,7 +728,9 @@ func promptString(prompt, defaultValue string) string {
 	return strings.TrimSpace(input)
 }
 
-func validateConfig() error {
+// validateProject checks the project settings and resolves the template
+// layers, without looking at the output directory
+func validateProject() error {
 	if config.AppName == "" {
 		return errors.New("app name is required")
 	}
@@ -181,7 +742,91 @@ func validateConfig() error {
 	if config.Domain == "" {
 		return errors.New("domain is required")
 	}
-	
+
+	if config.InflectionsFile != "" {
+		if err := generator.LoadInflectionRules(config.InflectionsFile); err != nil {
+			return err
+		}
+	}
+
+	if err := generator.ValidateDomains(config.Domains, config.DomainPlural); err != nil {
+		return err
+	}
+
+	fields, err := generator.ParseDomainFields(config.Fields, config.Domains)
+	if err != nil {
+		return err
+	}
+	config.FieldSpecs = fields
+
+	if config.DomainTitle != "" {
+		if err := generator.ValidateDomainTitle(config.DomainTitle); err != nil {
+			return err
+		}
+	}
+
+	if config.Template != "" && config.Remote == nil {
+		cacheDir, err := generator.DefaultTemplateCacheDir()
+		if err != nil {
+			return err
+		}
+		remote, err := generator.FetchTemplates(context.Background(), config.Template, cacheDir)
+		if err != nil {
+			return err
+		}
+		config.Remote = remote
+		config.TemplateDirs = append([]string{remote.Dir}, config.TemplateDirs...)
+	}
+
+	layers, err := generator.LoadLayers(config.TemplateDirs, config.TemplateKeys)
+	if err != nil {
+		return err
+	}
+	if err := layers.ValidateFeatures(config.Features); err != nil {
+		return err
+	}
+	if err := generator.ValidateSecretsPolicy(config.Guardrails.Secrets); err != nil {
+		return err
+	}
+	for _, name := range config.AllowHooks {
+		if !slices.Contains(layers.Names()[1:], name) {
+			return fmt.Errorf("--allow-hooks %s: no template pack of that name is layered", name)
+		}
+	}
+	config.Layers = layers
+	if _, err := generator.LoadMessages(config.Lang, layers, config.MessageFiles); err != nil {
+		return err
+	}
+
+	if err := generator.ValidateDatabase(config.Database); err != nil {
+		return err
+	}
+	if err := generator.ValidateTables(config.Domains, config.DomainPlural, config.Database); err != nil {
+		return err
+	}
+	if err := generator.ValidateFeatureDatabases(config.Features, config.Database); err != nil {
+		return err
+	}
+	if err := generator.ValidateRouter(config.Router); err != nil {
+		return err
+	}
+	if err := generator.ValidateLogger(config.Logger); err != nil {
+		return err
+	}
+	if err := generator.ValidateCIProvider(config.CI); err != nil {
+		return err
+	}
+	if err := generator.ValidateArchetype(config.Archetype); err != nil {
+		return err
+	}
+	return generator.ValidateDeployTarget(config.DeployTarget)
+}
+
+func validateConfig() error {
+	if err := validateProject(); err != nil {
+		return err
+	}
+
 	// Check if output directory exists
 	if _, err := os.Stat(config.OutputDir); os.IsNotExist(err) {
 		return fmt.Errorf("output directory does not exist: %s", config.OutputDir)
@@ -189,7 +834,7 @@ func validateConfig() error {
 	
 	// Check if target directory already exists
 	targetDir := filepath.Join(config.OutputDir, config.AppName)
-	if _, err := os.Stat(targetDir); err == nil {
+	if _, err := os.Stat(targetDir); err == nil && !dryRun && len(only) == 0 {
 		// Directory exists, check if it's empty
 		empty, err := isDirEmpty(targetDir)
 		if err != nil {
@@ -205,7 +850,7 @@ func validateConfig() error {
 			fmt.Scanln(&response)
 			
 			if !strings.EqualFold(response, "y") && !strings.EqualFold(response, "yes") {
-				return errors.New("operation cancelled")
+				return errCancelled
 			}
 			
 			// Remove existing directory
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+// Exit codes of the failure classes, as the root command documents them
+const (
+	exitFailure     = 1
+	exitValidation  = 2
+	exitTemplate    = 3
+	exitCancelled   = 4
+	exitToolMissing = 10
+	exitModule      = 11
+	exitSQLC        = 12
+	exitFormat      = 13
+	exitBuild       = 14
+	exitHook        = 15
+	exitProto       = 16
+	exitGraphQL     = 17
+)
+
+// Error formats of --error-format
+const (
+	errorFormatText = "text"
+	errorFormatJSON = "json"
+)
+
+// errorFormat is how Execute's caller prints a failure, set by --error-format
+var errorFormat = errorFormatText
+
+// errCancelled is returned when the user declines a confirmation prompt
+var errCancelled = errors.New("operation cancelled")
+
+// validationError marks an error in the flags, arguments, spec or other
+// inputs of a command
+type validationError struct {
+	err error
+}
+
+func (e *validationError) Error() string { return e.err.Error() }
+
+func (e *validationError) Unwrap() error { return e.err }
+
+// invalid marks err as a validation error; nil stays nil
+func invalid(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &validationError{err: err}
+}
+
+// validationErrors are the generator errors caused by the inputs
+var validationErrors = []error{
+	generator.ErrInvalidField,
+	generator.ErrInvalidHeader,
+	generator.ErrInvalidMakeTarget,
+	generator.ErrMakeTargetExists,
+	generator.ErrMakeTargetNotFound,
+	generator.ErrInvalidGuardrail,
+	generator.ErrInvalidOpenAPI,
+	generator.ErrInvalidSQLSchema,
+	generator.ErrDomainExists,
+	generator.ErrNotGeneratedProject,
+	generator.ErrNothingSelected,
+	generator.ErrAlreadyAdopted,
+	generator.ErrOriginalMismatch,
+	generator.ErrUnsafePath,
+}
+
+// templateErrors are the generator errors caused by the templates
+var templateErrors = []error{
+	generator.ErrTemplateParse,
+	generator.ErrTemplateExecute,
+	generator.ErrTemplateConflict,
+	generator.ErrInvalidPack,
+	generator.ErrInvalidTemplateTree,
+	generator.ErrPackVerification,
+	generator.ErrIncompatiblePack,
+	generator.ErrInvalidMessages,
+}
+
+// failure is how a failed command is reported
+type failure struct {
+	Class   string `json:"class"` // validation, template, cancelled, post-process or error
+	Code    int    `json:"code"`  // the exit code
+	Step    string `json:"step,omitempty"`
+	Message string `json:"message"`
+}
+
+// classify returns the failure class and exit code of err
+func classify(err error) failure {
+	f := failure{Class: "error", Code: exitFailure, Message: err.Error()}
+	var step *generator.StepError
+	var validation *validationError
+	switch {
+	case errors.As(err, &step):
+		f.Class, f.Step = "post-process", step.Step
+		f.Code = stepExitCode(step)
+	case errors.Is(err, errCancelled):
+		f.Class, f.Code = "cancelled", exitCancelled
+	case isAny(err, templateErrors):
+		f.Class, f.Code = "template", exitTemplate
+	case errors.As(err, &validation) || isAny(err, validationErrors):
+		f.Class, f.Code = "validation", exitValidation
+	}
+	return f
+}
+
+// stepExitCode returns the exit code of a failed post-processing step
+func stepExitCode(step *generator.StepError) int {
+	switch {
+	case errors.Is(step.Err, exec.ErrNotFound):
+		return exitToolMissing
+	case strings.HasPrefix(step.Step, "hook:"):
+		return exitHook
+	}
+	switch step.Step {
+	case "mod-init", "mod-tidy":
+		return exitModule
+	case "sqlc":
+		return exitSQLC
+	case "buf":
+		return exitProto
+	case "gqlgen":
+		return exitGraphQL
+	case "fmt", "goimports":
+		return exitFormat
+	case "build":
+		return exitBuild
+	}
+	return exitFailure
+}
+
+// isAny reports whether err is one of targets
+func isAny(err error, targets []error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExitCode returns the process exit code of an error Execute returned
+func ExitCode(err error) int {
+	return classify(err).Code
+}
+
+// PrintError writes an error Execute returned to w in the --error-format
+func PrintError(w io.Writer, err error) {
+	if errorFormat != errorFormatJSON {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return
+	}
+	content, _ := json.Marshal(classify(err))
+	fmt.Fprintln(w, string(content))
+}
+
+// silenceJSONUsage keeps cmd from printing its usage on failure with
+// --error-format json, where it would garble the JSON error on stderr
+func silenceJSONUsage(cmd *cobra.Command) {
+	if errorFormat == errorFormatJSON {
+		cmd.SilenceUsage = true
+	}
+}
+
+// markInvalidArgs makes the argument errors of c and its subcommands
+// validation errors
+func markInvalidArgs(c *cobra.Command) {
+	if args := c.Args; args != nil {
+		c.Args = func(cmd *cobra.Command, a []string) error {
+			err := args(cmd, a)
+			if err != nil {
+				silenceJSONUsage(cmd)
+			}
+			return invalid(err)
+		}
+	}
+	for _, sub := range c.Commands() {
+		markInvalidArgs(sub)
+	}
+}
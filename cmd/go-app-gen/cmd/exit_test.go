@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		class string
+		code  int
+	}{
+		{"unknown error", errors.New("boom"), "error", exitFailure},
+		{"invalid argument", invalid(errors.New("bad flag")), "validation", exitValidation},
+		{"invalid field", fmt.Errorf("failed to create project: %w", generator.ErrInvalidField), "validation", exitValidation},
+		{"unsafe output path", fmt.Errorf("failed to create project: %w: app name %q must be a plain directory name", generator.ErrUnsafePath, "../evil"), "validation", exitValidation},
+		{"template parse error", fmt.Errorf("%w: unexpected \"}\"", generator.ErrTemplateParse), "template", exitTemplate},
+		{"invalid pack", fmt.Errorf("%w: missing pack.yaml", generator.ErrInvalidPack), "template", exitTemplate},
+		{"incompatible pack", fmt.Errorf("%w: schema 99 is newer than this generator", generator.ErrIncompatiblePack), "template", exitTemplate},
+		{"invalid messages", fmt.Errorf("%w: de.yaml has unknown key %q", generator.ErrInvalidMessages, "readme.nope"), "template", exitTemplate},
+		{"template error in an invalid input", invalid(generator.ErrTemplateExecute), "template", exitTemplate},
+		{"cancelled", errCancelled, "cancelled", exitCancelled},
+		{"missing tool", &generator.StepError{Step: "sqlc", Err: exec.ErrNotFound}, "post-process", exitToolMissing},
+		{"sqlc failed", &generator.StepError{Step: "sqlc", Err: errors.New("syntax error")}, "post-process", exitSQLC},
+		{"build failed", &generator.StepError{Step: "build", Err: errors.New("undefined: x")}, "post-process", exitBuild},
+		{"hook failed", &generator.StepError{Step: "hook:lint", Err: errors.New("exit status 1")}, "post-process", exitHook},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classify(tt.err)
+			if got.Class != tt.class || got.Code != tt.code {
+				t.Fatalf("classify(%v) = %s %d, want %s %d", tt.err, got.Class, got.Code, tt.class, tt.code)
+			}
+			if ExitCode(tt.err) != tt.code {
+				t.Fatalf("ExitCode(%v) = %d, want %d", tt.err, ExitCode(tt.err), tt.code)
+			}
+		})
+	}
+}
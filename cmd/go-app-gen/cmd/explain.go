@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [project-name]",
+	Short: "Describe what a configuration would generate",
+	Long: `Describe what create would generate for the same flags or spec, without
+creating the project: the files, the docker compose services, the API endpoints
+and the Makefile targets.
+
+Examples:
+  go-app-gen explain --features metrics
+  go-app-gen explain myapp --domain customer,billing.invoice --features openapi
+  go-app-gen explain --spec project.yaml
+  go-app-gen explain --from-openapi api.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExplain,
+}
+
+func init() {
+	addProjectFlags(explainCmd)
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	config.AppName = "myapp"
+	if config.SpecFile != "" {
+		if err := applySpec(cmd, config.SpecFile); err != nil {
+			return err
+		}
+	}
+	if err := applyImport(cmd); err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		config.AppName = args[0]
+	}
+	applyDefaults()
+	if err := validateProject(); err != nil {
+		return fmt.Errorf("failed to explain project: %w", err)
+	}
+
+	for _, p := range config.Layers.Packs {
+		for _, warning := range p.Warnings {
+			fmt.Printf("⚠️  %s\n", warning)
+		}
+	}
+
+	explanation, err := generator.Explain(newProjectConfig())
+	if err != nil {
+		return fmt.Errorf("failed to explain project: %w", err)
+	}
+
+	summary := generator.GenerationReport{Files: explanation.Files}.Summary()
+	fmt.Printf("📁 Files (%d, %s, %d lines):\n", summary.Files, generator.FormatSize(summary.Size), summary.Lines)
+	for _, f := range explanation.Files {
+		fmt.Printf("   %s\n", f.Path)
+	}
+
+	fmt.Printf("\n🐳 Compose services:\n")
+	for _, c := range explanation.Compose {
+		fmt.Printf("   %-22s %s\n", c.Path, strings.Join(c.Services, ", "))
+	}
+
+	fmt.Printf("\n🌐 Endpoints:\n")
+	for _, e := range explanation.Endpoints {
+		fmt.Printf("   %-7s %s\n", e.Method, e.Path)
+	}
+
+	fmt.Printf("\n🛠️  Makefile targets:\n")
+	for _, t := range explanation.MakeTargets {
+		fmt.Printf("   %-20s %s\n", t.Target, t.Description)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+// previewLines is how much of a feature's sample file the browser prints
+const previewLines = 30
+
+// promptFeatures lets the user pick the features of the project in the
+// interactive mode. Entering ?N, or ? and a name, describes a feature and
+// previews the files it adds before the selection is confirmed.
+func promptFeatures() error {
+	if err := generator.ValidateDomains(config.Domains, config.DomainPlural); err != nil {
+		return err
+	}
+	layers, err := generator.LoadLayers(config.TemplateDirs, config.TemplateKeys)
+	if err != nil {
+		return err
+	}
+	features := layers.Features()
+
+	fmt.Println("🧩 Features (numbers or names, comma-separated; ?N shows what a feature adds):")
+	for i, f := range features {
+		fmt.Printf("   %2d. %-20s %s\n", i+1, f.Name, f.Description)
+	}
+
+	for {
+		input := promptString("Features", strings.Join(config.Features, ","))
+		if name, ok := strings.CutPrefix(input, "?"); ok {
+			if err := previewFeature(layers, features, name); err != nil {
+				fmt.Printf("⚠️  %v\n", err)
+			}
+			continue
+		}
+
+		selected, err := parseFeatureSelection(features, input)
+		if err == nil {
+			err = layers.ValidateFeatures(selected)
+		}
+		if err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			continue
+		}
+		config.Features = selected
+		return nil
+	}
+}
+
+// parseFeatureSelection resolves a comma-separated list of feature numbers and names
+func parseFeatureSelection(features []generator.Feature, input string) ([]string, error) {
+	var selected []string
+	for _, item := range strings.Split(input, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		name, err := featureName(features, item)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, name)
+	}
+	return selected, nil
+}
+
+// featureName returns the feature an item of the selection refers to, by
+// its number in the list or by name
+func featureName(features []generator.Feature, item string) (string, error) {
+	n, err := strconv.Atoi(item)
+	if err != nil {
+		return item, nil
+	}
+	if n < 1 || n > len(features) {
+		return "", fmt.Errorf("no feature %d, pick 1 to %d", n, len(features))
+	}
+	return features[n-1].Name, nil
+}
+
+// previewFeature prints a feature's description, the files it adds to the
+// project configured so far and the start of its sample file
+func previewFeature(layers *generator.Layers, features []generator.Feature, item string) error {
+	name, err := featureName(features, strings.TrimSpace(item))
+	if err != nil {
+		return err
+	}
+	preview, err := generator.PreviewFeature(newProjectConfig(), layers, name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n📖 %s: %s\n", preview.Feature.Name, preview.Feature.Description)
+	if len(preview.Requires) > 0 {
+		fmt.Printf("   Requires: %s\n", strings.Join(preview.Requires, ", "))
+	}
+	fmt.Printf("   Adds %d files:\n", len(preview.Files))
+	for _, f := range preview.Files {
+		fmt.Printf("     %s\n", f.Path)
+	}
+
+	if preview.Sample != "" {
+		lines := strings.Split(strings.TrimRight(string(preview.SampleContent), "\n"), "\n")
+		fmt.Printf("   Preview of %s:\n", preview.Sample)
+		for i, line := range lines {
+			if i == previewLines {
+				fmt.Printf("     … %d more lines\n", len(lines)-previewLines)
+				break
+			}
+			fmt.Printf("     │ %s\n", strings.TrimRight(line, " \t"))
+		}
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nhalm/go-app-gen/internal/fleet"
+)
+
+// errFleetFailed is returned when the upgrade of a repository of the fleet fails
+var errFleetFailed = errors.New("fleet upgrade failed")
+
+var (
+	fleetManifest string
+	fleetDryRun   bool
+	fleetVerbose  bool
+)
+
+var fleetCmd = &cobra.Command{
+	Use:   "fleet",
+	Short: "Maintain many generated services at once",
+}
+
+var fleetUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade every service of a fleet manifest and propose the changes",
+	Long: `Clone every repository of the fleet manifest, run its update commands in the
+generated project, run the verification commands and, when the service changed and
+passed, commit the change to a branch, push it and open a pull request through the
+manifest's SCM provider (github with the gh CLI, gitlab with glab, or none to push
+the branch only).
+
+A repository that fails does not stop the others.
+
+Examples:
+  go-app-gen fleet upgrade --manifest fleet.yaml
+  go-app-gen fleet upgrade --manifest fleet.yaml --dry-run -v`,
+	Args: cobra.NoArgs,
+	RunE: runFleetUpgrade,
+}
+
+func init() {
+	fleetUpgradeCmd.Flags().StringVar(&fleetManifest, "manifest", "", "Fleet manifest listing the repositories and the update and verification commands")
+	fleetUpgradeCmd.Flags().BoolVar(&fleetDryRun, "dry-run", false, "Update and verify the repositories without pushing or opening pull requests")
+	fleetUpgradeCmd.Flags().BoolVarP(&fleetVerbose, "verbose", "v", false, "Show the output of git and the manifest's commands")
+	_ = fleetUpgradeCmd.MarkFlagRequired("manifest")
+	fleetCmd.AddCommand(fleetUpgradeCmd)
+}
+
+func runFleetUpgrade(cmd *cobra.Command, args []string) error {
+	manifest, err := fleet.LoadManifest(fleetManifest)
+	if err != nil {
+		return err
+	}
+
+	opts := fleet.Options{DryRun: fleetDryRun}
+	if fleetVerbose {
+		opts.Log = os.Stdout
+	}
+	fmt.Printf("🚚 Upgrading %d repositories on branch %s\n", len(manifest.Repos), manifest.Branch)
+	results, err := fleet.Upgrade(context.Background(), manifest, opts)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		switch r.Outcome {
+		case fleet.OutcomeFailed:
+			failed++
+			fmt.Printf("❌ %s: %s failed: %v\n", r.Repo, r.Step, r.Err)
+		case fleet.OutcomeUpToDate:
+			fmt.Printf("➖ %s: up to date\n", r.Repo)
+		case fleet.OutcomeVerified:
+			fmt.Printf("✅ %s: updated and verified (dry run, not pushed)\n", r.Repo)
+		case fleet.OutcomePushed:
+			fmt.Printf("✅ %s: pushed %s\n", r.Repo, manifest.Branch)
+		case fleet.OutcomeProposed:
+			fmt.Printf("✅ %s: %s\n", r.Repo, r.URL)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d repositories", errFleetFailed, failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nhalm/go-app-gen/internal/server"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve the generator to editors and assistants over the Model Context Protocol",
+	Long: `Serve the generator over the Model Context Protocol (MCP) on stdin and stdout,
+JSON-RPC 2.0 messages one per line, for IDE plugins and AI assistants that start
+go-app-gen as a subprocess.
+
+The tools are:
+  list_options   the features, databases, deploy targets and field types
+  validate_spec  check a project spec, the YAML or JSON of a create --spec file
+  explain_spec   the files, HTTP endpoints and make targets a spec generates
+  generate       generate a spec's project into a directory, like create
+  add_domain     add a domain to a generated project, like add domain
+
+Specs can only use the built-in templates, as with serve. The progress the
+generator prints goes to stderr, leaving stdout to the protocol.
+
+Example client configuration:
+  {"mcpServers": {"go-app-gen": {"command": "go-app-gen", "args": ["mcp"]}}}`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The generator prints its progress to os.Stdout, which would corrupt
+		// the messages, so it is pointed at stderr while serving
+		protocol := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = protocol }()
+
+		return server.NewMCP(version).Serve(os.Stdin, protocol)
+	},
+}
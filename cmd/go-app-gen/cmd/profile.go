@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// profilePrefix is the --profile flag: the path prefix of the profiles written
+var profilePrefix string
+
+// cpuProfile is the CPU profile being written, nil when not profiling
+var cpuProfile *os.File
+
+// startProfile starts the CPU profile of the command when --profile is set
+func startProfile() error {
+	if profilePrefix == "" {
+		return nil
+	}
+	f, err := os.Create(profilePrefix + ".cpu.pprof")
+	if err != nil {
+		return fmt.Errorf("failed to create CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	cpuProfile = f
+	return nil
+}
+
+// stopProfile finishes the CPU profile and writes the heap profile; it runs
+// after failed commands too, whose profiles are often the interesting ones
+func stopProfile() error {
+	if cpuProfile == nil {
+		return nil
+	}
+	pprof.StopCPUProfile()
+	if err := cpuProfile.Close(); err != nil {
+		return fmt.Errorf("failed to write CPU profile: %w", err)
+	}
+
+	heapPath := profilePrefix + ".heap.pprof"
+	f, err := os.Create(heapPath)
+	if err != nil {
+		return fmt.Errorf("failed to create heap profile: %w", err)
+	}
+	defer f.Close()
+	runtime.GC() // up-to-date statistics of live objects
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "📈 Profiles written to %s and %s (go tool pprof <file>)\n", cpuProfile.Name(), heapPath)
+	cpuProfile = nil
+	return nil
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume <project-dir>",
+	Short: "Finish the post-processing of an interrupted or failed generation",
+	Long: `Run the post-generation tasks of a project that did not complete, e.g. after
+go mod tidy failed during a network outage, instead of regenerating it.
+
+The tasks and how far they got are recorded in the project's ` + generator.ManifestFile + `;
+tasks that completed are skipped and optional ones that only warned run again.
+A project created with --strict resumes strictly, exiting with the same codes.
+
+Examples:
+  go-app-gen resume myapp`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manifest, err := generator.LoadManifest(args[0])
+		if err != nil {
+			return err
+		}
+		if remaining := manifest.Remaining(); len(remaining) > 0 {
+			fmt.Printf("🔄 Resuming post-generation tasks: %s\n", strings.Join(remaining, ", "))
+		}
+
+		if err := generator.New(".").Resume(args[0]); err != nil {
+			return fmt.Errorf("failed to resume project: %w", err)
+		}
+		return nil
+	},
+}
@@ -21,14 +21,46 @@ var (
 		Long: `go-app-gen is a CLI tool for generating Go applications with clean architecture,
 database integration, and modern tooling. It creates projects based on proven patterns
 including Cobra CLI, Viper configuration, clean architecture layers, and comprehensive
-testing setups.`,
-		Version: version,
+testing setups.
+
+Exit codes:
+  0   success
+  1   any other failure
+  2   invalid flags, arguments, spec or other input
+  3   a template failed to parse or render, or a template pack or message bundle is invalid
+  4   cancelled at a prompt
+  10  a post-processing tool is not installed
+  11  go mod init or go mod tidy failed
+  12  sqlc generate failed (fails only with create --strict)
+  13  go fmt or goimports failed (goimports only with create --strict)
+  14  go build failed (fails only with create --strict)
+  15  a template pack hook failed
+  16  buf generate failed for the grpc feature (fails only with create --strict)
+  17  gqlgen generate failed for the graphql feature (fails only with create --strict)
+
+With --error-format json, a failure is printed to stderr as one JSON object
+with its class (validation, template, cancelled, post-process or error), exit
+code, post-processing step and message.`,
+		Version:       version,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if errorFormat != errorFormatText && errorFormat != errorFormatJSON {
+				return invalid(fmt.Errorf("unknown --error-format %q; use text or json", errorFormat))
+			}
+			silenceJSONUsage(cmd)
+			return startProfile()
+		},
 	}
 )
 
 // Execute runs the root command
 func Execute() error {
-	return rootCmd.Execute()
+	markInvalidArgs(rootCmd)
+	err := rootCmd.Execute()
+	if profileErr := stopProfile(); profileErr != nil && err == nil {
+		err = profileErr
+	}
+	return err
 }
 
 func init() {
@@ -44,6 +76,13 @@ func init() {
 		fmt.Sprintf("commit: %s\n", commit) +
 		fmt.Sprintf("built on: %s\n", buildDate))
 
+	rootCmd.PersistentFlags().StringVar(&errorFormat, "error-format", errorFormatText, "How a failure is printed to stderr: text, or json for scripts and editors")
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		silenceJSONUsage(cmd)
+		return invalid(err)
+	})
+	rootCmd.PersistentFlags().StringVar(&profilePrefix, "profile", "", "Write CPU and heap profiles of the run to <prefix>.cpu.pprof and <prefix>.heap.pprof")
+
 	// Add version command
 	var versionCmd = &cobra.Command{
 		Use:   "version",
@@ -59,4 +98,16 @@ func init() {
 
 	// Register subcommands
 	rootCmd.AddCommand(createCmd)
+	rootCmd.AddCommand(explainCmd)
+	rootCmd.AddCommand(resumeCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(fleetCmd)
+	rootCmd.AddCommand(splitCmd)
+	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(adoptCmd)
+	rootCmd.AddCommand(upgradeCmd)
+	rootCmd.AddCommand(templatesCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(mcpCmd)
 }
\ No newline at end of file
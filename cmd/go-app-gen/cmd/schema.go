@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+var schemaOutput string
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema [template-data|spec]",
+	Short: "Print the JSON Schema of the template data or of spec files",
+	Long: fmt.Sprintf(`Print a JSON Schema (draft 2020-12) generated from the generator's own types.
+
+template-data (the default) describes the fields templates are rendered with, TemplateData
+schema %d, for template pack authors; spec describes project spec files, for editors
+validating spec.yaml.
+
+Examples:
+  go-app-gen schema > template-data.schema.json
+  go-app-gen schema spec --output spec.schema.json`, generator.TemplateDataSchema),
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"template-data", "spec"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schema := generator.TemplateDataJSONSchema
+		if len(args) > 0 && args[0] == "spec" {
+			schema = generator.SpecJSONSchema
+		}
+		content, err := schema()
+		if err != nil {
+			return fmt.Errorf("failed to generate schema: %w", err)
+		}
+
+		if schemaOutput == "" {
+			_, err := os.Stdout.Write(content)
+			return err
+		}
+		if err := os.WriteFile(schemaOutput, content, 0644); err != nil {
+			return fmt.Errorf("failed to write schema: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	schemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "File to write the schema to instead of stdout")
+}
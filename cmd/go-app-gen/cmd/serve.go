@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nhalm/go-app-gen/internal/server"
+)
+
+var (
+	serveAddr        string
+	servePostProcess bool
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a web UI and API that generate projects as zip archives",
+	Long: `Serve a web UI, with a form for the project's name, domains, fields, database
+and features, and the JSON API behind it. POST /api/generate takes a project
+spec, the YAML or JSON of a create --spec file, and responds with the generated
+project as a zip archive; GET /api/options lists the features, databases, deploy
+targets and field types a spec can choose.
+
+Specs can only use the built-in templates: template, template_dirs,
+template_keys and messages are rejected. The archives are not post-processed
+unless --post-process is set, which needs Go and sqlc on the server; extract the
+archive and run go-app-gen resume in it to finish the project.
+
+Examples:
+  go-app-gen serve
+  go-app-gen serve --addr :8080 --post-process
+  curl -o shop.zip -d '{"name":"shop","domains":["product"],"features":["metrics"]}' \
+    http://localhost:8080/api/generate`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "localhost:8080", "Address to listen on")
+	serveCmd.Flags().BoolVar(&servePostProcess, "post-process", false, "Run go mod tidy, sqlc and the other post-generation tasks before archiving")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	srv := &http.Server{
+		Addr: serveAddr,
+		Handler: server.New(server.Options{
+			PostProcess: servePostProcess,
+			Version:     version,
+			Log:         os.Stdout,
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Printf("🌐 Serving the go-app-gen web UI on http://%s\n", serveAddr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
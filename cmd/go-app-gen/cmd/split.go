@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+// SplitOptions holds the flags of the split command
+type SplitOptions struct {
+	Namespace   string
+	AppName     string
+	ModuleName  string
+	ProjectDir  string
+	OutputDir   string
+	Features    []string
+	Description string
+	Author      string
+}
+
+var splitOptions SplitOptions
+
+var splitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Extract a bounded context of a generated project into its own service",
+	Long: `Extract a bounded context of a generated project into a new service.
+
+Run it in the project root. The new service is generated next to the project with
+the context's domains and then receives the project's code for them: the api,
+service and repository layers, schema, migrations, requests, protobuf definitions
+and client SDK, with imports rewritten to the new module. The project gets a
+client SDK for the service (pkg/<context>client) and both get an AsyncAPI
+contract for the context's events.
+
+The project is otherwise left as it is; the command prints what to remove from it
+once callers use the client.
+
+Examples:
+  go-app-gen split --domain billing
+  go-app-gen split --domain billing --name billing-service --module github.com/acme/billing-service`,
+	Args: cobra.NoArgs,
+	RunE: runSplit,
+}
+
+func init() {
+	splitCmd.Flags().StringVarP(&splitOptions.Namespace, "domain", "d", "", "Bounded context to extract (the namespace of its domains, e.g. billing)")
+	splitCmd.Flags().StringVar(&splitOptions.AppName, "name", "", "Name of the new service (default: the bounded context)")
+	splitCmd.Flags().StringVarP(&splitOptions.ModuleName, "module", "m", "", "Go module of the new service (default: the project module with its last element replaced by --name)")
+	splitCmd.Flags().StringVar(&splitOptions.ProjectDir, "project", ".", "Generated project to extract from")
+	splitCmd.Flags().StringVarP(&splitOptions.OutputDir, "output", "o", "", "Directory to create the service in (default: the project's parent directory)")
+	splitCmd.Flags().StringSliceVar(&splitOptions.Features, "features", nil, "Features of the new service (default: the features found in the project)")
+	splitCmd.Flags().StringVar(&splitOptions.Description, "description", "", "Service description")
+	splitCmd.Flags().StringVar(&splitOptions.Author, "author", "", "Author name, GitHub @handle or email")
+	_ = splitCmd.MarkFlagRequired("domain")
+}
+
+func runSplit(cmd *cobra.Command, args []string) error {
+	if namespace, _ := generator.ParseDomain(splitOptions.Namespace); namespace != "" {
+		return fmt.Errorf("--domain takes a bounded context, not a single domain: use %q", namespace)
+	}
+
+	projectDir, err := filepath.Abs(splitOptions.ProjectDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	outputDir := splitOptions.OutputDir
+	if outputDir == "" {
+		outputDir = filepath.Dir(projectDir)
+	}
+
+	var features []string
+	if cmd.Flags().Changed("features") {
+		features = append([]string{}, splitOptions.Features...)
+	}
+
+	gen := generator.New(outputDir)
+	result, err := gen.Split(generator.SplitConfig{
+		ProjectDir:  projectDir,
+		Namespace:   splitOptions.Namespace,
+		AppName:     splitOptions.AppName,
+		ModuleName:  splitOptions.ModuleName,
+		Features:    features,
+		Description: splitOptions.Description,
+		Author:      splitOptions.Author,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to split project: %w", err)
+	}
+
+	ns := splitOptions.Namespace
+	fmt.Printf("✅ Extracted '%s' into %s (module %s)\n", ns, result.ServiceDir, result.ModuleName)
+	fmt.Printf("   Domains: %s\n", strings.Join(result.Domains, ", "))
+	if len(result.Features) > 0 {
+		fmt.Printf("   Features: %s\n", strings.Join(result.Features, ", "))
+	}
+	for _, dir := range result.Copied {
+		fmt.Printf("   Copied %s\n", dir)
+	}
+	if result.ClientCreated {
+		fmt.Printf("📦 Added the %s client SDK to the project in %s\n", ns, result.ClientDir)
+	} else {
+		fmt.Printf("📦 The project already has the %s client SDK in %s\n", ns, result.ClientDir)
+	}
+	fmt.Printf("📜 Wrote the event contract to api/asyncapi.yaml in the service and %s in the project\n", result.Contract)
+	fmt.Println("")
+	fmt.Println("Next steps:")
+	fmt.Printf("   1. Start the service (cd %s && make up) and move the %s tables and their\n", result.ServiceDir, ns)
+	fmt.Printf("      schema_migrations_%s history to its database\n", ns)
+	fmt.Printf("   2. Switch the project's callers of internal/%s to %s\n", ns, result.ClientDir)
+	fmt.Printf("   3. Publish and consume the %s events over a broker as described in %s\n", ns, result.Contract)
+	fmt.Printf("   4. Remove internal/%s, its wiring in cmd/serve.go and its migrations from the project\n", ns)
+	return nil
+}
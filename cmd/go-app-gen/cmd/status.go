@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+var statusJSON bool
+
+var statusCmd = &cobra.Command{
+	Use:   "status [project-dir]",
+	Short: "Report the template versions and drift of a generated project",
+	Long: `Report how a generated project compares with its ` + generator.ManifestFile + `: whether
+the built-in templates and template packs changed since the generation, the
+enabled features, the generated files modified or deleted since, and the tools
+whose generated configuration drifted.
+
+With --json the report is printed as JSON, to collect it across many services.
+
+Examples:
+  go-app-gen status
+  go-app-gen status services/orders --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runStatus,
+}
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Print the report as JSON")
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	projectDir := "."
+	if len(args) > 0 {
+		projectDir = args[0]
+	}
+	status, err := generator.Status(projectDir)
+	if err != nil {
+		return err
+	}
+
+	if statusJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(status)
+	}
+
+	fmt.Printf("📦 %s\n", status.Module)
+	features := "none"
+	if len(status.Features) > 0 {
+		features = strings.Join(status.Features, ", ")
+	}
+	fmt.Printf("🧩 Features: %s\n", features)
+
+	fmt.Println("📐 Templates:")
+	for _, t := range status.Templates {
+		switch {
+		case t.UpToDate():
+			fmt.Printf("   %-20s up to date (%s)\n", t.Name, shortDigest(t.Current))
+		case t.Current == "":
+			fmt.Printf("   %-20s generated with %s, pack no longer found\n", t.Name, shortDigest(t.Generated))
+		default:
+			fmt.Printf("   %-20s changed since generation: %s → %s\n", t.Name, shortDigest(t.Generated), shortDigest(t.Current))
+		}
+	}
+
+	if len(status.Modified) == 0 && len(status.Deleted) == 0 {
+		fmt.Println("✅ No generated files changed since generation")
+	}
+	if len(status.Modified) > 0 {
+		fmt.Printf("✏️  Modified since generation (%d):\n", len(status.Modified))
+		for _, p := range status.Modified {
+			fmt.Printf("   %s\n", p)
+		}
+	}
+	if len(status.Deleted) > 0 {
+		fmt.Printf("🗑️  Deleted since generation (%d):\n", len(status.Deleted))
+		for _, p := range status.Deleted {
+			fmt.Printf("   %s\n", p)
+		}
+	}
+	if len(status.Drifted) > 0 {
+		fmt.Println("🔧 Drifted tool configuration:")
+		for _, d := range status.Drifted {
+			fmt.Printf("   %-20s %s\n", d.Tool, strings.Join(d.Files, ", "))
+		}
+	}
+
+	if len(status.Pending) > 0 {
+		fmt.Printf("⚠️  Post-processing incomplete (%s); run 'go-app-gen resume %s'\n", strings.Join(status.Pending, ", "), projectDir)
+	}
+	return nil
+}
+
+// shortDigest abbreviates a "sha256:…" digest for display
+func shortDigest(digest string) string {
+	if len(digest) > len("sha256:")+12 {
+		return digest[:len("sha256:")+12]
+	}
+	return digest
+}
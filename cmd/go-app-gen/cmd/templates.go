@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+// errPackTestsFailed is returned when golden tests of a template pack fail
+var errPackTestsFailed = errors.New("template pack tests failed")
+
+var (
+	templatesInitOutput string
+	templatesTestUpdate bool
+	templatesTestCache  bool
+)
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Author and test template packs",
+	Long: `Author and test template packs: directories of templates rendered with the same
+data and feature gating as the built-in templates, maintained outside this binary.`,
+}
+
+var templatesInitCmd = &cobra.Command{
+	Use:   "init <pack-name>",
+	Short: "Scaffold a new template pack",
+	Long: `Scaffold a new template pack with a manifest, example templates including one gated
+behind a pack feature, an authoring guide in README.md and golden tests whose golden
+files are rendered right away.
+
+Examples:
+  go-app-gen templates init acme-pack
+  go-app-gen templates init acme-pack --output ~/src`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gen := generator.New(templatesInitOutput)
+		pack, err := gen.InitPack(args[0], templatesInitOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create template pack: %w", err)
+		}
+
+		fmt.Printf("✅ Created template pack '%s' in %s\n", pack.Manifest.Name, pack.Dir)
+		fmt.Println("")
+		fmt.Println("Next steps:")
+		fmt.Printf("   1. Read %s for the template data and path placeholders\n", filepath.Join(pack.Dir, "README.md"))
+		fmt.Printf("   2. Replace the example templates in %s\n", filepath.Join(pack.Dir, "templates"))
+		fmt.Printf("   3. Run go-app-gen templates test %s --update and review the golden files\n", pack.Dir)
+		return nil
+	},
+}
+
+var templatesTestCmd = &cobra.Command{
+	Use:   "test [pack-dir]",
+	Short: "Run the golden tests of a template pack",
+	Long: `Render every test case in the pack's testdata/ directory and compare the output with
+its golden files. With --update the golden files are rewritten instead.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		pack, err := generator.LoadPack(dir)
+		if err != nil {
+			return err
+		}
+		for _, warning := range pack.Warnings {
+			fmt.Printf("⚠️  %s\n", warning)
+		}
+
+		gen := generator.New(dir)
+		if templatesTestCache {
+			if err := enableCache(gen); err != nil {
+				return err
+			}
+		}
+		results, err := gen.TestPack(pack, templatesTestUpdate)
+		if err != nil {
+			return fmt.Errorf("failed to test template pack: %w", err)
+		}
+		if templatesTestCache {
+			printCacheStats(gen)
+		}
+
+		failed := 0
+		for _, result := range results {
+			switch {
+			case result.Updated:
+				fmt.Printf("📝 %s: golden files updated\n", result.Name)
+			case len(result.Diffs) == 0:
+				fmt.Printf("✅ %s\n", result.Name)
+			default:
+				failed++
+				fmt.Printf("❌ %s\n", result.Name)
+				for _, diff := range result.Diffs {
+					fmt.Printf("   %s\n", diff)
+				}
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%w: %d of %d cases differ from golden (rerun with --update to accept)", errPackTestsFailed, failed, len(results))
+		}
+		return nil
+	},
+}
+
+var templatesChecksumsCmd = &cobra.Command{
+	Use:   "checksums [pack-dir]",
+	Short: "Write the checksums.txt of a template pack for signing",
+	Long: `Write checksums.txt with the sha256 of every file of the pack. Packs shipping it are
+checked against it when they are layered; sign it so that go-app-gen create
+--template-key can check who published the pack.
+
+Examples:
+  go-app-gen templates checksums acme-pack
+  minisign -Sm acme-pack/checksums.txt
+  cosign sign-blob --key cosign.key --output-signature acme-pack/checksums.txt.sig acme-pack/checksums.txt`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		pack, err := generator.LoadPack(dir)
+		if err != nil {
+			return err
+		}
+
+		digest, err := generator.WritePackChecksums(pack)
+		if err != nil {
+			return fmt.Errorf("failed to write checksums: %w", err)
+		}
+		fmt.Printf("✅ Wrote %s (%s)\n", filepath.Join(pack.Dir, generator.PackChecksumsFile), digest)
+		fmt.Println("   Sign it again whenever it changes; a stale signature fails verification")
+		return nil
+	},
+}
+
+func init() {
+	templatesInitCmd.Flags().StringVarP(&templatesInitOutput, "output", "o", ".", "Directory to create the pack in")
+	templatesTestCmd.Flags().BoolVar(&templatesTestUpdate, "update", false, "Rewrite the golden files with the current output")
+	templatesTestCmd.Flags().BoolVar(&templatesTestCache, "cache", false, "Reuse rendered templates from the user cache directory")
+	templatesCmd.AddCommand(templatesInitCmd)
+	templatesCmd.AddCommand(templatesTestCmd)
+	templatesCmd.AddCommand(templatesChecksumsCmd)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+var (
+	upgradeTemplate string
+	upgradeOriginal string
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade [project-dir]",
+	Short: "Re-apply newer templates to a generated project",
+	Long: `Re-render a generated project with this version's templates and the
+configuration recorded in its ` + generator.ManifestFile + `, and merge the result into it.
+
+The project is also rendered with the templates it was generated from: the
+release of go-app-gen the manifest records, fetched from GitHub, or the commit
+of the --template source it was created with. Generated files unchanged since
+then take the new render; files edited since receive the changes between the
+two renders, merged three ways, with conflict markers where they clash with
+the edits. Files the new templates add are created, and generated *_hooks.go
+files are left alone. The post-processing then runs again.
+
+When the original templates cannot be fetched, such as for a development
+build, pass them with --original, e.g. internal/generator/templates of a
+go-app-gen checkout at that version; without them edited files are kept as
+they are.
+
+Examples:
+  go-app-gen upgrade
+  go-app-gen upgrade services/orders
+  go-app-gen upgrade --template https://github.com/acme/templates@v2
+  go-app-gen upgrade --original ~/src/go-app-gen/internal/generator/templates`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectDir := "."
+		if len(args) > 0 {
+			projectDir = args[0]
+		}
+		projectDir, err := filepath.Abs(projectDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve project directory: %w", err)
+		}
+		cacheDir, err := generator.DefaultTemplateCacheDir()
+		if err != nil {
+			return err
+		}
+
+		result, err := generator.New(filepath.Dir(projectDir)).Upgrade(projectDir, generator.UpgradeOptions{
+			Version:  version,
+			Template: upgradeTemplate,
+			Original: upgradeOriginal,
+			CacheDir: cacheDir,
+		})
+		if result != nil {
+			printUpgrade(result)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to upgrade project: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	upgradeCmd.Flags().StringVar(&upgradeTemplate, "template", "", "Remote template source, URL[@branch, tag or commit], to upgrade a project created with --template to (default: the recorded commit)")
+	upgradeCmd.Flags().StringVar(&upgradeOriginal, "original", "", "Template tree the project was generated from, when it cannot be fetched")
+}
+
+// printUpgrade lists the files upgrading a project changed
+func printUpgrade(result *generator.UpgradeResult) {
+	for _, warning := range result.Warnings {
+		fmt.Printf("⚠️  %s\n", warning)
+	}
+	fmt.Printf("⬆️  Upgraded from go-app-gen %s to %s: %d files created, %d updated, %d merged\n",
+		cmp.Or(result.From, "unknown"), result.To, len(result.Created), len(result.Updated), len(result.Merged))
+	for _, p := range result.Created {
+		fmt.Printf("   created %s\n", p)
+	}
+	for _, p := range result.Updated {
+		fmt.Printf("   updated %s\n", p)
+	}
+	for _, p := range result.Merged {
+		fmt.Printf("   merged %s\n", p)
+	}
+	for _, p := range result.Conflicts {
+		fmt.Printf("⚠️  Conflict in %s: resolve the <<<<<<< markers by hand\n", p)
+	}
+	for _, p := range result.Kept {
+		fmt.Printf("   kept %s (edited, not merged without the original templates)\n", p)
+	}
+	for _, p := range result.Obsolete {
+		fmt.Printf("   %s is no longer generated; delete it if nothing uses it\n", p)
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/nhalm/go-app-gen/cmd/go-app-gen/cmd"
@@ -9,7 +8,7 @@ import (
 
 func main() {
 	if err := cmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		cmd.PrintError(os.Stderr, err)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
\ No newline at end of file
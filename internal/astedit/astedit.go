@@ -0,0 +1,572 @@
+// Package astedit merges additions to Go source files by their syntax rather
+// than their lines.
+//
+// A merge takes the base a file was generated from, ours (the file as the
+// user edited it) and theirs (the file as it would be generated now). When
+// theirs only adds to base, such as the registration of a new domain's routes,
+// each statement, literal element, field, spec and declaration it adds goes
+// into ours next to the code it follows in theirs. Code is compared by its
+// tokens, so the merge holds however the user formatted or commented the file,
+// and an addition ours already has is skipped, which makes a merge idempotent.
+package astedit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedChange is returned when theirs changes or removes code of
+// base instead of only adding to it
+var ErrUnsupportedChange = errors.New("change is not an addition")
+
+// listKind is the kind of code a list holds, which decides how additions are
+// separated from their neighbours
+type listKind int
+
+const (
+	declList listKind = iota
+	stmtList
+	eltList
+	fieldList
+	specList
+)
+
+// list is a sequence of code an addition can go into: the declarations of a
+// file, the statements of a block or case clause, the elements of a composite
+// literal, the fields of a struct or interface or the specs of a grouped
+// declaration
+type list struct {
+	kind listKind
+	// open and close are the offsets the items lie between
+	open, close int
+	items       []*item
+}
+
+// item is a declaration, statement, element, field or spec of a list
+type item struct {
+	start, end int
+	// key is the tokens of the item, shape the same with the content of its
+	// nested lists elided
+	key, shape string
+	lists      []*list
+}
+
+// edit inserts text at an offset of ours
+type edit struct {
+	offset int
+	text   string
+}
+
+// source is a parsed Go file
+type source struct {
+	src  []byte
+	file *ast.File
+	tf   *token.File
+}
+
+// Merge adds the code theirs adds to base into ours. It fails with
+// ErrUnsupportedChange when theirs differs from base by more than additions,
+// or when ours no longer has the code an addition follows.
+func Merge(base, ours, theirs []byte) ([]byte, error) {
+	b, err := parse(base)
+	if err != nil {
+		return nil, err
+	}
+	o, err := parse(ours)
+	if err != nil {
+		return nil, err
+	}
+	t, err := parse(theirs)
+	if err != nil {
+		return nil, err
+	}
+	if b.file.Name.Name != t.file.Name.Name {
+		return nil, fmt.Errorf("%w: package %s renamed to %s", ErrUnsupportedChange, b.file.Name.Name, t.file.Name.Name)
+	}
+
+	added, err := addedImports(b.file, t.file)
+	if err != nil {
+		return nil, err
+	}
+	var edits []edit
+	if err := mergeList(o, t, b.decls(), t.decls(), o.decls(), &edits); err != nil {
+		return nil, err
+	}
+
+	out := ours
+	if len(edits) > 0 {
+		sort.SliceStable(edits, func(i, j int) bool { return edits[i].offset > edits[j].offset })
+		for _, e := range edits {
+			out = splice(out, e.offset, e.text)
+		}
+	}
+	for _, spec := range added {
+		if out, err = addImport(out, spec); err != nil {
+			return nil, err
+		}
+	}
+	if bytes.Equal(out, ours) {
+		return ours, nil
+	}
+
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format merged source: %w", err)
+	}
+	return formatted, nil
+}
+
+// mergeList adds the items of t, a list of theirs, that its base list b lacks
+// to o, the list of ours, after the item they follow in t, and merges the
+// nested lists of the items t changed
+func mergeList(o, t *source, b, tl, ol *list, edits *[]edit) error {
+	if b.kind != tl.kind || b.kind != ol.kind {
+		return fmt.Errorf("%w: code reshaped", ErrUnsupportedChange)
+	}
+
+	toBase := match(tl.items, b.items)
+	kept := make([]bool, len(b.items))
+	for _, bi := range toBase {
+		if bi >= 0 {
+			kept[bi] = true
+		}
+	}
+	for bi, k := range kept {
+		if !k {
+			return fmt.Errorf("%w: %q removed or changed", ErrUnsupportedChange, b.items[bi].shape)
+		}
+	}
+	toOurs := match(b.items, ol.items)
+
+	var anchor *item
+	lost := false
+	var pending []string
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		if lost {
+			return fmt.Errorf("%w: the code %q follows is gone", ErrUnsupportedChange, pending[0])
+		}
+		*edits = append(*edits, o.insert(ol, anchor, pending))
+		pending = nil
+		return nil
+	}
+
+	for ti, it := range tl.items {
+		bi := toBase[ti]
+		if bi < 0 {
+			if !ol.has(it.key) {
+				pending = append(pending, string(t.src[it.start:it.end]))
+			}
+			continue
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+
+		bItem := b.items[bi]
+		oi := toOurs[bi]
+		if oi < 0 {
+			if it.key != bItem.key {
+				return fmt.Errorf("%w: the code %q adds to is gone", ErrUnsupportedChange, it.shape)
+			}
+			lost = true
+			continue
+		}
+		oItem := ol.items[oi]
+		if it.key != bItem.key {
+			if len(it.lists) != len(bItem.lists) || len(it.lists) != len(oItem.lists) {
+				return fmt.Errorf("%w: %q reshaped", ErrUnsupportedChange, it.shape)
+			}
+			for i := range it.lists {
+				if err := mergeList(o, t, bItem.lists[i], it.lists[i], oItem.lists[i], edits); err != nil {
+					return err
+				}
+			}
+		}
+		anchor, lost = oItem, false
+	}
+	return flush()
+}
+
+// match pairs the items of a and b with the same shape along their longest
+// common subsequence, returning the index in b of the pair of each item of a,
+// or -1
+func match(a, b []*item) []int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].shape == b[j].shape {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	pairs := make([]int, len(a))
+	for i := range pairs {
+		pairs[i] = -1
+	}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].shape == b[j].shape:
+			pairs[i] = j
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// has reports whether the list has an item with key
+func (l *list) has(key string) bool {
+	for _, it := range l.items {
+		if it.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// insert returns the edit adding texts to l after anchor, or at its start
+// without one
+func (s *source) insert(l *list, anchor *item, texts []string) edit {
+	if l.kind == eltList {
+		return s.insertElts(l, anchor, texts)
+	}
+
+	sep := "\n"
+	if l.kind == declList {
+		sep = "\n\n"
+	}
+	offset := l.open
+	if anchor != nil {
+		offset = s.lineEnd(anchor.end)
+	}
+	return edit{offset: offset, text: sep + strings.Join(texts, sep)}
+}
+
+// insertElts returns the edit adding texts to the elements of a composite
+// literal, on lines of their own when the literal has one element per line
+func (s *source) insertElts(l *list, anchor *item, texts []string) edit {
+	if anchor == nil {
+		switch {
+		case len(l.items) == 0:
+			return edit{offset: l.open, text: strings.Join(texts, ", ")}
+		case s.restIsBlank(l.open):
+			return edit{offset: s.lineEnd(l.open), text: "\n" + strings.Join(texts, ",\n") + ","}
+		default:
+			return edit{offset: l.open, text: strings.Join(texts, ", ") + ", "}
+		}
+	}
+
+	p := anchor.end
+	for p < len(s.src) && (s.src[p] == ' ' || s.src[p] == '\t') {
+		p++
+	}
+	if p >= len(s.src) || s.src[p] != ',' {
+		return edit{offset: anchor.end, text: ", " + strings.Join(texts, ", ")}
+	}
+	if s.restIsBlank(p + 1) {
+		return edit{offset: s.lineEnd(p + 1), text: "\n" + strings.Join(texts, ",\n") + ","}
+	}
+	return edit{offset: p + 1, text: " " + strings.Join(texts, ", ") + ","}
+}
+
+// restIsBlank reports whether the line holds nothing but a comment from offset on
+func (s *source) restIsBlank(offset int) bool {
+	rest := strings.TrimSpace(string(s.src[offset:s.lineEnd(offset)]))
+	return rest == "" || strings.HasPrefix(rest, "//")
+}
+
+// lineEnd returns the offset of the end of the line offset is on, past a
+// trailing comment, or offset itself when code follows it on the line
+func (s *source) lineEnd(offset int) int {
+	end := bytes.IndexByte(s.src[offset:], '\n')
+	if end < 0 {
+		end = len(s.src) - offset
+	}
+	rest := strings.TrimSpace(string(s.src[offset : offset+end]))
+	if rest != "" && !strings.HasPrefix(rest, "//") {
+		return offset
+	}
+	return offset + end
+}
+
+// parse parses src as a Go file, keeping comments
+func parse(src []byte) (*source, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source: %w", err)
+	}
+	return &source{src: src, file: file, tf: fset.File(file.Pos())}, nil
+}
+
+// offset returns the byte offset of pos
+func (s *source) offset(pos token.Pos) int {
+	return s.tf.Offset(pos)
+}
+
+// decls returns the declarations of the file but its imports, which start
+// after the last import
+func (s *source) decls() *list {
+	l := &list{kind: declList, open: s.offset(s.file.Name.End()), close: len(s.src)}
+	for _, decl := range s.file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			l.open = s.offset(gen.End())
+			continue
+		}
+		l.items = append(l.items, s.item(decl))
+	}
+	return l
+}
+
+// newList returns the list of nodes between open and close
+func (s *source) newList(kind listKind, open, close token.Pos, nodes []ast.Node) *list {
+	l := &list{kind: kind, open: s.offset(open), close: s.offset(close)}
+	for _, n := range nodes {
+		l.items = append(l.items, s.item(n))
+	}
+	return l
+}
+
+// item returns the item of a node, with its doc comment
+func (s *source) item(n ast.Node) *item {
+	it := &item{start: s.offset(n.Pos()), end: s.offset(n.End()), lists: s.lists(n)}
+	if doc := docComment(n); doc != nil {
+		it.start = s.offset(doc.Pos())
+	}
+	it.key = normalize(s.src[it.start:it.end])
+
+	var shape []byte
+	next := it.start
+	for _, l := range it.lists {
+		shape = append(shape, s.src[next:l.open]...)
+		shape = append(shape, " ... "...)
+		next = l.close
+	}
+	it.shape = normalize(append(shape, s.src[next:it.end]...))
+	return it
+}
+
+// lists returns the outermost lists nested in a node, in source order
+func (s *source) lists(n ast.Node) []*list {
+	var lists []*list
+	ast.Inspect(n, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.BlockStmt:
+			lists = append(lists, s.newList(stmtList, node.Lbrace+1, node.Rbrace, stmts(node.List)))
+			return false
+		case *ast.CaseClause:
+			lists = append(lists, s.newList(stmtList, node.Colon+1, node.End(), stmts(node.Body)))
+			return false
+		case *ast.CommClause:
+			lists = append(lists, s.newList(stmtList, node.Colon+1, node.End(), stmts(node.Body)))
+			return false
+		case *ast.CompositeLit:
+			if node.Type != nil {
+				lists = append(lists, s.lists(node.Type)...)
+			}
+			elts := make([]ast.Node, len(node.Elts))
+			for i, e := range node.Elts {
+				elts[i] = e
+			}
+			lists = append(lists, s.newList(eltList, node.Lbrace+1, node.Rbrace, elts))
+			return false
+		case *ast.StructType:
+			lists = append(lists, s.fields(node.Fields))
+			return false
+		case *ast.InterfaceType:
+			lists = append(lists, s.fields(node.Methods))
+			return false
+		case *ast.GenDecl:
+			if !node.Lparen.IsValid() {
+				return true
+			}
+			specs := make([]ast.Node, len(node.Specs))
+			for i, spec := range node.Specs {
+				specs[i] = spec
+			}
+			lists = append(lists, s.newList(specList, node.Lparen+1, node.Rparen, specs))
+			return false
+		}
+		return true
+	})
+	return lists
+}
+
+// fields returns the list of the fields of a struct or the methods of an interface
+func (s *source) fields(fl *ast.FieldList) *list {
+	fields := make([]ast.Node, len(fl.List))
+	for i, f := range fl.List {
+		fields[i] = f
+	}
+	return s.newList(fieldList, fl.Opening+1, fl.Closing, fields)
+}
+
+// stmts returns statements as nodes
+func stmts(list []ast.Stmt) []ast.Node {
+	nodes := make([]ast.Node, len(list))
+	for i, stmt := range list {
+		nodes[i] = stmt
+	}
+	return nodes
+}
+
+// docComment returns the doc comment of a declaration, field or spec
+func docComment(n ast.Node) *ast.CommentGroup {
+	switch n := n.(type) {
+	case *ast.FuncDecl:
+		return n.Doc
+	case *ast.GenDecl:
+		return n.Doc
+	case *ast.Field:
+		return n.Doc
+	case *ast.ValueSpec:
+		return n.Doc
+	case *ast.TypeSpec:
+		return n.Doc
+	}
+	return nil
+}
+
+// normalize returns the tokens of src separated by spaces, without the
+// comments, the semicolons gofmt leaves out and the trailing commas of lists
+// broken over lines, so code compares the same however it is formatted
+func normalize(src []byte) string {
+	fset := token.NewFileSet()
+	var sc scanner.Scanner
+	sc.Init(fset.AddFile("", -1, len(src)), src, nil, 0)
+
+	var tokens []string
+	var prev token.Token
+	for {
+		_, tok, lit := sc.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		if prev == token.COMMA && (tok == token.RBRACE || tok == token.RPAREN || tok == token.RBRACK) {
+			tokens = tokens[:len(tokens)-1]
+		}
+		if lit == "" {
+			lit = tok.String()
+		}
+		tokens = append(tokens, lit)
+		prev = tok
+	}
+	return strings.Join(tokens, " ")
+}
+
+// importSpec is an import of a file, with its name if it has one
+type importSpec struct {
+	name, path string
+}
+
+// imports returns the imports of a file
+func imports(file *ast.File) []importSpec {
+	var specs []importSpec
+	for _, imp := range file.Imports {
+		spec := importSpec{}
+		spec.path, _ = strconv.Unquote(imp.Path.Value)
+		if imp.Name != nil {
+			spec.name = imp.Name.Name
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// addedImports returns the imports theirs adds to base, failing when it
+// removes one
+func addedImports(base, theirs *ast.File) ([]importSpec, error) {
+	before, after := imports(base), imports(theirs)
+	for _, spec := range before {
+		if !containsImport(after, spec) {
+			return nil, fmt.Errorf("%w: import %q removed", ErrUnsupportedChange, spec.path)
+		}
+	}
+	var added []importSpec
+	for _, spec := range after {
+		if !containsImport(before, spec) {
+			added = append(added, spec)
+		}
+	}
+	return added, nil
+}
+
+// containsImport reports whether specs has spec
+func containsImport(specs []importSpec, spec importSpec) bool {
+	for _, s := range specs {
+		if s == spec {
+			return true
+		}
+	}
+	return false
+}
+
+// addImport adds an import to src unless it already imports the path
+func addImport(src []byte, spec importSpec) ([]byte, error) {
+	s, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	for _, imp := range imports(s.file) {
+		if imp.path == spec.path {
+			return src, nil
+		}
+	}
+
+	text := strconv.Quote(spec.path)
+	if spec.name != "" {
+		text = spec.name + " " + text
+	}
+
+	for _, decl := range s.file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+
+		if gen.Lparen.IsValid() {
+			return splice(src, s.offset(gen.Rparen), "\t"+text+"\n"), nil
+		}
+
+		// Single-line import: turn it into a grouped import
+		start, end := s.offset(gen.Pos()), s.offset(gen.End())
+		existing := string(src[s.offset(gen.Specs[0].Pos()):end])
+		grouped := "import (\n\t" + existing + "\n\t" + text + "\n)"
+		return append(append(append([]byte{}, src[:start]...), grouped...), src[end:]...), nil
+	}
+
+	// No imports yet: add one after the package clause
+	return splice(src, s.offset(s.file.Name.End()), "\n\nimport "+text+"\n"), nil
+}
+
+// splice inserts text at offset
+func splice(src []byte, offset int, text string) []byte {
+	out := make([]byte, 0, len(src)+len(text))
+	out = append(out, src[:offset]...)
+	out = append(out, text...)
+	return append(out, src[offset:]...)
+}
@@ -0,0 +1,293 @@
+package astedit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// routes returns a routes.go of the router with the registration lines of
+// body between its opening and closing lines
+func routes(imports, open, body, close string) string {
+	return "package api\n\nimport (\n" + imports + ")\n\n// RegisterRoutes registers all API routes\n" + open + body + close
+}
+
+var mergeTests = []struct {
+	name                      string
+	base, ours, theirs, wants string
+}{
+	{
+		name: "chi routes with the markers removed",
+		base: routes("\t\"github.com/go-chi/chi/v5\"\n",
+			"func RegisterRoutes(r chi.Router, handler *Handler) {\n\tr.Route(\"/api/v1\", func(r chi.Router) {\n",
+			"\t\t// BEGIN go-app-gen routes\n\t\tRegisterProductRoutes(r, handler)\n\t\t// END go-app-gen routes\n",
+			"\t})\n}\n"),
+		ours: routes("\t\"github.com/go-chi/chi/v5\"\n\t\"github.com/go-chi/chi/v5/middleware\"\n",
+			"func RegisterRoutes(r chi.Router, handler *Handler) {\n\tr.Route(\"/api/v1\", func(r chi.Router) {\n",
+			"\t\tr.Use(middleware.Logger)\n\t\tRegisterProductRoutes(r, handler)\n\t\tr.Get(\"/status\", handler.Status) // added by hand\n",
+			"\t})\n}\n"),
+		theirs: routes("\t\"github.com/go-chi/chi/v5\"\n",
+			"func RegisterRoutes(r chi.Router, handler *Handler) {\n\tr.Route(\"/api/v1\", func(r chi.Router) {\n",
+			"\t\t// BEGIN go-app-gen routes\n\t\tRegisterProductRoutes(r, handler)\n\t\tRegisterOrderRoutes(r, handler)\n\t\t// END go-app-gen routes\n",
+			"\t})\n}\n"),
+		wants: routes("\t\"github.com/go-chi/chi/v5\"\n\t\"github.com/go-chi/chi/v5/middleware\"\n",
+			"func RegisterRoutes(r chi.Router, handler *Handler) {\n\tr.Route(\"/api/v1\", func(r chi.Router) {\n",
+			"\t\tr.Use(middleware.Logger)\n\t\tRegisterProductRoutes(r, handler)\n\t\tRegisterOrderRoutes(r, handler)\n\t\tr.Get(\"/status\", handler.Status) // added by hand\n",
+			"\t})\n}\n"),
+	},
+	{
+		name: "echo routes after a route added by hand",
+		base: routes("\t\"github.com/labstack/echo/v4\"\n",
+			"func RegisterRoutes(g *echo.Group, handler *Handler) {\n\tg = g.Group(\"/api/v1\")\n",
+			"\t// BEGIN go-app-gen routes\n\tRegisterProductRoutes(g, handler)\n\t// END go-app-gen routes\n",
+			"}\n"),
+		ours: routes("\t\"github.com/labstack/echo/v4\"\n",
+			"func RegisterRoutes(g *echo.Group, handler *Handler) {\n\tg = g.Group(\"/api/v1\")\n",
+			"\t// BEGIN go-app-gen routes\n\tRegisterProductRoutes(g, handler)\n\tg.GET(\"/status\", handler.Status)\n\t// END go-app-gen routes\n",
+			"}\n"),
+		theirs: routes("\t\"github.com/labstack/echo/v4\"\n",
+			"func RegisterRoutes(g *echo.Group, handler *Handler) {\n\tg = g.Group(\"/api/v1\")\n",
+			"\t// BEGIN go-app-gen routes\n\tRegisterProductRoutes(g, handler)\n\tRegisterOrderRoutes(g, handler)\n\t// END go-app-gen routes\n",
+			"}\n"),
+		wants: routes("\t\"github.com/labstack/echo/v4\"\n",
+			"func RegisterRoutes(g *echo.Group, handler *Handler) {\n\tg = g.Group(\"/api/v1\")\n",
+			"\t// BEGIN go-app-gen routes\n\tRegisterProductRoutes(g, handler)\n\tRegisterOrderRoutes(g, handler)\n\tg.GET(\"/status\", handler.Status)\n\t// END go-app-gen routes\n",
+			"}\n"),
+	},
+	{
+		name: "gin routes written on one line",
+		base: routes("\t\"github.com/gin-gonic/gin\"\n",
+			"func RegisterRoutes(r gin.IRouter, handler *Handler) {\n\tr = r.Group(\"/api/v1\")\n",
+			"\t// BEGIN go-app-gen routes\n\tRegisterProductRoutes(r, handler)\n\t// END go-app-gen routes\n",
+			"}\n"),
+		ours: routes("\t\"github.com/gin-gonic/gin\"\n",
+			"func RegisterRoutes(r gin.IRouter, handler *Handler) {\n",
+			"\tr = r.Group(\"/api/v1\"); RegisterProductRoutes(r, handler)\n",
+			"}\n"),
+		theirs: routes("\t\"github.com/gin-gonic/gin\"\n",
+			"func RegisterRoutes(r gin.IRouter, handler *Handler) {\n\tr = r.Group(\"/api/v1\")\n",
+			"\t// BEGIN go-app-gen routes\n\tRegisterProductRoutes(r, handler)\n\tRegisterOrderRoutes(r, handler)\n\t// END go-app-gen routes\n",
+			"}\n"),
+		wants: routes("\t\"github.com/gin-gonic/gin\"\n",
+			"func RegisterRoutes(r gin.IRouter, handler *Handler) {\n",
+			"\tr = r.Group(\"/api/v1\")\n\tRegisterProductRoutes(r, handler)\n\tRegisterOrderRoutes(r, handler)\n",
+			"}\n"),
+	},
+	{
+		name: "stdlib routes with the first registration",
+		base: routes("\t\"example.com/shop/internal/utils\"\n",
+			"func RegisterRoutes(r *utils.Router, handler *Handler) {\n\tr = r.Route(\"/api/v1\")\n",
+			"\t// BEGIN go-app-gen routes\n\t// END go-app-gen routes\n",
+			"}\n"),
+		ours: routes("\t\"example.com/shop/internal/utils\"\n",
+			"func RegisterRoutes(r *utils.Router, handler *Handler) {\n\tr = r.Route( \"/api/v1\" )\n",
+			"\tr.HandleFunc(\"GET /status\", handler.Status)\n",
+			"}\n"),
+		theirs: routes("\t\"example.com/shop/internal/utils\"\n",
+			"func RegisterRoutes(r *utils.Router, handler *Handler) {\n\tr = r.Route(\"/api/v1\")\n",
+			"\t// BEGIN go-app-gen routes\n\tRegisterOrderRoutes(r, handler)\n\t// END go-app-gen routes\n",
+			"}\n"),
+		wants: routes("\t\"example.com/shop/internal/utils\"\n",
+			"func RegisterRoutes(r *utils.Router, handler *Handler) {\n\tr = r.Route(\"/api/v1\")\n",
+			"\tRegisterOrderRoutes(r, handler)\n\tr.HandleFunc(\"GET /status\", handler.Status)\n",
+			"}\n"),
+	},
+	{
+		name: "literal elements, imports and declarations",
+		base: `package cmd
+
+import "example.com/shop/internal/events"
+
+var sources = []source{
+	{topic: events.Topic, schemas: events.Schemas},
+}
+`,
+		ours: `package cmd
+
+import "example.com/shop/internal/events"
+
+// sources are the topics of the bounded contexts
+var sources = []source{{topic: events.Topic, schemas: events.Schemas}} // one per context
+
+func extra() {}
+`,
+		theirs: `package cmd
+
+import (
+	"example.com/shop/internal/events"
+	ordersevents "example.com/shop/internal/orders/events"
+)
+
+var sources = []source{
+	{topic: events.Topic, schemas: events.Schemas},
+	{topic: ordersevents.Topic, schemas: ordersevents.Schemas},
+}
+
+// orders is the bounded context of the orders
+type orders struct {
+	name string
+}
+`,
+		wants: `package cmd
+
+import (
+	"example.com/shop/internal/events"
+	ordersevents "example.com/shop/internal/orders/events"
+)
+
+// sources are the topics of the bounded contexts
+var sources = []source{{topic: events.Topic, schemas: events.Schemas}, {topic: ordersevents.Topic, schemas: ordersevents.Schemas}} // one per context
+
+// orders is the bounded context of the orders
+type orders struct {
+	name string
+}
+
+func extra() {}
+`,
+	},
+	{
+		name: "struct fields and switch cases",
+		base: `package api
+
+type Handler struct {
+	products *ProductHandler
+}
+
+func (h *Handler) route(name string) {
+	switch name {
+	case "products":
+		h.products.Register()
+	}
+}
+`,
+		ours: `package api
+
+type Handler struct {
+	products *ProductHandler
+	logger   *slog.Logger // added by hand
+}
+
+func (h *Handler) route(name string) {
+	switch name {
+	case "products":
+		h.logger.Info("products")
+		h.products.Register()
+	}
+}
+`,
+		theirs: `package api
+
+type Handler struct {
+	products *ProductHandler
+	orders   *OrderHandler
+}
+
+func (h *Handler) route(name string) {
+	switch name {
+	case "products":
+		h.products.Register()
+	case "orders":
+		h.orders.Register()
+	}
+}
+`,
+		wants: `package api
+
+type Handler struct {
+	products *ProductHandler
+	orders   *OrderHandler
+	logger   *slog.Logger // added by hand
+}
+
+func (h *Handler) route(name string) {
+	switch name {
+	case "products":
+		h.logger.Info("products")
+		h.products.Register()
+	case "orders":
+		h.orders.Register()
+	}
+}
+`,
+	},
+}
+
+func TestMerge(t *testing.T) {
+	for _, tt := range mergeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Merge([]byte(tt.base), []byte(tt.ours), []byte(tt.theirs))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.wants {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, tt.wants)
+			}
+		})
+	}
+}
+
+// TestMergeIsIdempotent merges every case into its own result, which must
+// come back unchanged
+func TestMergeIsIdempotent(t *testing.T) {
+	for _, tt := range mergeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Merge([]byte(tt.base), []byte(tt.wants), []byte(tt.theirs))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.wants {
+				t.Fatalf("expected the merged file unchanged, got:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestMergeRejectsChanges(t *testing.T) {
+	const base = `package api
+
+func RegisterRoutes(r Router, handler *Handler) {
+	r = r.Group("/api/v1")
+	RegisterProductRoutes(r, handler)
+}
+`
+	withImport := strings.Replace(base, "package api\n", "package api\n\nimport \"net/http\"\n", 1)
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+	}{
+		{
+			name:   "statement removed",
+			base:   base,
+			ours:   base,
+			theirs: strings.Replace(base, "\tRegisterProductRoutes(r, handler)\n", "", 1),
+		},
+		{
+			name:   "statement changed",
+			base:   base,
+			ours:   base,
+			theirs: strings.Replace(base, "/api/v1", "/api/v2", 1),
+		},
+		{
+			name:   "import removed",
+			base:   withImport,
+			ours:   withImport,
+			theirs: base,
+		},
+		{
+			name:   "code the addition follows removed",
+			base:   base,
+			ours:   strings.Replace(base, "\tRegisterProductRoutes(r, handler)\n", "", 1),
+			theirs: strings.Replace(base, "\tRegisterProductRoutes(r, handler)\n", "\tRegisterProductRoutes(r, handler)\n\tRegisterOrderRoutes(r, handler)\n", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Merge([]byte(tt.base), []byte(tt.ours), []byte(tt.theirs))
+			if !errors.Is(err, ErrUnsupportedChange) {
+				t.Fatalf("expected ErrUnsupportedChange, got %v", err)
+			}
+		})
+	}
+}
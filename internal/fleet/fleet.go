@@ -0,0 +1,319 @@
+// Package fleet upgrades many generated services at once.
+//
+// A fleet manifest lists the repositories of the services. Each one is cloned,
+// updated and verified with the manifest's commands. When it changed and
+// passed verification, the change is committed to a branch that is pushed and
+// proposed through the manifest's SCM provider.
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidManifest is returned for a fleet manifest that cannot be run
+var ErrInvalidManifest = errors.New("invalid fleet manifest")
+
+// DefaultBranch is the branch upgrades are committed to unless the manifest names one
+const DefaultBranch = "go-app-gen/upgrade"
+
+// DefaultVerify are the verification commands unless the manifest lists its own
+var DefaultVerify = []string{"go build ./...", "go vet ./...", "go test ./..."}
+
+// Manifest is a fleet.yaml
+//
+// Example fleet manifest:
+//
+//	branch: platform/go-app-gen-upgrade
+//	update:
+//	  - go-app-gen upgrade
+//	verify:
+//	  - make check
+//	scm:
+//	  provider: github
+//	  reviewers: [platform-team]
+//	repos:
+//	  - name: orders
+//	    url: git@github.com:acme/orders.git
+//	  - name: billing
+//	    url: git@github.com:acme/monorepo.git
+//	    dir: services/billing
+type Manifest struct {
+	// Workdir is where repositories are cloned, a temporary directory if empty
+	Workdir string `yaml:"workdir"`
+	Branch  string `yaml:"branch"`
+	// Update are the shell commands that update a service, run in its project directory
+	Update []string `yaml:"update"`
+	// Verify are the shell commands the updated service must pass
+	Verify []string `yaml:"verify"`
+	// Title and Body describe the commit and the pull request
+	Title string    `yaml:"title"`
+	Body  string    `yaml:"body"`
+	SCM   SCMConfig `yaml:"scm"`
+	Repos []Repo    `yaml:"repos"`
+}
+
+// Repo is a repository of the fleet
+type Repo struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Base is the branch the upgrade starts from and is proposed against,
+	// the repository's default branch if empty
+	Base string `yaml:"base"`
+	// Dir is the generated project inside the repository, "." if empty
+	Dir string `yaml:"dir"`
+}
+
+// Outcomes of a repository's upgrade
+const (
+	OutcomeUpToDate = "up-to-date" // the update changed nothing
+	OutcomeFailed   = "failed"
+	OutcomeVerified = "verified" // changed and verified, not pushed in a dry run
+	OutcomePushed   = "pushed"   // the branch was pushed, the provider opens no pull request
+	OutcomeProposed = "proposed" // a pull request was opened
+)
+
+// Result is the upgrade of one repository
+type Result struct {
+	Repo    string
+	Outcome string
+	Step    string // the step that failed
+	URL     string // of the pull request
+	Err     error
+}
+
+// Options tune an upgrade run
+type Options struct {
+	// DryRun updates and verifies the repositories without pushing
+	DryRun bool
+	// Log receives the output of the commands, discarded if nil
+	Log io.Writer
+}
+
+// LoadManifest reads and validates a fleet manifest, rejecting unknown keys
+func LoadManifest(path string) (*Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet manifest: %w", err)
+	}
+
+	var m Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse fleet manifest %s: %w", path, err)
+	}
+	if m.Workdir != "" && !filepath.IsAbs(m.Workdir) {
+		m.Workdir = filepath.Join(filepath.Dir(path), m.Workdir)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrInvalidManifest, path, err)
+	}
+	return &m, nil
+}
+
+// validate checks the manifest and fills in the defaults
+func (m *Manifest) validate() error {
+	if len(m.Repos) == 0 {
+		return errors.New("no repos")
+	}
+	if len(m.Update) == 0 {
+		return errors.New("no update commands")
+	}
+	if _, err := providerFor(m.SCM.Provider); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for i, r := range m.Repos {
+		if r.URL == "" {
+			return fmt.Errorf("repo %d has no url", i+1)
+		}
+		if r.Name == "" {
+			m.Repos[i].Name = strings.TrimSuffix(filepath.Base(r.URL), ".git")
+		}
+		if seen[m.Repos[i].Name] {
+			return fmt.Errorf("repo %q is listed twice", m.Repos[i].Name)
+		}
+		seen[m.Repos[i].Name] = true
+	}
+
+	if m.Branch == "" {
+		m.Branch = DefaultBranch
+	}
+	if len(m.Verify) == 0 {
+		m.Verify = DefaultVerify
+	}
+	if m.Title == "" {
+		m.Title = "Upgrade generated code with go-app-gen"
+	}
+	if m.Body == "" {
+		m.Body = "Re-applies the go-app-gen templates to this service.\n\nVerified with:\n- " + strings.Join(m.Verify, "\n- ")
+	}
+	return nil
+}
+
+// Upgrade runs the manifest against every repository, one after the other.
+// A repository that fails does not stop the others; see each Result.
+func Upgrade(ctx context.Context, m *Manifest, opts Options) ([]Result, error) {
+	provider, err := providerFor(m.SCM.Provider)
+	if err != nil {
+		return nil, err
+	}
+	workdir := m.Workdir
+	if workdir == "" {
+		if workdir, err = os.MkdirTemp("", "go-app-gen-fleet-"); err != nil {
+			return nil, fmt.Errorf("failed to create fleet workdir: %w", err)
+		}
+		defer os.RemoveAll(workdir)
+	} else if err := os.MkdirAll(workdir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create fleet workdir: %w", err)
+	}
+	if opts.Log == nil {
+		opts.Log = io.Discard
+	}
+
+	results := make([]Result, 0, len(m.Repos))
+	for _, repo := range m.Repos {
+		u := &upgrade{manifest: m, repo: repo, provider: provider, opts: opts, dir: filepath.Join(workdir, repo.Name)}
+		results = append(results, u.run(ctx))
+	}
+	return results, nil
+}
+
+// upgrade is the upgrade of one repository
+type upgrade struct {
+	manifest *Manifest
+	repo     Repo
+	provider Provider
+	opts     Options
+	dir      string // the clone
+}
+
+// run clones, updates, verifies and proposes the repository
+func (u *upgrade) run(ctx context.Context) Result {
+	result := Result{Repo: u.repo.Name}
+	fail := func(step string, err error) Result {
+		result.Outcome, result.Step, result.Err = OutcomeFailed, step, err
+		return result
+	}
+
+	if err := os.RemoveAll(u.dir); err != nil {
+		return fail("clone", err)
+	}
+	clone := []string{"clone", "--quiet"}
+	if u.repo.Base != "" {
+		clone = append(clone, "--branch", u.repo.Base)
+	}
+	if err := u.git(ctx, "", append(clone, u.repo.URL, u.dir)...); err != nil {
+		return fail("clone", err)
+	}
+	if err := u.git(ctx, u.dir, "checkout", "--quiet", "-b", u.manifest.Branch); err != nil {
+		return fail("branch", err)
+	}
+
+	project := filepath.Join(u.dir, filepath.FromSlash(u.repo.Dir))
+	for _, command := range u.manifest.Update {
+		if err := u.shell(ctx, project, command); err != nil {
+			return fail("update", err)
+		}
+	}
+	changes, err := u.output(ctx, "status", "--porcelain")
+	if err != nil {
+		return fail("update", err)
+	}
+	if len(bytes.TrimSpace(changes)) == 0 {
+		result.Outcome = OutcomeUpToDate
+		return result
+	}
+
+	for _, command := range u.manifest.Verify {
+		if err := u.shell(ctx, project, command); err != nil {
+			return fail("verify", err)
+		}
+	}
+	if err := u.git(ctx, u.dir, "add", "-A"); err != nil {
+		return fail("commit", err)
+	}
+	if err := u.git(ctx, u.dir, "commit", "--quiet", "-m", u.manifest.Title, "-m", u.manifest.Body); err != nil {
+		return fail("commit", err)
+	}
+	if u.opts.DryRun {
+		result.Outcome = OutcomeVerified
+		return result
+	}
+
+	if err := u.git(ctx, u.dir, "push", "--quiet", "--force", "-u", "origin", u.manifest.Branch); err != nil {
+		return fail("push", err)
+	}
+	base := u.repo.Base
+	if base == "" {
+		ref, err := u.output(ctx, "rev-parse", "--abbrev-ref", "origin/HEAD")
+		if err != nil {
+			return fail("propose", err)
+		}
+		base = strings.TrimPrefix(strings.TrimSpace(string(ref)), "origin/")
+	}
+	url, err := u.provider.Propose(ctx, u.dir, Proposal{
+		Head:      u.manifest.Branch,
+		Base:      base,
+		Title:     u.manifest.Title,
+		Body:      u.manifest.Body,
+		Reviewers: u.manifest.SCM.Reviewers,
+		Labels:    u.manifest.SCM.Labels,
+	})
+	if err != nil {
+		return fail("propose", err)
+	}
+	result.Outcome, result.URL = OutcomePushed, url
+	if url != "" {
+		result.Outcome = OutcomeProposed
+	}
+	return result
+}
+
+// git runs git in dir, the current directory if empty
+func (u *upgrade) git(ctx context.Context, dir string, args ...string) error {
+	return u.exec(ctx, dir, "git", args...)
+}
+
+// output returns the output of git run in the clone
+func (u *upgrade) output(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = u.dir
+	cmd.Stderr = u.opts.Log
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return out, nil
+}
+
+// shell runs a command of the manifest with sh in dir
+func (u *upgrade) shell(ctx context.Context, dir, command string) error {
+	return u.exec(ctx, dir, "sh", "-c", command)
+}
+
+// exec runs a command, sending its output to the log
+func (u *upgrade) exec(ctx context.Context, dir, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Stdout = u.opts.Log
+	cmd.Stderr = u.opts.Log
+	if err := cmd.Run(); err != nil {
+		if name == "sh" {
+			return fmt.Errorf("%s: %w", args[1], err)
+		}
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
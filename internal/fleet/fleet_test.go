@@ -0,0 +1,247 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// gitEnv sets the identity git commits with and keeps the user's git config out
+func gitEnv(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	for _, v := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(v, "go-app-gen")
+	}
+	for _, v := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(v, "go-app-gen@example.com")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+}
+
+// run runs git in dir
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// remote creates a bare repository in root whose main branch holds files and
+// returns its URL
+func remote(t *testing.T, root, name string, files map[string]string) string {
+	t.Helper()
+	work := filepath.Join(root, "work", name)
+	for rel, content := range files {
+		path := filepath.Join(work, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	url := filepath.Join(root, "remotes", name+".git")
+	run(t, root, "init", "--quiet", "--bare", "--initial-branch", "main", url)
+	run(t, work, "init", "--quiet", "--initial-branch", "main")
+	run(t, work, "add", "-A")
+	run(t, work, "commit", "--quiet", "-m", "Generate "+name)
+	run(t, work, "push", "--quiet", url, "main")
+	return url
+}
+
+// recorder is a provider that records the proposals and fails the one of the
+// clone named fail
+type recorder struct {
+	mu        sync.Mutex
+	proposals map[string]Proposal
+	fail      string
+}
+
+func (r *recorder) Propose(_ context.Context, dir string, p Proposal) (string, error) {
+	name := filepath.Base(dir)
+	if name == r.fail {
+		return "", errors.New("pull requests are disabled")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.proposals[name] = p
+	return "https://scm.example.com/" + name + "/pull/1", nil
+}
+
+// fleetManifest returns a manifest of a service per outcome, the failing ones
+// first, whose update script and verification run as their files say
+func fleetManifest(t *testing.T, provider string) *Manifest {
+	t.Helper()
+	root := t.TempDir()
+	service := map[string]string{"go.mod": "module example.com/service\n"}
+	with := func(name, content string) map[string]string {
+		files := maps.Clone(service)
+		files[name] = content
+		return files
+	}
+	return &Manifest{
+		Workdir: filepath.Join(root, "clones"),
+		Branch:  DefaultBranch,
+		Update:  []string{"if [ -f broken-update ]; then exit 3; fi", "[ -f up-to-date ] || echo v2 > VERSION"},
+		Verify:  []string{"[ ! -f broken-build ]"},
+		Title:   "Upgrade generated code",
+		Body:    "Re-applies the templates.",
+		SCM:     SCMConfig{Provider: provider, Reviewers: []string{"platform-team"}, Labels: []string{"dependencies"}},
+		Repos: []Repo{
+			{Name: "missing", URL: filepath.Join(root, "remotes", "missing.git")},
+			{Name: "billing", URL: remote(t, root, "billing", with("broken-update", ""))},
+			{Name: "search", URL: remote(t, root, "search", with("services/search/broken-build", "")), Dir: "services/search"},
+			{Name: "catalog", URL: remote(t, root, "catalog", service)},
+			{Name: "users", URL: remote(t, root, "users", with("up-to-date", ""))},
+			{Name: "orders", URL: remote(t, root, "orders", service)},
+		},
+	}
+}
+
+// TestUpgradeReportsEachService upgrades a fleet whose services fail at every
+// step, which must not keep the others from being proposed
+func TestUpgradeReportsEachService(t *testing.T) {
+	gitEnv(t)
+	provider := &recorder{proposals: make(map[string]Proposal), fail: "catalog"}
+	RegisterProvider("recorder", provider)
+	m := fleetManifest(t, "recorder")
+
+	results, err := Upgrade(t.Context(), m, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		repo, outcome, step, err string
+	}{
+		{repo: "missing", outcome: OutcomeFailed, step: "clone", err: "git clone"},
+		{repo: "billing", outcome: OutcomeFailed, step: "update", err: "exit status 3"},
+		{repo: "search", outcome: OutcomeFailed, step: "verify", err: "[ ! -f broken-build ]"},
+		{repo: "catalog", outcome: OutcomeFailed, step: "propose", err: "pull requests are disabled"},
+		{repo: "users", outcome: OutcomeUpToDate},
+		{repo: "orders", outcome: OutcomeProposed},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Upgrade() = %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Repo != w.repo || got.Outcome != w.outcome || got.Step != w.step {
+			t.Errorf("result %d = %s %s at %q, want %s %s at %q", i, got.Repo, got.Outcome, got.Step, w.repo, w.outcome, w.step)
+		}
+		if (w.err == "") != (got.Err == nil) || got.Err != nil && !strings.Contains(got.Err.Error(), w.err) {
+			t.Errorf("%s: error = %v, want %q", w.repo, got.Err, w.err)
+		}
+	}
+	if got := results[5].URL; got != "https://scm.example.com/orders/pull/1" {
+		t.Errorf("orders: URL = %q", got)
+	}
+
+	proposal := provider.proposals["orders"]
+	if proposal.Head != DefaultBranch || proposal.Base != "main" || proposal.Title != m.Title || !slices.Equal(proposal.Reviewers, []string{"platform-team"}) {
+		t.Errorf("orders: proposal = %+v", proposal)
+	}
+	// The branches of the proposed and the failed proposal were pushed, no others
+	for _, r := range m.Repos[1:] {
+		pushed := run(t, r.URL, "branch", "--list", DefaultBranch) != ""
+		if wantPushed := r.Name == "orders" || r.Name == "catalog"; pushed != wantPushed {
+			t.Errorf("%s: %s pushed = %v, want %v", r.Name, DefaultBranch, pushed, wantPushed)
+		}
+	}
+	if got := run(t, m.Repos[5].URL, "show", DefaultBranch+":VERSION"); got != "v2" {
+		t.Errorf("orders: pushed VERSION = %q, want v2", got)
+	}
+}
+
+func TestUpgradeDryRun(t *testing.T) {
+	gitEnv(t)
+	m := fleetManifest(t, "none")
+	m.Repos = m.Repos[5:]
+
+	results, err := Upgrade(t.Context(), m, Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Outcome != OutcomeVerified || results[0].Err != nil {
+		t.Fatalf("Upgrade() = %+v, want orders verified", results)
+	}
+	if pushed := run(t, m.Repos[0].URL, "branch", "--list", DefaultBranch); pushed != "" {
+		t.Errorf("a dry run pushed %s", DefaultBranch)
+	}
+
+	// Without a provider that opens pull requests, the branch is only pushed
+	results, err = Upgrade(t.Context(), m, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Outcome != OutcomePushed || results[0].URL != "" {
+		t.Errorf("Upgrade() = %+v, want orders pushed", results[0])
+	}
+}
+
+var loadManifestTests = []struct {
+	name     string
+	manifest string
+	wants    string
+}{
+	{name: "no repos", manifest: "update: [go-app-gen upgrade]\n", wants: "no repos"},
+	{name: "no update commands", manifest: "repos:\n  - url: git@github.com:acme/orders.git\n", wants: "no update commands"},
+	{name: "a repo without url", manifest: "update: [go-app-gen upgrade]\nrepos:\n  - name: orders\n", wants: "repo 1 has no url"},
+	{name: "a repo listed twice", manifest: "update: [go-app-gen upgrade]\nrepos:\n  - url: git@github.com:acme/orders.git\n  - url: git@gitlab.com:acme/orders.git\n", wants: `repo "orders" is listed twice`},
+	{name: "an unknown provider", manifest: "update: [go-app-gen upgrade]\nscm:\n  provider: bitbucket\nrepos:\n  - url: git@github.com:acme/orders.git\n", wants: `unknown SCM provider: "bitbucket"`},
+}
+
+func TestLoadManifestErrors(t *testing.T) {
+	for _, tt := range loadManifestTests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "fleet.yaml")
+			if err := os.WriteFile(path, []byte(tt.manifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadManifest(path)
+			if !errors.Is(err, ErrInvalidManifest) || !strings.Contains(err.Error(), tt.wants) {
+				t.Errorf("LoadManifest() error = %v, want %q", err, tt.wants)
+			}
+		})
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fleet.yaml")
+	if err := os.WriteFile(path, []byte("workdir: clones\nupdate: [go-app-gen upgrade]\nrepos:\n  - url: git@github.com:acme/orders.git\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Workdir != filepath.Join(dir, "clones") || m.Branch != DefaultBranch || !slices.Equal(m.Verify, DefaultVerify) || m.Repos[0].Name != "orders" {
+		t.Errorf("LoadManifest() = %+v, want the defaults", m)
+	}
+	if !strings.Contains(m.Body, "- go vet ./...") {
+		t.Errorf("Body = %q, want the verification commands", m.Body)
+	}
+
+	// Unknown keys are rejected rather than ignored
+	if err := os.WriteFile(path, []byte("update: [go-app-gen upgrade]\nverfy: [make check]\nrepos:\n  - url: git@github.com:acme/orders.git\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadManifest(path); err == nil || !strings.Contains(err.Error(), "verfy") {
+		t.Errorf("LoadManifest() error = %v, want the unknown key", err)
+	}
+}
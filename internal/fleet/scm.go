@@ -0,0 +1,124 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownProvider is returned for an SCM provider that is not registered
+var ErrUnknownProvider = errors.New("unknown SCM provider")
+
+// SCMConfig selects how upgrades are proposed
+type SCMConfig struct {
+	// Provider is a registered provider: github, gitlab, or none to push the
+	// branch only (the default)
+	Provider  string   `yaml:"provider"`
+	Reviewers []string `yaml:"reviewers"`
+	Labels    []string `yaml:"labels"`
+}
+
+// Proposal is a pushed upgrade branch to propose for merging
+type Proposal struct {
+	Head      string
+	Base      string
+	Title     string
+	Body      string
+	Reviewers []string
+	Labels    []string
+}
+
+// Provider opens pull requests on a source code host
+type Provider interface {
+	// Propose opens a pull request for the branch pushed from the clone in
+	// dir and returns its URL, or "" if the provider opens none
+	Propose(ctx context.Context, dir string, p Proposal) (string, error)
+}
+
+// ProviderFunc adapts a function to a Provider
+type ProviderFunc func(ctx context.Context, dir string, p Proposal) (string, error)
+
+// Propose calls f
+func (f ProviderFunc) Propose(ctx context.Context, dir string, p Proposal) (string, error) {
+	return f(ctx, dir, p)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{
+		"none":   ProviderFunc(func(context.Context, string, Proposal) (string, error) { return "", nil }),
+		"github": ProviderFunc(proposeGitHub),
+		"gitlab": ProviderFunc(proposeGitLab),
+	}
+)
+
+// RegisterProvider makes a provider available to fleet manifests under name,
+// replacing a registered one
+func RegisterProvider(name string, p Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = p
+}
+
+// providerFor returns the registered provider called name, none if empty
+func providerFor(name string) (Provider, error) {
+	if name == "" {
+		name = "none"
+	}
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[name]
+	if !ok {
+		names := make([]string, 0, len(providers))
+		for n := range providers {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownProvider, name, strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+// proposeGitHub opens a pull request with the GitHub CLI
+func proposeGitHub(ctx context.Context, dir string, p Proposal) (string, error) {
+	args := []string{"pr", "create", "--head", p.Head, "--base", p.Base, "--title", p.Title, "--body", p.Body}
+	for _, r := range p.Reviewers {
+		args = append(args, "--reviewer", r)
+	}
+	for _, l := range p.Labels {
+		args = append(args, "--label", l)
+	}
+	return cliURL(ctx, dir, "gh", args...)
+}
+
+// proposeGitLab opens a merge request with the GitLab CLI
+func proposeGitLab(ctx context.Context, dir string, p Proposal) (string, error) {
+	args := []string{"mr", "create", "--yes", "--source-branch", p.Head, "--target-branch", p.Base, "--title", p.Title, "--description", p.Body}
+	if len(p.Reviewers) > 0 {
+		args = append(args, "--reviewer", strings.Join(p.Reviewers, ","))
+	}
+	if len(p.Labels) > 0 {
+		args = append(args, "--label", strings.Join(p.Labels, ","))
+	}
+	return cliURL(ctx, dir, "glab", args...)
+}
+
+// cliURL runs a host's CLI in dir and returns the last line it prints, the
+// URL of the pull request
+func cliURL(ctx context.Context, dir, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %w: %s", name, args[0]+" "+args[1], err, strings.TrimSpace(stderr.String()))
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1]), nil
+}
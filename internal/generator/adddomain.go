@@ -0,0 +1,400 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nhalm/go-app-gen/internal/astedit"
+	"github.com/nhalm/go-app-gen/internal/merge"
+)
+
+// ErrDomainExists is returned when the domain to add is already part of the project
+var ErrDomainExists = errors.New("domain already exists")
+
+var (
+	rootCommandUse   = regexp.MustCompile(`(?m)^\s*Use:\s*("[^"\n]*")`)
+	rootCommandShort = regexp.MustCompile(`(?m)^\s*Short:\s*("(?:[^"\\\n]|\\.)*")`)
+	migrationFile    = regexp.MustCompile(`^(\d+)_create_(\w+)\.up\.sql$`)
+)
+
+// AddDomainResult reports what AddDomain changed in a project, with paths
+// relative to it
+type AddDomainResult struct {
+	Domain string
+	// Created lists the files of the domain, and of its bounded context when
+	// the project did not have it yet
+	Created []string
+	// Merged lists the shared files, such as the server wiring, that received
+	// the domain's changes
+	Merged []string
+	// Conflicts lists the shared files whose changes clash with edits made
+	// since the generation; they hold conflict markers to resolve by hand
+	Conflicts []string
+}
+
+// DetectProject returns the configuration a generated project was created
+// with: its module from go.mod, and its configuration, domains, entity
+// fields, features and template packs from its manifest. Projects without one
+// are read from their layout: the
+// name and description of the root command, the domains with repository
+// queries and the features whose files exist.
+func DetectProject(projectDir string) (*ProjectConfig, error) {
+	config, err := inspectProject(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var domains []string
+	manifest, err := LoadManifest(projectDir)
+	switch {
+	case errors.Is(err, ErrNotGeneratedProject):
+		if domains, err = layoutDomains(projectDir); err != nil {
+			return nil, err
+		}
+		config.Features = layoutFeatures(projectDir, domains)
+	case err != nil:
+		return nil, err
+	default:
+		domains, config.Features, config.Lang = manifest.Domains, manifest.Features, manifest.Lang
+		if manifest.Name != "" {
+			// Manifests written before the configuration was recorded have none
+			config.AppName, config.Description, config.Author = manifest.Name, manifest.Description, manifest.Author
+		}
+		config.DomainPlural, config.DomainTitle, config.DeployTarget, config.Database = manifest.DomainPlural, manifest.DomainTitle, manifest.DeployTarget, manifest.Database
+		config.Router, config.Logger, config.CI, config.Archetype = manifest.Router, manifest.Logger, manifest.CI, manifest.Archetype
+		config.MakeTargets, config.Header, config.MessageFiles = manifest.MakeTargets, manifest.Header, manifest.Messages
+		config.TemplateKeys, config.AllowHooks, config.GeneratorVersion = manifest.TemplateKeys, manifest.AllowHooks, manifest.Generator
+		config.TemplateSource, config.Strict = manifest.TemplateSource, manifest.Strict
+		if len(domains) == 0 {
+			// Manifests written before domains were recorded
+			if domains, err = layoutDomains(projectDir); err != nil {
+				return nil, err
+			}
+		}
+		for domain, definitions := range manifest.Fields {
+			fields, err := ParseFields(definitions)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the fields of %s from the manifest: %w", domain, err)
+			}
+			if config.Fields == nil {
+				config.Fields = make(map[string][]FieldSpec)
+			}
+			config.Fields[domain] = fields
+		}
+		if manifest.TemplateTree != "" {
+			config.TemplateDirs = append(config.TemplateDirs, manifest.TemplateTree)
+		}
+		for _, p := range manifest.Packs {
+			config.TemplateDirs = append(config.TemplateDirs, p.Dir)
+		}
+	}
+
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("%w: no domains found in %s", ErrNotGeneratedProject, projectDir)
+	}
+	config.Domain, config.Domains = domains[0], domains[1:]
+	return config, nil
+}
+
+// inspectProject reads the module of a project from its go.mod and its name
+// and description from its root command, defaulting to the directory name
+func inspectProject(projectDir string) (*ProjectConfig, error) {
+	module, err := readModulePath(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	config := &ProjectConfig{ModuleName: module, AppName: filepath.Base(abs)}
+
+	if root, err := os.ReadFile(filepath.Join(projectDir, "cmd", "root.go")); err == nil {
+		if m := rootCommandUse.FindSubmatch(root); m != nil {
+			config.AppName, _ = strconv.Unquote(string(m[1]))
+		}
+		if m := rootCommandShort.FindSubmatch(root); m != nil {
+			config.Description, _ = strconv.Unquote(string(m[1]))
+		}
+	}
+	return config, nil
+}
+
+// layoutFeatures returns the features whose files exist in the project, in
+// the root context and in the bounded contexts of the domains
+func layoutFeatures(projectDir string, domains []string) []string {
+	var features []string
+	namespaces := map[string]bool{}
+	for _, d := range domains {
+		namespace, _ := ParseDomain(d)
+		if !namespaces[namespace] {
+			namespaces[namespace] = true
+			features = appendMissing(features, detectFeatures(projectDir, namespace)...)
+		}
+	}
+	return features
+}
+
+// layoutDomains finds the domains of a project by their repository queries:
+// the root context first, then the bounded contexts by name, each in the
+// order of its migrations
+func layoutDomains(projectDir string) ([]string, error) {
+	var namespaces []string
+	if _, err := os.Stat(filepath.Join(projectDir, "internal", "repository", "queries")); err == nil {
+		namespaces = append(namespaces, "")
+	}
+	contexts, err := filepath.Glob(filepath.Join(projectDir, "internal", "*", "repository", "queries"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the bounded contexts: %w", err)
+	}
+	for _, dir := range contexts {
+		namespace := filepath.Base(filepath.Dir(filepath.Dir(dir)))
+		if ValidateNamespace(namespace) == nil {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	var domains []string
+	for _, namespace := range namespaces {
+		ns := newNamespaceData(namespace)
+		queries, err := filepath.Glob(filepath.Join(projectDir, filepath.FromSlash(ns.NamespaceDir), "repository", "queries", "*.sql"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the domains of %s: %w", ns.NamespaceDir, err)
+		}
+
+		versions := make(map[string]string)
+		if entries, err := os.ReadDir(filepath.Join(projectDir, filepath.FromSlash(ns.MigrationsDir))); err == nil {
+			for _, e := range entries {
+				if m := migrationFile.FindStringSubmatch(e.Name()); m != nil {
+					versions[m[2]] = m[1]
+				}
+			}
+		}
+
+		var found []string
+		for _, q := range queries {
+			name := strings.TrimSuffix(filepath.Base(q), ".sql")
+			if namespace != "" {
+				name = namespace + "." + name
+			}
+			found = append(found, name)
+		}
+		version := func(domain string) string {
+			if v, ok := versions[newDomainData(domain, "", "").DomainPlural]; ok {
+				return v
+			}
+			return "~" // after every numbered migration
+		}
+		sort.SliceStable(found, func(i, j int) bool { return version(found[i]) < version(found[j]) })
+		domains = append(domains, found...)
+	}
+	return domains, nil
+}
+
+// appendMissing appends the values list does not contain yet
+func appendMissing(list []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// AddDomain adds a domain with the given entity fields, DefaultFields if nil,
+// to a generated project. The project is rendered twice, as it is and with
+// the domain: files only the second render has are
+// created, and the changes between the two renders are merged into the shared
+// files, so the edits made to them since the generation are kept. The
+// post-processing then runs again.
+func (g *Generator) AddDomain(projectDir, domain string, fields []FieldSpec) (*AddDomainResult, error) {
+	config, err := DetectProject(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	existing := append([]string{config.Domain}, config.Domains...)
+	if err := ValidateDomains(append(existing, domain), ""); err != nil {
+		if err := ValidateDomains([]string{domain}, ""); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrDomainExists, err)
+	}
+	if err := ValidateTables([]string{domain}, "", config.Database); err != nil {
+		return nil, err
+	}
+
+	manifest, err := LoadManifest(projectDir)
+	if err != nil && !errors.Is(err, ErrNotGeneratedProject) {
+		return nil, err
+	}
+	var drifted map[string]string
+	if manifest != nil {
+		drifted = manifest.drifted(projectDir)
+	}
+
+	extended := *config
+	extended.Domains = append(append([]string{}, config.Domains...), domain)
+	if fields != nil {
+		extended.Fields = maps.Clone(config.Fields)
+		if extended.Fields == nil {
+			extended.Fields = make(map[string][]FieldSpec)
+		}
+		extended.Fields[domain] = fields
+	}
+	result := &AddDomainResult{Domain: domain}
+
+	err = renderTemporary(config, nil, func(_ *Generator, _ *TemplateData, baseDir string) error {
+		return renderTemporary(&extended, nil, func(rendered *Generator, data *TemplateData, renderDir string) error {
+			for _, f := range rendered.report.Files {
+				if err := result.apply(projectDir, baseDir, renderDir, f.Path); err != nil {
+					return err
+				}
+			}
+
+			if manifest == nil {
+				if manifest, err = rendered.newManifest(data); err != nil {
+					return err
+				}
+			} else {
+				manifest.Domains = append(existing, domain)
+				if fields != nil {
+					if definitions := FormatFields(fields); definitions != DefaultFields {
+						if manifest.Fields == nil {
+							manifest.Fields = make(map[string]string)
+						}
+						manifest.Fields[domain] = definitions
+					}
+				}
+				for _, p := range result.Created {
+					if !isProjectOwned(p) {
+						manifest.Files[p] = ""
+					}
+				}
+				manifest.Pipeline = nil
+				for _, step := range postSteps(manifest.Module, manifest.Features) {
+					manifest.Pipeline = append(manifest.Pipeline, PipelineStep{Name: step.name, Status: StepPending})
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("🔄 Running post-generation tasks...")
+	if err := g.runPipeline(projectDir, manifest); err != nil {
+		return result, err
+	}
+	// Files edited since the generation keep their generated checksum, so
+	// status still reports them as modified
+	if len(drifted) > 0 {
+		for p, sum := range drifted {
+			if _, ok := manifest.Files[p]; ok {
+				manifest.Files[p] = sum
+			}
+		}
+		if err := manifest.write(projectDir); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// apply brings one file of the render with the new domain into the project
+func (r *AddDomainResult) apply(projectDir, baseDir, renderDir, rel string) error {
+	theirs, err := os.ReadFile(filepath.Join(renderDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return fmt.Errorf("failed to read rendered %s: %w", rel, err)
+	}
+	base, err := os.ReadFile(filepath.Join(baseDir, filepath.FromSlash(rel)))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read rendered %s: %w", rel, err)
+	}
+	if bytes.Equal(base, theirs) {
+		return nil
+	}
+
+	target, err := projectPath(projectDir, rel)
+	if err != nil {
+		return err
+	}
+	ours, err := os.ReadFile(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		r.Created = append(r.Created, rel)
+		return os.WriteFile(target, theirs, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", rel, err)
+	}
+	if isProjectOwned(rel) {
+		return nil
+	}
+
+	merged := mergeFile(rel, base, ours, theirs, "go-app-gen add domain "+r.Domain)
+	if bytes.Equal(merged.Content, ours) {
+		return nil
+	}
+	if merged.Conflicts > 0 {
+		r.Conflicts = append(r.Conflicts, rel)
+	} else {
+		r.Merged = append(r.Merged, rel)
+	}
+	if err := os.WriteFile(target, merged.Content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	return nil
+}
+
+// mergeFile merges the changes between two renders of a project file, base
+// and theirs, into ours, the file as the project has it
+func mergeFile(rel string, base, ours, theirs []byte, label string) merge.Result {
+	// The project's Go files went through gofmt, the renders did not
+	isGo := path.Ext(rel) == ".go"
+	if isGo {
+		base, theirs = gofmt(base), gofmt(theirs)
+	}
+	// Generated regions take the new render as a whole, so only the code
+	// around them is merged. A file that lost one of the regions merges
+	// whole, or the changes to that region would be dropped.
+	regionBase, regionOurs := base, ours
+	if merge.HasRegions(theirs) {
+		if replaced, err := merge.ReplaceRegions(ours, theirs); err == nil && len(replaced.Missing) == 0 {
+			regionOurs = replaced.Content
+			if replacedBase, err := merge.ReplaceRegions(base, theirs); err == nil {
+				regionBase = replacedBase.Content
+			}
+		}
+	}
+	result := merge.Merge(regionBase, regionOurs, theirs, merge.Labels{Ours: rel, Theirs: label})
+
+	// Lines of Go the user reformatted or moved the region markers of
+	// conflict, but the render usually only adds code, which merges by its
+	// syntax instead
+	if result.Conflicts > 0 && isGo {
+		if merged, err := astedit.Merge(base, ours, theirs); err == nil {
+			return merge.Result{Content: merged}
+		}
+	}
+	return result
+}
+
+// gofmt formats Go source, returning it unchanged when it does not parse
+func gofmt(src []byte) []byte {
+	if formatted, err := format.Source(src); err == nil {
+		return formatted
+	}
+	return src
+}
@@ -0,0 +1,88 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/nhalm/go-app-gen/internal/merge"
+)
+
+// TestMergeFileMergesGoBySyntax adds a domain's routes to a routes.go whose
+// region markers the user replaced with a route of their own, which the line
+// merge cannot place
+func TestMergeFileMergesGoBySyntax(t *testing.T) {
+	const base = `package api
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers all API routes
+func RegisterRoutes(r chi.Router, handler *Handler) {
+	r.Route("/api/v1", func(r chi.Router) {
+		// BEGIN go-app-gen routes
+		RegisterProductRoutes(r, handler)
+		// END go-app-gen routes
+	})
+}
+`
+	const theirs = `package api
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers all API routes
+func RegisterRoutes(r chi.Router, handler *Handler) {
+	r.Route("/api/v1", func(r chi.Router) {
+		// BEGIN go-app-gen routes
+		RegisterProductRoutes(r, handler)
+		RegisterOrderRoutes(r, handler)
+		// END go-app-gen routes
+	})
+}
+`
+	const ours = `package api
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers all API routes
+func RegisterRoutes(r chi.Router, handler *Handler) {
+	r.Route("/api/v1", func(r chi.Router) {
+		RegisterProductRoutes(r, handler)
+		r.Get("/status", handler.Status)
+	})
+}
+`
+	const want = `package api
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers all API routes
+func RegisterRoutes(r chi.Router, handler *Handler) {
+	r.Route("/api/v1", func(r chi.Router) {
+		RegisterProductRoutes(r, handler)
+		RegisterOrderRoutes(r, handler)
+		r.Get("/status", handler.Status)
+	})
+}
+`
+	const rel = "internal/api/routes.go"
+
+	if lines := merge.Merge([]byte(base), []byte(ours), []byte(theirs), merge.Labels{}); lines.Conflicts == 0 {
+		t.Fatal("expected the line merge to conflict")
+	}
+
+	got := mergeFile(rel, []byte(base), []byte(ours), []byte(theirs), "go-app-gen add domain order")
+	if got.Conflicts != 0 || string(got.Content) != want {
+		t.Fatalf("got %d conflicts:\n%s\nwant:\n%s", got.Conflicts, got.Content, want)
+	}
+
+	again := mergeFile(rel, []byte(base), got.Content, []byte(theirs), "go-app-gen add domain order")
+	if again.Conflicts != 0 || string(again.Content) != want {
+		t.Fatalf("expected merging again to change nothing, got %d conflicts:\n%s", again.Conflicts, again.Content)
+	}
+}
@@ -0,0 +1,143 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// ErrAlreadyAdopted is returned when adopting a project that has a manifest
+var ErrAlreadyAdopted = errors.New("project already has a manifest")
+
+// AdoptOptions overrides what Adopt infers from the project's layout
+type AdoptOptions struct {
+	Module   string   // the go.mod module when empty
+	Domains  []string // the domains with repository queries when empty
+	Features []string // the features whose files exist when nil
+	// Force replaces an existing manifest
+	Force bool
+	// DryRun reports what would be recorded without writing the manifest
+	DryRun bool
+}
+
+// AdoptResult reports how an existing codebase compares with the project the
+// templates render for it, with paths relative to the project
+type AdoptResult struct {
+	Config *ProjectConfig
+	// Matching lists the project files identical to their rendered template
+	Matching []string
+	// Differing lists the project files at a rendered path with other content
+	Differing []string
+	// Missing lists the rendered files the project does not have
+	Missing []string
+}
+
+// Adopt writes a manifest into an existing Go service that go-app-gen did not
+// generate, so status, add domain and the other incremental commands work on
+// it. The module, name, domains and features are inferred from the layout
+// unless opts sets them. The project is rendered with them and every project
+// file at a rendered path is recorded as generated with its current content,
+// so status reports the changes made after the adoption.
+func Adopt(projectDir string, opts AdoptOptions) (*AdoptResult, error) {
+	if _, err := LoadManifest(projectDir); err == nil && !opts.Force {
+		return nil, fmt.Errorf("%w: %s; use status, or adopt with --force to replace it", ErrAlreadyAdopted, filepath.Join(projectDir, ManifestFile))
+	} else if err != nil && !errors.Is(err, ErrNotGeneratedProject) {
+		return nil, err
+	}
+
+	config, err := inspectProject(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Module != "" {
+		config.ModuleName = opts.Module
+	}
+	domains := opts.Domains
+	if len(domains) == 0 {
+		if domains, err = layoutDomains(projectDir); err != nil {
+			return nil, err
+		}
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("%w: no domains found in %s; name them with --domain", ErrNotGeneratedProject, projectDir)
+	}
+	if err := ValidateDomains(domains, ""); err != nil {
+		return nil, err
+	}
+	config.Domain, config.Domains = domains[0], domains[1:]
+	config.Features = opts.Features
+	if config.Features == nil {
+		config.Features = layoutFeatures(projectDir, domains)
+	}
+	if err := ValidateFeatures(config.Features); err != nil {
+		return nil, err
+	}
+	if err := ValidateFeatureDatabases(config.Features, config.Database); err != nil {
+		return nil, err
+	}
+
+	result := &AdoptResult{Config: config}
+	err = renderTemporary(config, nil, func(rendered *Generator, data *TemplateData, renderDir string) error {
+		manifest, err := rendered.newManifest(data)
+		if err != nil {
+			return err
+		}
+		for _, f := range rendered.report.Files {
+			if f.Path == ".env" {
+				delete(manifest.Files, f.Path)
+				continue
+			}
+			if err := result.compare(projectDir, renderDir, f.Path); err != nil {
+				return err
+			}
+		}
+
+		// Files the project lacks are dropped, the others get its checksums,
+		// and there is no post-processing left to run
+		if err := manifest.checksum(projectDir); err != nil {
+			return err
+		}
+		manifest.Pipeline = nil
+		if opts.DryRun {
+			return nil
+		}
+		return manifest.write(projectDir)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(result.Differing)
+	return result, nil
+}
+
+// compare sorts one rendered file by how the project's copy compares with it
+func (r *AdoptResult) compare(projectDir, renderDir, rel string) error {
+	ours, err := os.ReadFile(filepath.Join(projectDir, filepath.FromSlash(rel)))
+	if errors.Is(err, fs.ErrNotExist) {
+		r.Missing = append(r.Missing, rel)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", rel, err)
+	}
+	theirs, err := os.ReadFile(filepath.Join(renderDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return fmt.Errorf("failed to read rendered %s: %w", rel, err)
+	}
+
+	// The project's Go files went through gofmt, the render did not
+	if path.Ext(rel) == ".go" {
+		theirs = gofmt(theirs)
+	}
+	if bytes.Equal(ours, theirs) {
+		r.Matching = append(r.Matching, rel)
+	} else {
+		r.Differing = append(r.Differing, rel)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownArchetype is returned when a requested service archetype is not supported
+var ErrUnknownArchetype = errors.New("unknown archetype")
+
+// DefaultArchetype is the service archetype of projects that do not choose one
+const DefaultArchetype = "api"
+
+// Archetype is the shape of service a project is generated as. Every archetype
+// serves the domains' API; the others add the code of a specialized service
+// around it.
+type Archetype struct {
+	Name        string
+	Title       string
+	Description string
+
+	// Templates lists template paths, relative to templates/, that are only
+	// rendered for the archetypes that list them. Entries ending in "/" match a
+	// directory.
+	Templates []string
+}
+
+// Archetypes lists every service archetype the generator supports
+var Archetypes = []Archetype{
+	{
+		Name:        "api",
+		Title:       "API",
+		Description: "HTTP API over the domains' services",
+	},
+	{
+		Name:        "webhook-receiver",
+		Title:       "Webhook receiver",
+		Description: "GitHub webhook receiver with signature verification, event routing, idempotent processing stored in the database and replay",
+		Templates: []string{
+			"cmd/webhooks.go.tmpl",
+			"docs/runbooks/webhook-replay.md.tmpl",
+			"internal/database/migrations/webhook/",
+			"internal/database/webhook/",
+			"internal/webhook/",
+		},
+	},
+	{
+		Name:        "gateway",
+		Title:       "API gateway",
+		Description: "API gateway / backend-for-frontend proxying per-route upstreams, with API key authentication, rate limiting, response aggregation and merged OpenAPI specs",
+		Templates: []string{
+			"cmd/gateway.go.tmpl",
+			"config/gateway.yaml.tmpl",
+			"internal/gateway/",
+		},
+	},
+	{
+		Name:        "pipeline",
+		Title:       "Batch pipeline",
+		Description: "ETL/batch job framework with source, transform and sink interfaces, parallel workers, checkpoints stored in the database, backfills and records processed metrics",
+		Templates: []string{
+			"cmd/pipeline.go.tmpl",
+			"docs/runbooks/pipeline.md.tmpl",
+			"internal/database/migrations/pipeline/",
+			"internal/database/pipeline/",
+			"internal/metrics/pipeline.go.tmpl",
+			"internal/pipeline/",
+		},
+	},
+}
+
+// ArchetypeNames returns the names of all supported service archetypes
+func ArchetypeNames() []string {
+	names := make([]string, len(Archetypes))
+	for i, a := range Archetypes {
+		names[i] = a.Name
+	}
+	return names
+}
+
+// ValidateArchetype checks that name is empty, for DefaultArchetype, or a
+// supported service archetype
+func ValidateArchetype(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := lookupArchetype(name); ok {
+		return nil
+	}
+	return fmt.Errorf("%w: %q (available: %s)", ErrUnknownArchetype, name, strings.Join(ArchetypeNames(), ", "))
+}
+
+// lookupArchetype returns the service archetype of a name, DefaultArchetype if empty
+func lookupArchetype(name string) (Archetype, bool) {
+	if name == "" {
+		name = DefaultArchetype
+	}
+	for _, a := range Archetypes {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Archetype{}, false
+}
+
+// templateArchetypes returns the service archetypes that own a template; a
+// template that none own is rendered for every archetype
+func templateArchetypes(templatePath string) []string {
+	path := strings.TrimPrefix(templatePath, "templates/")
+
+	var owners []string
+	for _, a := range Archetypes {
+		if ownsTemplate(a.Templates, path) {
+			owners = append(owners, a.Name)
+		}
+	}
+	return owners
+}
@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Archive renders the project into a temporary directory and writes it to w
+// as a zip archive with every file below <AppName>/. With postProcess the
+// post-generation tasks run first, so the archive holds go.mod, go.sum and the
+// sqlc code; without it the manifest records them as pending, for go-app-gen
+// resume to run once the archive is unpacked. Nothing is written to w when the
+// project fails to render.
+func (g *Generator) Archive(config *ProjectConfig, postProcess bool, w io.Writer) error {
+	return renderTemporary(config, g.templates, func(rendered *Generator, data *TemplateData, projectDir string) error {
+		defer func() { g.report = rendered.report }()
+		if err := rendered.scanSecrets(config, projectDir); err != nil {
+			return err
+		}
+
+		if postProcess {
+			if err := rendered.PostProcess(projectDir, data); err != nil {
+				return fmt.Errorf("post-processing failed: %w", err)
+			}
+		} else {
+			m, err := rendered.newManifest(data)
+			if err != nil {
+				return err
+			}
+			if err := m.write(projectDir); err != nil {
+				return err
+			}
+		}
+
+		return writeZip(w, projectDir, config.AppName)
+	})
+}
+
+// writeZip writes the regular files below dir to w as a zip archive, with
+// their paths below prefix
+func writeZip(w io.Writer, dir, prefix string) error {
+	zw := zip.NewWriter(w)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(prefix, filepath.ToSlash(rel))
+		header.Method = zip.Deflate
+		dst, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(dst, src)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write the archive: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write the archive: %w", err)
+	}
+	return nil
+}
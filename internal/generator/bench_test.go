@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+)
+
+// BenchmarkParse parses every built-in template with the partials
+func BenchmarkParse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseTemplateSet(templatesFS, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkExecute executes the built-in templates the project renders, from
+// the parsed set, for every scope of their paths
+func BenchmarkExecute(b *testing.B) {
+	for _, bc := range benchProjects(b) {
+		b.Run(bc.name, func(b *testing.B) {
+			data := newTemplateData(bc.config)
+			g := New(b.TempDir())
+			set, err := builtinTemplateSet()
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, path := range set.paths {
+					if !data.enabled(path) {
+						continue
+					}
+					for _, scope := range g.templateScopes(path, data) {
+						if err := set.templates[path].tmpl.Execute(io.Discard, scope); err != nil {
+							b.Fatalf("failed to execute template %s: %v", path, err)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkRender renders the project into the same directory on every
+// iteration, as a regeneration does, with and without the template cache
+func BenchmarkRender(b *testing.B) {
+	for _, bc := range benchProjects(b) {
+		for _, cached := range []bool{false, true} {
+			name := bc.name
+			if cached {
+				name += "-cached"
+			}
+			b.Run(name, func(b *testing.B) {
+				g := New(b.TempDir())
+				if cached {
+					if err := g.UseCache(b.TempDir()); err != nil {
+						b.Fatal(err)
+					}
+					// Warm the cache and the project directory
+					if _, _, err := g.render(bc.config); err != nil {
+						b.Fatal(err)
+					}
+				}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, _, err := g.render(bc.config); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// benchProject is a project the benchmarks render
+type benchProject struct {
+	name   string
+	config *ProjectConfig
+}
+
+// benchProjects returns a single-domain project and a multi-domain one with
+// every feature that can be enabled alongside the others
+func benchProjects(b *testing.B) []benchProject {
+	b.Helper()
+	features := compatibleFeatures()
+	if err := ValidateFeatures(features); err != nil {
+		b.Fatal(err)
+	}
+	if err := ValidateFeatureDatabases(features, DefaultDatabase); err != nil {
+		b.Fatal(err)
+	}
+
+	return []benchProject{
+		{name: "1-domain", config: benchConfig(1, nil)},
+		{name: "12-domains-all-features", config: benchConfig(12, features)},
+	}
+}
+
+// compatibleFeatures returns the features that run on the default database,
+// with the first broker feature only and the requirements of each enabled
+func compatibleFeatures() []string {
+	broker := ""
+	var features []string
+	for _, f := range Features {
+		if len(f.Databases) > 0 && !slices.Contains(f.Databases, DefaultDatabase) {
+			continue
+		}
+		if slices.Contains(brokerFeatures, f.Name) {
+			if broker != "" {
+				continue
+			}
+			broker = f.Name
+		}
+		features = append(features, f.Name)
+	}
+
+	// Drop the features whose requirements were dropped, until none are left
+	for {
+		var kept []string
+		for _, name := range features {
+			f, _ := findFeature(name)
+			if !slices.ContainsFunc(f.Requires, func(required string) bool { return !slices.Contains(features, required) }) {
+				kept = append(kept, name)
+			}
+		}
+		if len(kept) == len(features) {
+			break
+		}
+		features = kept
+	}
+	return features
+}
+
+// benchConfig returns a project with domains spread over bounded contexts of three domains
+func benchConfig(domains int, features []string) *ProjectConfig {
+	config := &ProjectConfig{
+		AppName:     "bench",
+		ModuleName:  "github.com/example/bench",
+		Domain:      "item",
+		Description: "A benchmark project",
+		Features:    features,
+	}
+	for i := 1; i < domains; i++ {
+		config.Domains = append(config.Domains, fmt.Sprintf("context%d.entity%d", i/3, i))
+	}
+	return config
+}
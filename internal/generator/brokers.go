@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// brokerFeatures are the features publishing the domain events to a message
+// broker. Each takes over the publishing service and the consume command, so
+// a project enables one at most.
+var brokerFeatures = []string{"kafka", "nats"}
+
+// brokerTemplates lists template paths, relative to templates/, that every
+// broker feature renders. Entries ending in "/" match a directory.
+var brokerTemplates = []string{
+	"cmd/consume.go.tmpl",
+	"internal/{{.namespace}}/events/consumers.go.tmpl",
+	"internal/{{.namespace}}/service/publishing.go.tmpl",
+	"internal/{{.namespace}}/service/{{.domain}}_publishing.go.tmpl",
+}
+
+// enabledBroker returns the broker feature among features, empty without one
+func enabledBroker(features []string) string {
+	for _, f := range features {
+		if slices.Contains(brokerFeatures, f) {
+			return f
+		}
+	}
+	return ""
+}
+
+// validateBrokers checks that features enable one broker feature at most
+func validateBrokers(features []string) error {
+	var enabled []string
+	for _, f := range features {
+		if slices.Contains(brokerFeatures, f) && !slices.Contains(enabled, f) {
+			enabled = append(enabled, f)
+		}
+	}
+	if len(enabled) > 1 {
+		return fmt.Errorf("%w: %q and %q both publish the domain events, enable one of them", ErrConflictingFeatures, enabled[0], enabled[1])
+	}
+	return nil
+}
+
+// isBrokerTemplate reports whether a template is rendered by every broker feature
+func isBrokerTemplate(templatePath string) bool {
+	return ownsTemplate(brokerTemplates, strings.TrimPrefix(templatePath, "templates/"))
+}
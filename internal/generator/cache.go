@@ -0,0 +1,157 @@
+package generator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// renderCacheVersion is part of every cache key; bump it when rendering
+// changes in a way the template and data hashes do not capture
+const renderCacheVersion = "1"
+
+// renderCache stores rendered template outputs on disk, keyed by the hash of
+// the template and the hash of the data it is rendered with, so repeated
+// generations only execute the templates whose inputs changed
+type renderCache struct {
+	dir   string
+	stats CacheStats
+}
+
+// CacheStats counts how a generation used the render cache
+type CacheStats struct {
+	Hits   int // outputs taken from the cache instead of executing their template
+	Misses int // outputs rendered and added to the cache
+	// Unchanged counts outputs already on disk with the same content, which
+	// are not written again so their modification times stay put
+	Unchanged int
+}
+
+// DefaultCacheDir returns the render cache in the user's cache directory
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "go-app-gen", "render"), nil
+}
+
+// UseCache makes the generator reuse rendered outputs cached in dir and skip
+// writing files whose content is unchanged
+func (g *Generator) UseCache(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create render cache: %w", err)
+	}
+	g.cache = &renderCache{dir: dir}
+	return nil
+}
+
+// CacheStats returns the render cache usage since UseCache
+func (g *Generator) CacheStats() CacheStats {
+	if g.cache == nil {
+		return CacheStats{}
+	}
+	return g.cache.stats
+}
+
+// templateHash identifies a template by its path and content
+func templateHash(templatePath string, content []byte) string {
+	sum := sha256.Sum256(append([]byte(templatePath+"\x00"), content...))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashProject records the hash of the project's data in data, before it is
+// copied into per-domain scopes. The enabled features and the messages are
+// hashed besides the fields since HasFeature and Msg hide them.
+func (c *renderCache) hashProject(data *TemplateData) error {
+	if data.projectHash != "" {
+		return nil
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to hash template data: %w", err)
+	}
+	messages, err := json.Marshal(data.messages)
+	if err != nil {
+		return fmt.Errorf("failed to hash template data: %w", err)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", renderCacheVersion, strings.Join(data.features, ","))
+	h.Write(encoded)
+	h.Write(messages)
+	data.projectHash = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// key returns the cache key of a template rendered with a scope of the
+// project's data. The project hash covers every domain, so the scope is
+// identified by its domain alone; its namespace's domains follow from it.
+func (c *renderCache) key(templateHash string, data *TemplateData) (string, error) {
+	encoded, err := json.Marshal(data.DomainData)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash template data: %w", err)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", data.projectHash, templateHash)
+	h.Write(encoded)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// path returns the file of a cache entry, sharded by the first byte of the key
+func (c *renderCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// get returns the cached output for key; unreadable entries count as misses
+func (c *renderCache) get(key string) ([]byte, bool) {
+	content, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	c.stats.Hits++
+	return content, true
+}
+
+// put stores an output under key, renaming it into place so concurrent
+// generations never read a partial entry
+func (c *renderCache) put(key string, content []byte) error {
+	c.stats.Misses++
+	entry := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(entry), 0755); err != nil {
+		return fmt.Errorf("failed to write render cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(entry), key+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write render cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write render cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write render cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), entry); err != nil {
+		return fmt.Errorf("failed to write render cache: %w", err)
+	}
+	return nil
+}
+
+// unchanged reports whether outputPath already holds content
+func (c *renderCache) unchanged(outputPath string, content []byte) bool {
+	existing, err := os.ReadFile(outputPath)
+	if err != nil || !bytes.Equal(existing, content) {
+		return false
+	}
+	c.stats.Unchanged++
+	return true
+}
@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownCIProvider is returned when a requested CI provider is not supported
+var ErrUnknownCIProvider = errors.New("unknown CI provider")
+
+// CIProvider is a CI service the project gets a pipeline for: generating the
+// sqlc and gRPC code, linting, testing against the database, building and
+// publishing the Docker image
+type CIProvider struct {
+	Name        string
+	Title       string
+	Description string
+
+	// Templates lists template paths, relative to templates/, that are only
+	// rendered for this provider. Entries ending in "/" match a directory.
+	Templates []string
+}
+
+// CIProviders lists every CI provider the generator supports
+var CIProviders = []CIProvider{
+	{
+		Name:        "github",
+		Title:       "GitHub Actions",
+		Description: "GitHub Actions workflow publishing the image to the GitHub Container Registry",
+		Templates: []string{
+			".github/actions/generate/",
+			".github/workflows/ci.yml.tmpl",
+		},
+	},
+	{
+		Name:        "gitlab",
+		Title:       "GitLab CI",
+		Description: "GitLab CI pipeline publishing the image to the project's container registry",
+		Templates: []string{
+			".gitlab-ci.yml.tmpl",
+		},
+	},
+	{
+		Name:        "circleci",
+		Title:       "CircleCI",
+		Description: "CircleCI workflow publishing the image to the registry of the REGISTRY variables",
+		Templates: []string{
+			".circleci/",
+		},
+	},
+}
+
+// CIProviderNames returns the names of all supported CI providers
+func CIProviderNames() []string {
+	names := make([]string, len(CIProviders))
+	for i, p := range CIProviders {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// ValidateCIProvider checks that name is empty, for no pipeline, or a
+// supported CI provider
+func ValidateCIProvider(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := lookupCIProvider(name); ok {
+		return nil
+	}
+	return fmt.Errorf("%w: %q (available: %s)", ErrUnknownCIProvider, name, strings.Join(CIProviderNames(), ", "))
+}
+
+// lookupCIProvider returns the CI provider of a name
+func lookupCIProvider(name string) (CIProvider, bool) {
+	for _, p := range CIProviders {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return CIProvider{}, false
+}
+
+// templateCIProvider returns the CI provider that owns a template, if any
+func templateCIProvider(templatePath string) (string, bool) {
+	path := strings.TrimPrefix(templatePath, "templates/")
+	for _, p := range CIProviders {
+		if ownsTemplate(p.Templates, path) {
+			return p.Name, true
+		}
+	}
+	return "", false
+}
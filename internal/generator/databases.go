@@ -0,0 +1,184 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrUnknownDatabase is returned when a requested database engine is not supported
+var ErrUnknownDatabase = errors.New("unknown database")
+
+// DefaultDatabase is the database engine of projects that do not choose one
+const DefaultDatabase = "postgres"
+
+// Database is a database engine the project stores its entities in
+type Database struct {
+	Name        string
+	Title       string
+	Description string
+	// Engine is the sqlc engine of the database's schema and queries
+	Engine string
+
+	// Templates lists template paths, relative to templates/, that are only
+	// rendered for the databases that list them. Entries ending in "/" match a
+	// directory.
+	Templates []string
+
+	// sqlTypes are the column types of the field types that differ from the
+	// Postgres ones of fieldTypes
+	sqlTypes map[string]string
+	// reservedTables are the words sqlc's parser of the engine rejects as a
+	// table name besides the SQL keywords of reservedFields
+	reservedTables []string
+}
+
+// Databases lists every database engine the generator supports
+var Databases = []Database{
+	{
+		Name:        "postgres",
+		Title:       "Postgres",
+		Description: "PostgreSQL with pgx, migration linting and squashing",
+		Engine:      "postgresql",
+		Templates: []string{
+			"cmd/migrate_safety.go.tmpl",
+			"cmd/migrate_squash.go.tmpl",
+			"internal/migrationlint/",
+			".github/workflows/migrations.yml.tmpl",
+			".idea/runConfigurations/Database.xml.tmpl",
+		},
+	},
+	{
+		Name:        "mysql",
+		Title:       "MySQL",
+		Description: "MySQL 8 with go-sql-driver/mysql",
+		Engine:      "mysql",
+		Templates: []string{
+			".idea/runConfigurations/Database.xml.tmpl",
+		},
+		sqlTypes: map[string]string{
+			"float":     "DOUBLE",
+			"decimal":   "DECIMAL(12,2)",
+			"timestamp": "DATETIME(6)",
+			"uuid":      "CHAR(36)",
+			"json":      "JSON",
+		},
+	},
+	{
+		Name:        "sqlite",
+		Title:       "SQLite",
+		Description: "SQLite with the pure Go modernc.org/sqlite driver, in a local file",
+		Engine:      "sqlite",
+		sqlTypes: map[string]string{
+			"float":     "REAL",
+			"decimal":   "TEXT", // exact, where a DECIMAL column would store a REAL
+			"timestamp": "DATETIME",
+			"json":      "JSON",
+		},
+		reservedTables: []string{"others", "ties"},
+	},
+}
+
+// DatabaseNames returns the names of all supported database engines
+func DatabaseNames() []string {
+	names := make([]string, len(Databases))
+	for i, d := range Databases {
+		names[i] = d.Name
+	}
+	return names
+}
+
+// ValidateDatabase checks that name is empty, for DefaultDatabase, or a
+// supported database engine
+func ValidateDatabase(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := lookupDatabase(name); ok {
+		return nil
+	}
+	return fmt.Errorf("%w: %q (available: %s)", ErrUnknownDatabase, name, strings.Join(DatabaseNames(), ", "))
+}
+
+// lookupDatabase returns the database engine of a name, DefaultDatabase if empty
+func lookupDatabase(name string) (Database, bool) {
+	if name == "" {
+		name = DefaultDatabase
+	}
+	for _, d := range Databases {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return Database{}, false
+}
+
+// templateDatabases returns the database engines that own a template; a
+// template that none own is rendered for every engine
+func templateDatabases(templatePath string) []string {
+	path := strings.TrimPrefix(templatePath, "templates/")
+
+	var owners []string
+	for _, d := range Databases {
+		if ownsTemplate(d.Templates, path) {
+			owners = append(owners, d.Name)
+		}
+	}
+	return owners
+}
+
+// withDatabase returns a field with the column type, SQL name and SQL example
+// of the database engine
+func (d Database) withDatabase(f FieldSpec) FieldSpec {
+	if t, ok := d.sqlTypes[f.Type]; ok {
+		f.SQLType = t
+	}
+	f.SQLName = d.quoteIdentifier(f.Name)
+	f.SQLExample = d.sqlLiteral(f)
+	return f
+}
+
+// quoteIdentifier returns a table or column name as the engine's statements
+// spell it. MySQL reserves plurals such as groups and keys, so its names are
+// always quoted in backticks.
+func (d Database) quoteIdentifier(name string) string {
+	if d.Name == "mysql" {
+		return "`" + name + "`"
+	}
+	return name
+}
+
+// reservesTable reports whether the engine's statements cannot name a table
+// unquoted. MySQL quotes every table, so it reserves none.
+func (d Database) reservesTable(table string) bool {
+	if d.Name == "mysql" {
+		return false
+	}
+	return reservedFields[table] == "SQL keyword" || slices.Contains(d.reservedTables, table)
+}
+
+// sqlLiteral returns the example of a field as an SQL literal. Postgres casts
+// the quoted example to the column type; the other engines convert numbers,
+// booleans and quoted values on insert.
+func (d Database) sqlLiteral(f FieldSpec) string {
+	value := f.Example
+	var s string
+	if err := json.Unmarshal([]byte(value), &s); err == nil {
+		value = s
+	}
+	literal := "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	switch {
+	case f.Type == "string" || f.Type == "text":
+		return literal
+	case d.Name == "postgres":
+		return literal + "::" + f.SQLType
+	case f.Type == "int" || f.Type == "bigint" || f.Type == "float" || f.Type == "bool":
+		return strings.ToUpper(value)
+	case f.Type == "timestamp":
+		// DATETIME columns hold no time zone; examples are in UTC
+		return strings.Replace(strings.TrimSuffix(literal, "Z'"), "T", " ", 1) + "'"
+	}
+	return literal
+}
@@ -0,0 +1,346 @@
+package generator
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// namespacePattern accepts lowercase single-word package names
+var namespacePattern = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// DomainData holds the derived names and locations of a single domain
+type DomainData struct {
+	Domain            string // billing.purchase_order
+	DomainTitle       string // PurchaseOrder
+	DomainCamel       string // purchaseOrder
+	DomainKebab       string // purchase-order
+	DomainPlural      string // purchase_orders
+	DomainPluralTitle string // PurchaseOrders
+	DomainPluralLower string // purchase_orders
+	DomainPluralKebab string // purchase-orders
+	DomainLower       string // purchase_order
+	TableName         string // billing_purchase_orders
+	SQLTable          string // billing_purchase_orders - the table in SQL statements, quoted where the database needs it
+	MigrationVersion  string // 001
+	ProtoPackage      string // billing.purchase_order.v1
+	ProtoPath         string // billing/purchase_order/v1, relative to proto/
+	ProtoGoPackage    string // purchaseorderv1
+	GraphQLType       string // BillingPurchaseOrder, unique across contexts like every GraphQL name
+	GraphQLField      string // billingPurchaseOrder, the query of one entity
+	GraphQLListField  string // billingPurchaseOrders, the query listing them
+
+	// Fields are the entity's fields besides the id, the effective period and
+	// the timestamps every entity has
+	Fields []FieldSpec
+	// DisplayField is the first required string or text field, which names an
+	// entity in examples, seed data and tests
+	DisplayField FieldSpec
+	// ExampleCreate is the JSON body of an example create request with every
+	// field, ExampleUpdate that of an update renaming the entity
+	ExampleCreate string
+	ExampleUpdate string
+	// HasFieldType reports whether a field has a type: {{if call .HasFieldType "json"}}
+	HasFieldType func(string) bool `json:"-"`
+	// Relations are the fields holding the ids of other entities of the context
+	Relations []Relation
+
+	NamespaceData
+}
+
+// NamespaceData describes the bounded context a domain belongs to. Domains
+// without a namespace live in the root context directly under internal/.
+type NamespaceData struct {
+	Namespace         string // billing, empty for the root context
+	NamespaceTitle    string // Billing, prefixes names that must be unique across contexts
+	NamespaceDir      string // internal/billing
+	RoutePrefix       string // /api/v1/billing
+	MigrationsDir     string // internal/database/migrations/billing
+	MigrationsTable   string // schema_migrations_billing
+	SchemaFile        string // internal/database/billing/schema.sql
+	APIPackage        string // billingapi, the import name used by the server wiring
+	ServicePackage    string // billingservice
+	RepositoryPackage string // billingrepository
+	ClientPackage     string // billingclient, the client SDK package under pkg/
+	EventsPackage     string // billingevents
+	CachePackage      string // billingcache
+	RPCPackage        string // billingrpc
+	GraphPackage      string // billinggraph
+	RepoVar           string // billingRepo
+	ServiceVar        string // billingSvc
+	HandlerVar        string // billingHandler
+	ChangesVar        string // billingChanges, the change brokers of the gRPC watchers
+	ResolverVar       string // billingResolver, the GraphQL resolver of the context
+	CachedVar         string // billingCached, the read cache the HTTP and GraphQL APIs share
+	DispatcherVar     string // billingDispatcher, the handlers of the events consumed from the broker
+}
+
+// Relation is a uuid field named after another domain of the same context
+// with an _id suffix: product_id relates an order to its product
+type Relation struct {
+	Field             FieldSpec // product_id
+	Name              string    // product - the JSON field and the include parameter value
+	Title             string    // Product - the Go field of the related entity in responses
+	GraphQLName       string    // product - the GraphQL field resolving the related entity
+	TargetTitle       string    // Product - the related domain's DomainTitle
+	TargetPluralTitle string    // Products
+	TargetPlural      string    // products
+	TargetGraphQLType string    // Product
+}
+
+// NamespaceGroup is a bounded context together with its domains
+type NamespaceGroup struct {
+	NamespaceData
+	Domains []DomainData
+}
+
+// ParseDomain splits a possibly namespaced domain ("billing.invoice") into its parts
+func ParseDomain(domain string) (namespace, name string) {
+	if i := strings.LastIndex(domain, "."); i >= 0 {
+		return domain[:i], domain[i+1:]
+	}
+	return "", domain
+}
+
+// ValidateNamespace checks that a namespace can be used as a Go package name
+func ValidateNamespace(namespace string) error {
+	if !namespacePattern.MatchString(namespace) {
+		return fmt.Errorf("%w: namespace %q must be a lowercase word of letters and digits", ErrInvalidDomain, namespace)
+	}
+
+	if token.IsKeyword(namespace) {
+		return fmt.Errorf("%w: namespace %q is a Go keyword", ErrInvalidDomain, namespace)
+	}
+
+	if reason, ok := reservedDomains()[namespace]; ok {
+		return fmt.Errorf("%w: namespace %q collides with a %s", ErrInvalidDomain, namespace, reason)
+	}
+
+	return nil
+}
+
+// ValidateDomains checks every domain of a project and rejects duplicates within a namespace.
+// The plural override, which may be empty, applies to the first (primary) domain.
+func ValidateDomains(domains []string, plural string) error {
+	seen := make(map[string]string)
+
+	for i, domain := range domains {
+		namespace, name := ParseDomain(domain)
+		if strings.Contains(namespace, ".") {
+			return fmt.Errorf("%w: %q may only be nested one level (namespace.domain)", ErrInvalidDomain, domain)
+		}
+
+		if namespace != "" {
+			if err := ValidateNamespace(namespace); err != nil {
+				return err
+			}
+		}
+
+		domainPlural := ""
+		if i == 0 {
+			domainPlural = plural
+		}
+
+		if err := ValidateDomain(name, domainPlural); err != nil {
+			return err
+		}
+
+		key := namespace + "." + NewDomainNames(name, domainPlural, "").Snake
+		if previous, ok := seen[key]; ok {
+			return fmt.Errorf("%w: %q and %q resolve to the same domain", ErrInvalidDomain, previous, domain)
+		}
+		seen[key] = domain
+	}
+
+	return nil
+}
+
+// ValidateTables checks that the database engine, DefaultDatabase if empty,
+// accepts the table of every domain. The plural override, which may be empty,
+// applies to the first (primary) domain.
+func ValidateTables(domains []string, plural, database string) error {
+	db, ok := lookupDatabase(database)
+	if !ok {
+		return ValidateDatabase(database)
+	}
+
+	for i, domain := range domains {
+		domainPlural, alternative := "", ""
+		if i == 0 {
+			domainPlural, alternative = plural, "; alternatively set --domain-plural"
+		}
+
+		table := newDomainData(domain, domainPlural, "").TableName
+		if db.reservesTable(table) {
+			_, name := ParseDomain(domain)
+			return fmt.Errorf("%w: the table %q of %q is a reserved word in %s%s%s",
+				ErrInvalidDomain, table, domain, db.Title, suggest(NewDomainNames(name, "", "").Snake), alternative)
+		}
+	}
+
+	return nil
+}
+
+// newNamespaceData derives package locations and identifiers for a namespace
+func newNamespaceData(namespace string) NamespaceData {
+	if namespace == "" {
+		return NamespaceData{
+			NamespaceDir:      "internal",
+			RoutePrefix:       "/api/v1",
+			MigrationsDir:     "internal/database/migrations",
+			MigrationsTable:   "schema_migrations",
+			SchemaFile:        "internal/database/schema.sql",
+			APIPackage:        "api",
+			ServicePackage:    "service",
+			RepositoryPackage: "repository",
+			ClientPackage:     "client",
+			EventsPackage:     "events",
+			CachePackage:      "cache",
+			RPCPackage:        "rpc",
+			GraphPackage:      "graph",
+			RepoVar:           "repo",
+			ServiceVar:        "svc",
+			HandlerVar:        "handler",
+			ChangesVar:        "changes",
+			ResolverVar:       "resolver",
+			CachedVar:         "cached",
+			DispatcherVar:     "dispatcher",
+		}
+	}
+
+	return NamespaceData{
+		Namespace:         namespace,
+		NamespaceTitle:    strings.ToUpper(namespace[:1]) + namespace[1:],
+		NamespaceDir:      path.Join("internal", namespace),
+		RoutePrefix:       "/api/v1/" + namespace,
+		MigrationsDir:     path.Join("internal/database/migrations", namespace),
+		MigrationsTable:   "schema_migrations_" + namespace,
+		SchemaFile:        path.Join("internal/database", namespace, "schema.sql"),
+		APIPackage:        namespace + "api",
+		ServicePackage:    namespace + "service",
+		RepositoryPackage: namespace + "repository",
+		ClientPackage:     namespace + "client",
+		EventsPackage:     namespace + "events",
+		CachePackage:      namespace + "cache",
+		RPCPackage:        namespace + "rpc",
+		GraphPackage:      namespace + "graph",
+		RepoVar:           namespace + "Repo",
+		ServiceVar:        namespace + "Svc",
+		HandlerVar:        namespace + "Handler",
+		ChangesVar:        namespace + "Changes",
+		ResolverVar:       namespace + "Resolver",
+		CachedVar:         namespace + "Cached",
+		DispatcherVar:     namespace + "Dispatcher",
+	}
+}
+
+// newDomainData derives all names for a domain. Plural and title overrides may be empty.
+func newDomainData(domain, plural, title string) DomainData {
+	namespace, name := ParseDomain(domain)
+	names := NewDomainNames(name, plural, title)
+
+	table := names.PluralSnake
+	protoPath := path.Join(names.Snake, "v1")
+	graphqlType, graphqlListType := names.Title, names.PluralTitle
+	if namespace != "" {
+		table = namespace + "_" + table
+		protoPath = path.Join(namespace, protoPath)
+		// Every context shares the one GraphQL schema
+		prefix := strings.ToUpper(namespace[:1]) + namespace[1:]
+		graphqlType, graphqlListType = prefix+graphqlType, prefix+graphqlListType
+	}
+
+	return DomainData{
+		Domain:            domain,
+		DomainTitle:       names.Title,
+		DomainCamel:       names.Camel,
+		DomainKebab:       names.Kebab,
+		DomainPlural:      names.PluralSnake,
+		DomainPluralTitle: names.PluralTitle,
+		DomainPluralLower: names.PluralSnake,
+		DomainPluralKebab: names.PluralKebab,
+		DomainLower:       names.Snake,
+		TableName:         table,
+		ProtoPackage:      strings.ReplaceAll(protoPath, "/", "."),
+		ProtoPath:         protoPath,
+		ProtoGoPackage:    strings.ReplaceAll(names.Snake, "_", "") + "v1",
+		GraphQLType:       graphqlType,
+		GraphQLField:      lowerFirst(graphqlType),
+		GraphQLListField:  lowerFirst(graphqlListType),
+		NamespaceData:     newNamespaceData(namespace),
+	}
+}
+
+// setFields sets the entity fields of the domain, DefaultFields if nil, typed
+// for the database engine, and the table as the engine's statements name it
+func (d *DomainData) setFields(fields []FieldSpec, db Database) {
+	if fields == nil {
+		fields = defaultFields()
+	}
+	d.SQLTable = db.quoteIdentifier(d.TableName)
+	d.Fields, d.DisplayField = entityFields(fields, d.DomainLower, db)
+	d.ExampleCreate, d.ExampleUpdate = exampleBodies(d.Fields, d.DisplayField)
+	types := make(map[string]bool)
+	for _, f := range d.Fields {
+		types[f.Type] = true
+	}
+	d.HasFieldType = func(t string) bool { return types[t] }
+}
+
+// setRelations finds the relations of every domain. A field whose name is
+// already taken by the related entity, such as product next to product_id,
+// leaves the relation out.
+func setRelations(domains []DomainData) {
+	for i := range domains {
+		d := &domains[i]
+		taken := make(map[string]bool, len(d.Fields))
+		for _, f := range d.Fields {
+			taken[f.Name] = true
+		}
+		d.Relations = nil
+		for _, f := range d.Fields {
+			name, ok := strings.CutSuffix(f.Name, "_id")
+			if f.Type != "uuid" || !ok || taken[name] {
+				continue
+			}
+			for _, target := range domains {
+				if target.Namespace != d.Namespace || target.DomainLower != name {
+					continue
+				}
+				d.Relations = append(d.Relations, Relation{
+					Field:             f,
+					Name:              name,
+					Title:             target.DomainTitle,
+					GraphQLName:       target.DomainCamel,
+					TargetTitle:       target.DomainTitle,
+					TargetPluralTitle: target.DomainPluralTitle,
+					TargetPlural:      target.DomainPlural,
+					TargetGraphQLType: target.GraphQLType,
+				})
+			}
+		}
+	}
+}
+
+// groupDomains numbers each domain's migration within its namespace and groups
+// the domains by namespace in order of first appearance
+func groupDomains(domains []DomainData) []NamespaceGroup {
+	var groups []NamespaceGroup
+	index := make(map[string]int)
+
+	for k := range domains {
+		i, ok := index[domains[k].Namespace]
+		if !ok {
+			i = len(groups)
+			index[domains[k].Namespace] = i
+			groups = append(groups, NamespaceGroup{NamespaceData: domains[k].NamespaceData})
+		}
+
+		domains[k].MigrationVersion = fmt.Sprintf("%03d", len(groups[i].Domains)+1)
+		groups[i].Domains = append(groups[i].Domains, domains[k])
+	}
+
+	return groups
+}
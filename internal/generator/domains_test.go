@@ -0,0 +1,36 @@
+package generator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateTables(t *testing.T) {
+	tests := []struct {
+		name     string
+		domains  []string
+		plural   string
+		database string
+		valid    bool
+	}{
+		{"plain plural", []string{"product", "group"}, "", "", true},
+		{"keyword plural", []string{"person"}, "user", "", false},
+		{"plural override of the primary domain only", []string{"person", "user"}, "", "", true},
+		{"keyword plural in a bounded context", []string{"billing.person"}, "user", "postgres", true},
+		{"keyword plural quoted by MySQL", []string{"person"}, "order", "mysql", true},
+		{"plural SQLite reserves", []string{"product", "other"}, "", "sqlite", false},
+		{"plural Postgres accepts", []string{"other"}, "", "postgres", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTables(tt.domains, tt.plural, tt.database)
+			if tt.valid && err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidDomain) {
+				t.Fatalf("expected ErrInvalidDomain, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Explanation describes what a configuration generates, without the project
+type Explanation struct {
+	Files []GeneratedFile
+	// Compose lists the docker compose files with the services each defines or overrides
+	Compose     []ComposeFile
+	Endpoints   []Endpoint // HTTP routes of the API server
+	MakeTargets []MakeHelp // Makefile targets listed by make help
+}
+
+// ComposeFile is a docker compose file of the project
+type ComposeFile struct {
+	Path     string
+	Services []string // in the order the file declares them
+}
+
+// Endpoint is an HTTP route of the generated API server
+type Endpoint struct {
+	Method string
+	Path   string
+}
+
+// MakeHelp is a documented target of the generated Makefile
+type MakeHelp struct {
+	Target      string
+	Description string
+}
+
+// makeHelpLine matches the targets make help lists: "name: deps ## description"
+var makeHelpLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_.-]*):(?:[^=].*?)?## (.*)$`)
+
+// Explain renders the project into a temporary directory, without running
+// the post-processing tools, and describes the result
+func Explain(config *ProjectConfig) (*Explanation, error) {
+	var explanation *Explanation
+	err := renderTemporary(config, nil, func(g *Generator, data *TemplateData, projectDir string) error {
+		explanation = &Explanation{Files: g.report.Files, Endpoints: endpoints(data)}
+		for _, f := range g.report.Files {
+			if !isComposeFile(f.Path) {
+				continue
+			}
+			services, err := composeServices(filepath.Join(projectDir, filepath.FromSlash(f.Path)))
+			if err != nil {
+				return err
+			}
+			explanation.Compose = append(explanation.Compose, ComposeFile{Path: f.Path, Services: services})
+		}
+
+		if makefile, err := os.ReadFile(filepath.Join(projectDir, "Makefile")); err == nil {
+			explanation.MakeTargets = makeHelp(makefile)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return explanation, nil
+}
+
+// renderTemporary renders the project into a temporary directory, without
+// the post-processing tools, and calls inspect before removing it. templates
+// is the generator's template tree, nil for the embedded templates.
+func renderTemporary(config *ProjectConfig, templates fs.FS, inspect func(g *Generator, data *TemplateData, projectDir string) error) error {
+	tmp, err := os.MkdirTemp("", "go-app-gen-explain-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	g := &Generator{outputDir: tmp, templates: templates}
+	data, projectDir, err := g.render(config)
+	if err != nil {
+		return err
+	}
+	return inspect(g, data, projectDir)
+}
+
+// isComposeFile reports whether a project file is a docker compose file
+func isComposeFile(p string) bool {
+	name := path.Base(p)
+	return p == name && (name == "docker-compose.yml" || strings.HasPrefix(name, "compose.") && strings.HasSuffix(name, ".yaml"))
+}
+
+// composeServices returns the services of a compose file in declaration order
+func composeServices(file string) ([]string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(file), err)
+	}
+	var doc struct {
+		Services yaml.Node `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(file), err)
+	}
+
+	var services []string
+	// A mapping node alternates keys and values
+	for i := 0; i+1 < len(doc.Services.Content); i += 2 {
+		services = append(services, doc.Services.Content[i].Value)
+	}
+	return services, nil
+}
+
+// makeHelp returns the Makefile targets with a ## description, as make help prints them
+func makeHelp(makefile []byte) []MakeHelp {
+	var targets []MakeHelp
+	scanner := bufio.NewScanner(bytes.NewReader(makefile))
+	for scanner.Scan() {
+		if m := makeHelpLine.FindStringSubmatch(scanner.Text()); m != nil {
+			targets = append(targets, MakeHelp{Target: m[1], Description: m[2]})
+		}
+	}
+	return targets
+}
+
+// endpoints returns the routes cmd/serve.go and the domain handlers register
+func endpoints(data *TemplateData) []Endpoint {
+	routes := []Endpoint{{Method: "GET", Path: "/api/v1/health"}}
+	if data.HasFeature("metrics") {
+		routes = append(routes, Endpoint{Method: "GET", Path: "/metrics"})
+	}
+	for _, d := range data.Domains {
+		base := d.RoutePrefix + "/" + d.DomainPluralKebab
+		routes = append(routes,
+			Endpoint{Method: "GET", Path: base},
+			Endpoint{Method: "POST", Path: base},
+			Endpoint{Method: "GET", Path: base + "/{id}"},
+			Endpoint{Method: "PATCH", Path: base + "/{id}"},
+			Endpoint{Method: "DELETE", Path: base + "/{id}"},
+		)
+	}
+	return routes
+}
@@ -0,0 +1,397 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrUnknownFeature is returned when a requested feature is not supported
+var ErrUnknownFeature = errors.New("unknown feature")
+
+// ErrMissingFeature is returned when a feature is requested without a feature it depends on
+var ErrMissingFeature = errors.New("missing required feature")
+
+// ErrConflictingFeatures is returned when features that replace each other are requested together
+var ErrConflictingFeatures = errors.New("conflicting features")
+
+// ErrUnsupportedDatabase is returned when a feature is requested with a database engine it does not support
+var ErrUnsupportedDatabase = errors.New("feature not supported by the database")
+
+// Feature is an optional part of a generated project
+type Feature struct {
+	Name        string
+	Description string
+
+	// Templates lists template paths, relative to templates/, that are only
+	// rendered when the feature is enabled. Entries ending in "/" match a directory.
+	// A template claimed by several features needs all of them.
+	Templates []string
+
+	// Requires lists features that must be enabled alongside this one
+	Requires []string
+
+	// Databases lists the database engines the feature supports, every engine if empty
+	Databases []string
+}
+
+// Features lists every optional feature the generator supports
+var Features = []Feature{
+	{
+		Name:        "grpc",
+		Description: "gRPC API with server-streaming watch and client-streaming bulk create RPCs, served next to HTTP",
+		Templates: []string{
+			"buf.yaml.tmpl",
+			"buf.gen.yaml.tmpl",
+			"docs/runbooks/watch-backlog.md.tmpl",
+			"proto/",
+			"internal/grpcserver/",
+			"internal/{{.namespace}}/rpc/",
+		},
+	},
+	{
+		Name:        "graphql",
+		Description: "GraphQL API generated by gqlgen from a schema per domain, with resolvers calling the services and a playground",
+		Templates: []string{
+			"gqlgen.yml.tmpl",
+			"internal/graphqlserver/",
+			"internal/{{.namespace}}/graph/",
+		},
+	},
+	{
+		Name:        "graphql-federation",
+		Description: "Apollo Federation v2 subgraph of the GraphQL API, with entity resolvers and a local router in compose",
+		Requires:    []string{"graphql"},
+		Templates: []string{
+			"deploy/federation/",
+			"internal/{{.namespace}}/graph/{{.domain}}_entity.go.tmpl",
+		},
+	},
+	{
+		Name:        "dataloader",
+		Description: "Per-request dataloaders batching and caching the lookups of related entities for GraphQL fields and ?include= in REST responses",
+		Templates: []string{
+			"docs/runbooks/dataloader.md.tmpl",
+			"internal/dataloader/",
+			"internal/{{.namespace}}/service/loaders.go.tmpl",
+			"internal/{{.namespace}}/service/{{.domain}}_loader.go.tmpl",
+			"internal/{{.namespace}}/service/{{.domain}}_loader_test.go.tmpl",
+		},
+	},
+	{
+		Name:        "events",
+		Description: "Versioned event payloads with upcasters and schema compatibility tests",
+		Templates: []string{
+			"internal/{{.namespace}}/events/",
+		},
+	},
+	{
+		Name:        "contract-tests",
+		Description: "Client SDK with Pact consumer tests, provider verification and broker CI stubs",
+		Templates: []string{
+			".github/workflows/contract-tests.yml.tmpl",
+			"pkg/{{.namespace}}client/",
+			"test/contract/",
+		},
+	},
+	{
+		Name:        "fault-injection",
+		Description: "Latency, error and connection reset injection middleware for resilience testing",
+		Templates: []string{
+			"internal/faults/",
+		},
+	},
+	{
+		Name:        "http-client",
+		Description: "Outbound HTTP client with retries, backoff, circuit breaking and per-host timeouts",
+		Templates: []string{
+			"docs/runbooks/outbound-http.md.tmpl",
+			"internal/httpclient/",
+			"internal/metrics/httpclient.go.tmpl",
+		},
+	},
+	{
+		Name:        "service-auth",
+		Description: "Signed service tokens (SPIFFE-style identities) for service-to-service calls",
+		Templates: []string{
+			"api/requests/auth.hurl.tmpl",
+			"cmd/authn.go.tmpl",
+			"docs/runbooks/service-auth.md.tmpl",
+			"internal/authn/",
+		},
+	},
+	{
+		Name:        "auth-oidc",
+		Description: "OIDC access token validation for identity providers such as Auth0 and Keycloak",
+		Templates: []string{
+			"docs/runbooks/auth-oidc.md.tmpl",
+			"internal/oidc/",
+		},
+	},
+	{
+		Name:        "rbac",
+		Description: "Role-based access control: roles and permissions in the database with seeded defaults, per-route permission checks and an authorization helper in the service layer",
+		Templates: []string{
+			"cmd/rbac.go.tmpl",
+			"docs/runbooks/rbac.md.tmpl",
+			"internal/database/migrations/rbac/",
+			"internal/database/rbac/",
+			"internal/rbac/",
+			"internal/{{.namespace}}/service/authorization.go.tmpl",
+		},
+	},
+	{
+		Name:        "openapi",
+		Description: "OpenAPI 3 spec with a Postman collection and local/dev/prod environments",
+		Templates: []string{
+			"api/openapi.go.tmpl",
+			"api/openapi.yaml.tmpl",
+			"api/postman/",
+		},
+	},
+	{
+		Name:        "mockserver",
+		Description: "Mock server answering from the OpenAPI spec with fake data, latency and errors",
+		Templates: []string{
+			"cmd/mockserver/",
+			"internal/mockserver/",
+		},
+		Requires: []string{"openapi"},
+	},
+	{
+		Name:        "read-cache",
+		Description: "Read-through cache for get-by-ID, invalidated by update and delete events",
+		Templates: []string{
+			"docs/runbooks/read-cache.md.tmpl",
+			"internal/{{.namespace}}/cache/",
+		},
+		Requires: []string{"events"},
+	},
+	{
+		Name:        "redis-cache",
+		Description: "Cache-aside Redis cache of the get-by-ID repository reads, invalidated on writes, with Redis in docker-compose",
+		Templates: []string{
+			"docs/runbooks/redis-cache.md.tmpl",
+			"internal/rediscache/",
+			"internal/{{.namespace}}/repository/cache.go.tmpl",
+			"internal/{{.namespace}}/repository/{{.domain}}_cache.go.tmpl",
+		},
+	},
+	{
+		Name:        "kafka",
+		Description: "Kafka producer publishing the domain events from the service layer, a consume command running a consumer group, and Kafka with a UI in docker-compose",
+		Templates: []string{
+			"internal/kafka/",
+			"internal/{{.namespace}}/events/kafka.go.tmpl",
+			"internal/{{.namespace}}/events/kafka_test.go.tmpl",
+			"internal/{{.namespace}}/events/{{.domain}}_kafka.go.tmpl",
+		},
+		Requires: []string{"events"},
+	},
+	{
+		Name:        "schema-registry",
+		Description: "Schema registry integration for Kafka: Avro or Protobuf schemas of every event version, serializers with a subject naming strategy, a schemas command checking their compatibility, and a schema registry in docker-compose",
+		Templates: []string{
+			"cmd/schemas.go.tmpl",
+			"internal/schemaregistry/",
+			"internal/{{.namespace}}/events/schemaregistry.go.tmpl",
+			"internal/{{.namespace}}/events/schemaregistry_test.go.tmpl",
+			"internal/{{.namespace}}/events/schemas/",
+		},
+		Requires: []string{"kafka"},
+	},
+	{
+		Name:        "nats",
+		Description: "NATS JetStream publisher of the domain events from the service layer, stream and consumer provisioning on startup, a consume command, and NATS in docker-compose",
+		Templates: []string{
+			"internal/nats/",
+			"internal/{{.namespace}}/events/nats.go.tmpl",
+			"internal/{{.namespace}}/events/nats_test.go.tmpl",
+			"internal/{{.namespace}}/events/{{.domain}}_nats.go.tmpl",
+		},
+		Requires: []string{"events"},
+	},
+	{
+		Name:        "reports",
+		Description: "Reports running SQL aggregates over the domains, rendered as CSV, XLSX and PDF into a report store by a reports command, once or on a schedule, with a download endpoint",
+		Templates: []string{
+			"cmd/reports.go.tmpl",
+			"docs/runbooks/reports.md.tmpl",
+			"internal/reports/",
+		},
+	},
+	{
+		Name:        "ai-search",
+		Description: "Semantic search of the domains with pgvector: an embedding column, a search index command embedding the rows with OpenAI or a local model, and a search endpoint (Postgres only)",
+		Templates: []string{
+			"cmd/search.go.tmpl",
+			"docs/runbooks/ai-search.md.tmpl",
+			"internal/aisearch/",
+			"internal/database/migrations/aisearch/",
+		},
+		Databases: []string{"postgres"},
+	},
+	{
+		Name:        "llm",
+		Description: "Language model integration with OpenAI, Anthropic or a local Ollama model: prompt templates, a response cache, token usage metrics and an endpoint enriching the domain records",
+		Templates: []string{
+			"docs/runbooks/llm.md.tmpl",
+			"internal/llm/",
+			"internal/metrics/llm.go.tmpl",
+		},
+	},
+	{
+		Name:        "metrics",
+		Description: "Prometheus metrics with Grafana dashboards and alert rules provisioned in docker-compose",
+		Templates: []string{
+			"deploy/grafana/dashboards/",
+			"deploy/grafana/provisioning/dashboards/",
+			"deploy/grafana/provisioning/datasources/prometheus.yml.tmpl",
+			"deploy/prometheus/",
+			"deploy/slo.yaml.tmpl",
+			"internal/metrics/",
+		},
+	},
+	{
+		Name:        "observability-logs",
+		Description: "Structured log fields shipped by Vector to Loki and Grafana in docker-compose",
+		Templates: []string{
+			"deploy/grafana/provisioning/datasources/loki.yml.tmpl",
+			"deploy/vector/",
+			"internal/logging/",
+		},
+	},
+	{
+		Name:        "sentry",
+		Description: "Sentry error reporting with panic capture, release tagging and PII scrubbing",
+		Templates: []string{
+			"internal/errorreport/",
+		},
+	},
+	{
+		Name:        "system-service",
+		Description: "service command installing the API as a Windows service or a systemd/launchd daemon (kardianos/service)",
+		Templates: []string{
+			"cmd/service.go.tmpl",
+			"internal/lifecycle/service.go.tmpl",
+			"internal/lifecycle/service_test.go.tmpl",
+			"internal/lifecycle/service_unix.go.tmpl",
+			"internal/lifecycle/service_windows.go.tmpl",
+		},
+	},
+	{
+		Name:        "goreleaser",
+		Description: "GoReleaser config building archives and deb/rpm packages with shell completions and man pages",
+		Templates: []string{
+			".goreleaser.yaml.tmpl",
+		},
+	},
+	{
+		Name:        "editor",
+		Description: "VS Code settings, tasks and launch configurations and GoLand run configurations for the compose setup",
+		Templates: []string{
+			".idea/",
+			".vscode/",
+		},
+	},
+	{
+		Name:        "dep-updates",
+		Description: "Renovate config grouping Go modules, Docker images and GitHub Actions with weekly automerged updates",
+		Templates: []string{
+			".github/renovate.json.tmpl",
+		},
+	},
+	{
+		Name:        "repo-hygiene",
+		Description: "GitHub issue forms, a PR template with the project's checks and CODEOWNERS seeded with the author",
+		Templates: []string{
+			".github/CODEOWNERS.tmpl",
+			".github/ISSUE_TEMPLATE/",
+			".github/pull_request_template.md.tmpl",
+		},
+	},
+	{
+		Name:        "docs-site",
+		Description: "MkDocs Material site with architecture, API reference and runbooks",
+		Templates: []string{
+			"mkdocs.yml.tmpl",
+			"docs/",
+		},
+	},
+}
+
+// FeatureNames returns the names of all supported features
+func FeatureNames() []string {
+	names := make([]string, len(Features))
+	for i, f := range Features {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// ValidateFeatures checks that every requested feature is supported, has its
+// requirements enabled and conflicts with none of the others
+func ValidateFeatures(features []string) error {
+	for _, name := range features {
+		feature, ok := findFeature(name)
+		if !ok {
+			return fmt.Errorf("%w: %q (available: %s)", ErrUnknownFeature, name, strings.Join(FeatureNames(), ", "))
+		}
+		for _, required := range feature.Requires {
+			if !slices.Contains(features, required) {
+				return fmt.Errorf("%w: %q requires %q", ErrMissingFeature, name, required)
+			}
+		}
+	}
+	return validateBrokers(features)
+}
+
+// ValidateFeatureDatabases checks that every requested feature supports the
+// database engine, DefaultDatabase if empty
+func ValidateFeatureDatabases(features []string, database string) error {
+	if database == "" {
+		database = DefaultDatabase
+	}
+	for _, name := range features {
+		feature, ok := findFeature(name)
+		if ok && len(feature.Databases) > 0 && !slices.Contains(feature.Databases, database) {
+			return fmt.Errorf("%w: %q requires --database %s, not %q", ErrUnsupportedDatabase, name, strings.Join(feature.Databases, " or "), database)
+		}
+	}
+	return nil
+}
+
+// findFeature returns the supported feature called name
+func findFeature(name string) (Feature, bool) {
+	for _, f := range Features {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Feature{}, false
+}
+
+// templateFeatures returns the features that own a template
+func templateFeatures(templatePath string) []string {
+	path := strings.TrimPrefix(templatePath, "templates/")
+
+	var owners []string
+	for _, f := range Features {
+		if ownsTemplate(f.Templates, path) {
+			owners = append(owners, f.Name)
+		}
+	}
+	return owners
+}
+
+// ownsTemplate reports whether a template path, relative to templates/, is one
+// of owned or lies in one of its directories
+func ownsTemplate(owned []string, path string) bool {
+	for _, o := range owned {
+		if path == o || (strings.HasSuffix(o, "/") && strings.HasPrefix(path, o)) {
+			return true
+		}
+	}
+	return false
+}
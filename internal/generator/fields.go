@@ -0,0 +1,377 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidField is returned when a field definition cannot be used for code generation
+var ErrInvalidField = errors.New("invalid field")
+
+// DefaultFields are the fields of a domain entity when none are given
+const DefaultFields = "name:string,description:text?"
+
+// fieldPattern accepts lowercase snake_case names, the spelling of columns and JSON keys
+var fieldPattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// FieldSpec is a field of a domain entity, with its spelling and type in every
+// layer of the generated code. Fields are given as name:type, where a type
+// ending in ? makes the field optional (description:text?).
+type FieldSpec struct {
+	Name       string // released_at - the column, JSON and protobuf field name
+	Title      string // ReleasedAt - the Go field, spelled as sqlc spells it (owner_id is OwnerID)
+	ProtoTitle string // ReleasedAt - the Go field protoc-gen-go generates (owner_id is OwnerId)
+	Label      string // released at - the name in prose
+	Type       string // timestamp, one of FieldTypeNames
+	Optional   bool   // nullable and may be left out of create requests
+
+	GoType         string // *time.Time - the type in models and create requests
+	GoUpdateType   string // *time.Time - the type in update requests, nil leaves the field unchanged
+	SQLType        string // TIMESTAMPTZ - the column type of the project's database
+	SQLName        string // released_at - the column in SQL statements, quoted where the database needs it
+	ProtoType      string // google.protobuf.Timestamp; optional scalars are declared optional
+	AvroType       string // {"type": "long", "logicalType": "timestamp-micros"} - the type in the Avro event schemas
+	GraphQLName    string // releasedAt - the GraphQL field name
+	GraphQLType    string // Time - the GraphQL type; required fields add the !
+	JSONType       string // string - the type in OpenAPI and JSON Schema
+	JSONFormat     string // date-time, empty if none
+	MaxLength      int    // 255 for strings, 0 when unbounded
+	Validate       string // validate tag of the create request field, empty if none
+	UpdateValidate string // validate tag of the update request field, empty if none
+	// ProtoNumber is the field number in the entity message and
+	// ProtoCreateNumber the one in the bulk create request; the default fields
+	// keep the numbers next to the id, later ones follow the fixed fields
+	ProtoNumber       int
+	ProtoCreateNumber int
+
+	Example       string // a JSON value, for example requests and fixtures
+	UpdateExample string // another JSON value, for example updates
+	SQLExample    string // Example as an SQL literal of SQLType
+}
+
+// fieldType describes how a field type is stored and exchanged
+type fieldType struct {
+	goType      string // of a required field; optional ones are pointers to it
+	sqlType     string
+	protoType   string
+	avroType    string
+	graphqlType string
+	jsonType    string
+	format      string
+	maxLength   int
+	// Validate tags of a required field on create and update, and of an optional one
+	validate, updateValidate, optionalValidate string
+	example, updateExample                     string // JSON values
+}
+
+// fieldTypes maps each field type to its representations. The Go types are
+// the ones sqlc generates with the overrides of the generated sqlc.yaml, so
+// models, requests and queries share them.
+var fieldTypes = map[string]fieldType{
+	"string":    {goType: "string", sqlType: "VARCHAR(255)", protoType: "string", avroType: `"string"`, graphqlType: "String", jsonType: "string", maxLength: 255, validate: "required,min=1,max=255", updateValidate: "omitempty,min=1,max=255", optionalValidate: "omitempty,max=255"},
+	"text":      {goType: "string", sqlType: "TEXT", protoType: "string", avroType: `"string"`, graphqlType: "String", jsonType: "string", validate: "required", updateValidate: "omitempty,min=1"},
+	"int":       {goType: "int32", sqlType: "INTEGER", protoType: "int32", avroType: `"int"`, graphqlType: "Int", jsonType: "integer", format: "int32", example: "1", updateExample: "2"},
+	"bigint":    {goType: "int64", sqlType: "BIGINT", protoType: "int64", avroType: `"long"`, graphqlType: "Int", jsonType: "integer", format: "int64", example: "1000", updateExample: "2000"},
+	"float":     {goType: "float64", sqlType: "DOUBLE PRECISION", protoType: "double", avroType: `"double"`, graphqlType: "Float", jsonType: "number", format: "double", example: "1.5", updateExample: "2.5"},
+	"decimal":   {goType: "string", sqlType: "NUMERIC(12,2)", protoType: "string", avroType: `"string"`, graphqlType: "String", jsonType: "string", format: "decimal", validate: "required,numeric", updateValidate: "omitempty,numeric", optionalValidate: "omitempty,numeric", example: `"19.99"`, updateExample: `"24.99"`},
+	"bool":      {goType: "bool", sqlType: "BOOLEAN", protoType: "bool", avroType: `"boolean"`, graphqlType: "Boolean", jsonType: "boolean", example: "true", updateExample: "false"},
+	"timestamp": {goType: "time.Time", sqlType: "TIMESTAMPTZ", protoType: "google.protobuf.Timestamp", avroType: `{"type": "long", "logicalType": "timestamp-micros"}`, graphqlType: "Time", jsonType: "string", format: "date-time", validate: "required", example: `"2024-06-01T00:00:00Z"`, updateExample: `"2024-07-01T00:00:00Z"`},
+	"uuid":      {goType: "uuid.UUID", sqlType: "UUID", protoType: "string", avroType: `{"type": "string", "logicalType": "uuid"}`, graphqlType: "ID", jsonType: "string", format: "uuid", example: `"5f2c8a9e-3b7d-4e1f-a6c4-9d8e7f6a5b10"`, updateExample: `"5f2c8a9e-3b7d-4e1f-a6c4-9d8e7f6a5b11"`},
+	"json":      {goType: "json.RawMessage", sqlType: "JSONB", protoType: "bytes", avroType: `{"type": "string", "contentType": "application/json"}`, graphqlType: "JSON", jsonType: "object", example: `{"key": "value"}`, updateExample: `{"key": "updated"}`},
+}
+
+// fixedFields are the columns every entity has besides its fields
+var fixedFields = []string{"id", "effective_start", "effective_end", "created_at", "updated_at", "deleted_at"}
+
+// reservedFields are names that collide with SQL keywords or with the methods
+// protoc-gen-go generates on messages
+var reservedFields = map[string]string{
+	"all": "SQL keyword", "and": "SQL keyword", "any": "SQL keyword", "array": "SQL keyword",
+	"as": "SQL keyword", "asc": "SQL keyword", "both": "SQL keyword", "case": "SQL keyword",
+	"cast": "SQL keyword", "check": "SQL keyword", "collate": "SQL keyword", "column": "SQL keyword",
+	"constraint": "SQL keyword", "create": "SQL keyword", "current_date": "SQL keyword",
+	"current_time": "SQL keyword", "current_timestamp": "SQL keyword", "current_user": "SQL keyword",
+	"default": "SQL keyword", "desc": "SQL keyword", "distinct": "SQL keyword", "do": "SQL keyword",
+	"else": "SQL keyword", "end": "SQL keyword", "except": "SQL keyword", "false": "SQL keyword",
+	"fetch": "SQL keyword", "for": "SQL keyword", "foreign": "SQL keyword", "from": "SQL keyword",
+	"grant": "SQL keyword", "group": "SQL keyword", "having": "SQL keyword", "in": "SQL keyword",
+	"intersect": "SQL keyword", "into": "SQL keyword", "lateral": "SQL keyword", "leading": "SQL keyword",
+	"limit": "SQL keyword", "localtime": "SQL keyword", "not": "SQL keyword", "null": "SQL keyword",
+	"offset": "SQL keyword", "on": "SQL keyword", "only": "SQL keyword", "or": "SQL keyword",
+	"order": "SQL keyword", "primary": "SQL keyword", "references": "SQL keyword", "returning": "SQL keyword",
+	"select": "SQL keyword", "some": "SQL keyword", "table": "SQL keyword", "then": "SQL keyword",
+	"to": "SQL keyword", "trailing": "SQL keyword", "true": "SQL keyword", "union": "SQL keyword",
+	"unique": "SQL keyword", "user": "SQL keyword", "using": "SQL keyword", "when": "SQL keyword",
+	"where": "SQL keyword", "window": "SQL keyword", "with": "SQL keyword",
+
+	"descriptor": "protobuf message method", "marshal": "protobuf message method",
+	"proto_message": "protobuf message method", "proto_reflect": "protobuf message method",
+	"reset": "protobuf message method", "string": "protobuf message method",
+	"unmarshal": "protobuf message method",
+}
+
+// FieldTypeNames returns the field types, sorted
+func FieldTypeNames() []string {
+	names := make([]string, 0, len(fieldTypes))
+	for name := range fieldTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseFields parses comma-separated name:type field definitions, such as
+// "name:string,price:decimal,released_at:timestamp?". An entity needs a
+// required string or text field to be named by in examples and seed data.
+func ParseFields(definitions string) ([]FieldSpec, error) {
+	var fields []FieldSpec
+	titles := make(map[string]string)
+	for _, definition := range strings.Split(definitions, ",") {
+		definition = strings.TrimSpace(definition)
+		if definition == "" {
+			continue
+		}
+		name, typ, ok := strings.Cut(definition, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q must be name:type (types: %s)", ErrInvalidField, definition, strings.Join(FieldTypeNames(), ", "))
+		}
+		field, err := newFieldSpec(strings.TrimSpace(name), strings.TrimSpace(typ))
+		if err != nil {
+			return nil, err
+		}
+		if previous, ok := titles[field.Title]; ok {
+			return nil, fmt.Errorf("%w: %q and %q are both the Go field %s", ErrInvalidField, previous, field.Name, field.Title)
+		}
+		titles[field.Title] = field.Name
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: no fields in %q", ErrInvalidField, definitions)
+	}
+	if _, ok := displayField(fields); !ok {
+		return nil, fmt.Errorf("%w: %q needs a required string or text field to name the entity, such as name:string", ErrInvalidField, definitions)
+	}
+	numberFields(fields)
+	return fields, nil
+}
+
+// newFieldSpec derives the spellings and types of one field
+func newFieldSpec(name, typ string) (FieldSpec, error) {
+	if !fieldPattern.MatchString(name) {
+		return FieldSpec{}, fmt.Errorf("%w: %q must be lowercase snake_case, starting with a letter", ErrInvalidField, name)
+	}
+	for _, fixed := range fixedFields {
+		if name == fixed {
+			return FieldSpec{}, fmt.Errorf("%w: %q is a column every entity has", ErrInvalidField, name)
+		}
+	}
+	if reason, ok := reservedFields[name]; ok {
+		return FieldSpec{}, fmt.Errorf("%w: %q collides with a %s", ErrInvalidField, name, reason)
+	}
+
+	base, optional := strings.CutSuffix(typ, "?")
+	t, ok := fieldTypes[base]
+	if !ok {
+		return FieldSpec{}, fmt.Errorf("%w: %s has the unknown type %q (types: %s)", ErrInvalidField, name, typ, strings.Join(FieldTypeNames(), ", "))
+	}
+
+	f := FieldSpec{
+		Name:           name,
+		Title:          sqlcTitle(name),
+		ProtoTitle:     protoTitle(name),
+		Label:          strings.ReplaceAll(name, "_", " "),
+		Type:           base,
+		Optional:       optional,
+		GoType:         t.goType,
+		GoUpdateType:   "*" + t.goType,
+		SQLType:        t.sqlType,
+		ProtoType:      t.protoType,
+		AvroType:       t.avroType,
+		GraphQLName:    lowerFirst(protoTitle(name)),
+		GraphQLType:    t.graphqlType,
+		JSONType:       t.jsonType,
+		JSONFormat:     t.format,
+		MaxLength:      t.maxLength,
+		Validate:       t.validate,
+		UpdateValidate: t.updateValidate,
+		Example:        t.example,
+		UpdateExample:  t.updateExample,
+	}
+	if optional {
+		f.GoType = "*" + t.goType
+		f.Validate, f.UpdateValidate = t.optionalValidate, t.optionalValidate
+	}
+	if base == "json" {
+		// A nil RawMessage is the null of an optional field and leaves an update unchanged
+		f.GoType, f.GoUpdateType = t.goType, t.goType
+	}
+	return f, nil
+}
+
+// numberFields assigns the protobuf field numbers. The entity message numbers
+// the id 1 and the effective period and timestamps 4 to 7, the bulk create
+// request the effective period 3 and 4: the first two fields take the numbers
+// in between, as name and description always had, and the others follow.
+func numberFields(fields []FieldSpec) {
+	for i := range fields {
+		if i < 2 {
+			fields[i].ProtoNumber, fields[i].ProtoCreateNumber = i+2, i+1
+		} else {
+			fields[i].ProtoNumber, fields[i].ProtoCreateNumber = i+6, i+3
+		}
+	}
+}
+
+// FormatFields returns the definitions ParseFields parses into fields
+func FormatFields(fields []FieldSpec) string {
+	definitions := make([]string, len(fields))
+	for i, f := range fields {
+		definitions[i] = f.Name + ":" + f.Type
+		if f.Optional {
+			definitions[i] += "?"
+		}
+	}
+	return strings.Join(definitions, ",")
+}
+
+// ParseDomainFields parses --fields values: definitions for the primary
+// domain, or domain=definitions for any domain of the project. Domains
+// without a value are left out of the result and get DefaultFields.
+func ParseDomainFields(values []string, domains []string) (map[string][]FieldSpec, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	result := make(map[string][]FieldSpec)
+	for _, value := range values {
+		domain, definitions, ok := strings.Cut(value, "=")
+		if !ok {
+			domain, definitions = domains[0], value
+		}
+		domain = strings.TrimSpace(domain)
+		if !slices.Contains(domains, domain) {
+			return nil, fmt.Errorf("%w: fields for %q, which is not a domain of the project (%s)", ErrInvalidField, domain, strings.Join(domains, ", "))
+		}
+		if _, ok := result[domain]; ok {
+			return nil, fmt.Errorf("%w: fields for %q are given twice", ErrInvalidField, domain)
+		}
+		fields, err := ParseFields(definitions)
+		if err != nil {
+			return nil, fmt.Errorf("fields of %s: %w", domain, err)
+		}
+		result[domain] = fields
+	}
+	return result, nil
+}
+
+// defaultFields are the parsed DefaultFields
+func defaultFields() []FieldSpec {
+	fields, err := ParseFields(DefaultFields)
+	if err != nil {
+		panic(err)
+	}
+	return fields
+}
+
+// displayField returns the first required string or text field
+func displayField(fields []FieldSpec) (FieldSpec, bool) {
+	for _, f := range fields {
+		if !f.Optional && (f.Type == "string" || f.Type == "text") {
+			return f, true
+		}
+	}
+	return FieldSpec{}, false
+}
+
+// entityFields returns the fields of a domain with the examples of their
+// strings, which mention the domain: the display field is "Example product"
+// and "Renamed product", the others "Example sku" and "Updated sku". Their
+// column types and SQL examples are those of the database engine.
+func entityFields(fields []FieldSpec, domain string, db Database) (all []FieldSpec, display FieldSpec) {
+	display, _ = displayField(fields)
+	all = make([]FieldSpec, len(fields))
+	for i, f := range fields {
+		if f.Type == "string" || f.Type == "text" {
+			example, update := "Example "+f.Label, "Updated "+f.Label
+			if f.Name == display.Name {
+				words := strings.ReplaceAll(domain, "_", " ")
+				example, update = "Example "+words, "Renamed "+words
+			}
+			f.Example, f.UpdateExample = jsonString(example), jsonString(update)
+		}
+		f = db.withDatabase(f)
+		all[i] = f
+		if f.Name == display.Name {
+			display = f
+		}
+	}
+	return all, display
+}
+
+// exampleBodies returns the JSON bodies of an example create request, with
+// every field, and of an example update, renaming the entity
+func exampleBodies(fields []FieldSpec, display FieldSpec) (create, update string) {
+	var b strings.Builder
+	b.WriteString("{")
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "\n  %q: %s", f.Name, f.Example)
+	}
+	b.WriteString("\n}")
+	return b.String(), fmt.Sprintf("{\n  %q: %s\n}", display.Name, display.UpdateExample)
+}
+
+// jsonString returns s as a JSON string
+func jsonString(s string) string {
+	encoded, _ := json.Marshal(s)
+	return string(encoded)
+}
+
+// sqlcTitle spells a column the way sqlc names its struct field: each word
+// title-cased, with the initialism id upper-cased
+func sqlcTitle(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		if word == "id" {
+			b.WriteString("ID")
+			continue
+		}
+		b.WriteString(titleCase(word))
+	}
+	return b.String()
+}
+
+// protoTitle spells a field the way protoc-gen-go names its Go field: an
+// underscore before a lowercase letter is dropped and the letter upper-cased,
+// as is the first letter of every run after a digit or other character
+func protoTitle(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '_' && i+1 < len(name) && isLowerASCII(name[i+1]):
+			// dropped; the next letter starts a word
+		case c >= '0' && c <= '9':
+			b.WriteByte(c)
+		default:
+			if isLowerASCII(c) {
+				c -= 'a' - 'A'
+			}
+			b.WriteByte(c)
+			for ; i+1 < len(name) && isLowerASCII(name[i+1]); i++ {
+				b.WriteByte(name[i+1])
+			}
+		}
+	}
+	return b.String()
+}
+
+func isLowerASCII(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
@@ -4,15 +4,16 @@ import (
 	"bytes"
 	"context"
 	"embed"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
-
-	"github.com/jinzhu/inflection"
 )
 
 //go:embed templates/*
@@ -26,28 +27,125 @@ type ProjectConfig struct {
 	Description string
 	Author      string
 	Features    []string
+
+	// TemplateDirs are template packs layered over the built-in templates, lowest priority first
+	TemplateDirs []string
+	// TemplateSource is the remote template repository, pinned to a commit,
+	// that FetchTemplates checked out as the first of TemplateDirs
+	TemplateSource string
+	// TemplateKeys are minisign or cosign public keys; when set, every template
+	// pack must ship a checksums.txt signed by one of them
+	TemplateKeys []string
+	// AllowHooks names the template packs whose hooks may run after
+	// post-processing; hooks of other packs are skipped
+	AllowHooks []string
+
+	// DeployTarget adds a runtime besides the container image, e.g. "wasm-edge"
+	DeployTarget string
+	// Database is the database engine, one of DatabaseNames; DefaultDatabase if empty
+	Database string
+	// Router is the HTTP router of the API layer, one of RouterNames; DefaultRouter if empty
+	Router string
+	// Logger is the logging library, one of LoggerNames; DefaultLogger if empty
+	Logger string
+	// CI is the CI provider the project gets a pipeline for, one of
+	// CIProviderNames; none if empty
+	CI string
+	// Archetype is the shape of service, one of ArchetypeNames; DefaultArchetype if empty
+	Archetype string
+
+	// Domains lists additional domains generated alongside Domain. Any domain
+	// may be namespaced ("billing.invoice") to group it into a bounded context.
+	Domains []string
+
+	// DomainPlural overrides the inflected plural form of Domain
+	DomainPlural string
+	// DomainTitle overrides the title-cased form of Domain (e.g. "SKU")
+	DomainTitle string
+	// Fields maps a domain, as Domain and Domains name it, to the fields of its
+	// entity; domains without an entry get DefaultFields
+	Fields map[string][]FieldSpec
+
+	// MakeTargets adds, replaces or extends targets of the generated Makefile
+	MakeTargets []MakeTarget
+
+	// Secrets is what credentials the secrets scan finds in the output do:
+	// SecretsWarn (the default), SecretsFail or SecretsOff
+	Secrets string
+
+	// Strict makes the optional post-processing steps, such as sqlc and the
+	// build, fail the generation when they fail instead of warning
+	Strict bool
+
+	// Header is injected at the top of generated source files
+	Header HeaderSpec
+	// GeneratorVersion is the go-app-gen version the header reports
+	GeneratorVersion string
+
+	// Lang is the language of the README and comments, DefaultLanguage if empty
+	Lang string
+	// MessageFiles are message bundles layered over the built-in and pack
+	// ones, e.g. a team's own translation
+	MessageFiles []string
 }
 
 // TemplateData holds the data passed to templates
+//
+// The embedded DomainData is the domain being rendered: each domain for
+// templates whose path contains a domain placeholder, the first domain of the
+// namespace for templates whose path contains {{.namespace}}, and the primary
+// domain for all other templates.
+//
+// Its fields make up TemplateDataSchema: fields may be added, but removing or
+// changing one bumps the schema.
 type TemplateData struct {
-	AppName           string
-	ModuleName        string
-	Domain            string
-	DomainTitle       string
-	DomainPlural      string
-	DomainPluralLower string
-	DomainLower       string
-	Description       string
-	Author            string
-	PackageImportPath string
-	GoVersion         string
-	HasFeature        func(string) bool
+	AppName    string // orders, the project and binary name
+	ModuleName string // github.com/acme/orders
+	DomainData
+	Domains           []DomainData      // every domain of the project
+	Namespaces        []NamespaceGroup  // domains grouped by bounded context
+	NamespaceDomains  []DomainData      // domains of the namespace being rendered
+	Description       string            // one-line project description
+	Author            string            // name, GitHub @handle or email
+	CodeOwner         string            // Author when it is a GitHub @handle or an email, for CODEOWNERS
+	PackageImportPath string            // import path of the project's packages, the module name
+	GoVersion         string            // 1.23, the go directive of the generated go.mod
+	MakeTargets       []MakeTarget      // Makefile targets from the project spec
+	DeployTarget      string            // extra runtime, empty for the container image only
+	Database          string            // postgres, mysql or sqlite, the database engine
+	DatabaseTitle     string            // Postgres, MySQL or SQLite, the name of Database in prose
+	SQLEngine         string            // postgresql, mysql or sqlite, the sqlc engine of Database
+	Router            string            // chi, stdlib, echo or gin, the HTTP router of the API layer
+	RouterTitle       string            // chi, net/http, Echo or Gin, the name of Router in prose
+	Logger            string            // slog, zerolog or zap, the logging library
+	LoggerTitle       string            // log/slog, zerolog or zap, the name of Logger in prose
+	CI                string            // github, gitlab or circleci, the CI provider of the pipeline; empty without one
+	CITitle           string            // GitHub Actions, GitLab CI or CircleCI, the name of CI in prose
+	Archetype         string            // api, webhook-receiver, gateway or pipeline, the shape of service
+	Broker            string            // kafka or nats, the feature publishing the domain events; empty without one
+	HasFeature        func(string) bool `json:"-"` // reports whether a feature is enabled: {{if call .HasFeature "metrics"}}
+	Lang              string            // en, the language of README sections and comments
+	// Msg returns a text of the message bundle of Lang, formatted with the
+	// arguments: {{call .Msg "readme.api.list" "orders"}}
+	Msg func(key string, args ...any) (string, error) `json:"-"`
+
+	config      *ProjectConfig // the configuration the data was built from, for the manifest
+	features    []string       // the enabled features, for the render cache
+	messages    Messages       // the texts Msg returns, hashed by the render cache
+	projectHash string         // hash of the project's data, set by the render cache
 }
 
 // Generator handles project generation
 type Generator struct {
 	outputDir string
 	verbose   bool
+	cache     *renderCache // nil unless UseCache is called
+	report    GenerationReport
+	layers    *Layers        // the template layers of the last render
+	hooks     []PipelineStep // the allowed pack hooks of the last render
+	source    string         // the remote template source of the last render
+	header    *header        // nil without a header template
+	templates fs.FS          // nil for the embedded templates
 }
 
 // New creates a new generator
@@ -66,21 +164,168 @@ func NewWithVerbose(outputDir string, verbose bool) *Generator {
 	}
 }
 
+// NewWithTemplates creates a new generator rendering the template tree at the
+// root of templates instead of the embedded templates. The tree is laid out
+// like internal/generator/templates and renders with the same path
+// placeholders, feature gating and post-processing; a template tree given as
+// the first TemplateDirs entry takes precedence.
+func NewWithTemplates(outputDir string, templates fs.FS) *Generator {
+	return &Generator{
+		outputDir: outputDir,
+		templates: treeFS{templates},
+	}
+}
+
 // Generate creates a new project based on the configuration
 func (g *Generator) Generate(config *ProjectConfig) error {
-	// Create template data
+	data, projectDir, err := g.render(config)
+	if err != nil {
+		return err
+	}
+
+	if err := g.scanSecrets(config, projectDir); err != nil {
+		return err
+	}
+
+	// Run post-processing
+	if err := g.PostProcess(projectDir, data); err != nil {
+		return fmt.Errorf("post-processing failed: %w", err)
+	}
+
+	return nil
+}
+
+// scanSecrets scans the rendered project for credentials unless the config
+// turns the scan off, failing with ErrSecretsFound under SecretsFail
+func (g *Generator) scanSecrets(config *ProjectConfig, projectDir string) error {
+	if config.Secrets == SecretsOff {
+		return nil
+	}
+	var err error
+	if g.report.Secrets, err = ScanSecrets(projectDir, g.report); err != nil {
+		return err
+	}
+	if config.Secrets == SecretsFail && len(g.report.Secrets) > 0 {
+		locations := make([]string, len(g.report.Secrets))
+		for i, f := range g.report.Secrets {
+			locations[i] = f.String()
+		}
+		return fmt.Errorf("%w:\n  %s", ErrSecretsFound, strings.Join(locations, "\n  "))
+	}
+	return nil
+}
+
+// DryRun renders the project into a temporary directory instead of the output
+// directory and skips the post-processing, returning the report of the files
+// Generate would write
+func (g *Generator) DryRun(config *ProjectConfig) (GenerationReport, error) {
+	err := renderTemporary(config, g.templates, func(rendered *Generator, _ *TemplateData, projectDir string) error {
+		g.report = rendered.report
+		if config.Secrets == SecretsOff {
+			return nil
+		}
+		var err error
+		g.report.Secrets, err = ScanSecrets(projectDir, g.report)
+		return err
+	})
+	return g.report, err
+}
+
+// render writes the project files without running the post-processing tools
+func (g *Generator) render(config *ProjectConfig) (*TemplateData, string, error) {
+	data := newTemplateData(config)
+	g.report = GenerationReport{}
+
+	layers, err := loadLayers(g.templates, config.TemplateDirs, config.TemplateKeys)
+	if err != nil {
+		return nil, "", err
+	}
+	g.layers = layers
+	g.hooks = hookSteps(layers, config.AllowHooks)
+	g.source = config.TemplateSource
+	if data.messages, err = LoadMessages(config.Lang, layers, config.MessageFiles); err != nil {
+		return nil, "", err
+	}
+	if g.header, err = newHeader(config); err != nil {
+		return nil, "", err
+	}
+
+	if err := ValidateAppName(config.AppName); err != nil {
+		return nil, "", err
+	}
+
+	// Create project directory
+	projectDir := filepath.Join(g.outputDir, config.AppName)
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		return nil, "", fmt.Errorf("failed to create project directory: %w", err)
+	}
+
+	// Process templates
+	if err := g.processTemplates(data, layers, projectDir); err != nil {
+		return nil, "", err
+	}
+
+	if layers.assemblesReadme() {
+		if err := g.writeReadme(data, projectDir); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := g.writeDotenv(projectDir); err != nil {
+		return nil, "", err
+	}
+
+	return data, projectDir, nil
+}
+
+// newTemplateData builds the data passed to templates from the project configuration
+func newTemplateData(config *ProjectConfig) *TemplateData {
+	db, _ := lookupDatabase(config.Database) // validated with the rest of the config
+	router, _ := lookupRouter(config.Router)
+	logger, _ := lookupLogger(config.Logger)
+	ci, _ := lookupCIProvider(config.CI)
+	archetype, _ := lookupArchetype(config.Archetype)
+	domains := []DomainData{newDomainData(config.Domain, config.DomainPlural, config.DomainTitle)}
+	for _, d := range config.Domains {
+		domains = append(domains, newDomainData(d, "", ""))
+	}
+	for i := range domains {
+		domains[i].setFields(config.Fields[domains[i].Domain], db)
+	}
+	setRelations(domains)
+	namespaces := groupDomains(domains)
+	lang := config.Lang
+	if lang == "" {
+		lang = DefaultLanguage
+	}
+
 	data := &TemplateData{
 		AppName:           config.AppName,
 		ModuleName:        config.ModuleName,
-		Domain:            config.Domain,
-		DomainTitle:       titleCase(config.Domain),
-		DomainPlural:      inflection.Plural(config.Domain),
-		DomainPluralLower: strings.ToLower(inflection.Plural(config.Domain)),
-		DomainLower:       strings.ToLower(config.Domain),
+		DomainData:        domains[0],
+		Domains:           domains,
+		Namespaces:        namespaces,
+		NamespaceDomains:  namespaces[0].Domains,
 		Description:       config.Description,
 		Author:            config.Author,
+		CodeOwner:         codeOwner(config.Author),
 		PackageImportPath: config.ModuleName,
 		GoVersion:         "1.23",
+		MakeTargets:       config.MakeTargets,
+		DeployTarget:      config.DeployTarget,
+		Database:          db.Name,
+		DatabaseTitle:     db.Title,
+		SQLEngine:         db.Engine,
+		Router:            router.Name,
+		RouterTitle:       router.Title,
+		Logger:            logger.Name,
+		LoggerTitle:       logger.Title,
+		CI:                ci.Name,
+		CITitle:           ci.Title,
+		Archetype:         archetype.Name,
+		Broker:            enabledBroker(config.Features),
+		config:            config,
+		features:          config.Features,
 		HasFeature: func(feature string) bool {
 			for _, f := range config.Features {
 				if f == feature {
@@ -89,73 +334,269 @@ func (g *Generator) Generate(config *ProjectConfig) error {
 			}
 			return false
 		},
+		Lang:     lang,
+		messages: builtinMessages(),
+	}
+	data.Msg = func(key string, args ...any) (string, error) {
+		return data.messages.message(key, args...)
 	}
+	return data
+}
 
-	// Create project directory
-	projectDir := filepath.Join(g.outputDir, config.AppName)
-	if err := os.MkdirAll(projectDir, 0755); err != nil {
-		return fmt.Errorf("failed to create project directory: %w", err)
+// codeOwner returns author if CODEOWNERS accepts it as an owner: a GitHub
+// @user or @org/team, or an email address of a GitHub account
+func codeOwner(author string) string {
+	author = strings.TrimSpace(author)
+	if author == "" || strings.ContainsAny(author, " \t") || !strings.Contains(author, "@") {
+		return ""
 	}
+	return author
+}
 
-	// Process templates
-	if err := g.processTemplates(data, projectDir); err != nil {
+// processTemplates renders every layer: the embedded templates or the template
+// tree replacing them and then the template packs, each skipping the templates
+// a higher layer overrides
+func (g *Generator) processTemplates(data *TemplateData, layers *Layers, projectDir string) error {
+	builtin, err := layers.baseTemplates()
+	if err != nil {
 		return err
 	}
-
-	// Run post-processing
-	if err := g.PostProcess(projectDir, data); err != nil {
-		return fmt.Errorf("post-processing failed: %w", err)
+	err = g.processTemplateDir(builtin, "templates", data, projectDir, func(path string) bool {
+		return layers.renders(builtinLayer, path) && data.enabled(path)
+	})
+	if err != nil {
+		return err
 	}
 
+	for _, pack := range layers.Packs {
+		name := pack.Manifest.Name
+		err := g.processPack(pack, builtin, data, projectDir, func(path string) bool {
+			if layers.overridesBuiltin(path) && !data.enabled(path) {
+				return false
+			}
+			return layers.renders(name, path)
+		})
+		if err != nil {
+			return fmt.Errorf("template pack %s: %w", name, err)
+		}
+	}
 	return nil
 }
 
-// processTemplates walks through the embedded templates and processes them
-func (g *Generator) processTemplates(data *TemplateData, projectDir string) error {
-	return fs.WalkDir(templatesFS, "templates", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
+// enabled reports whether a template is rendered for the project: it skips
+// templates owned by features that are not enabled and templates of other
+// deploy targets, CI providers, database engines, routers and archetypes
+func (data *TemplateData) enabled(templatePath string) bool {
+	for _, feature := range templateFeatures(templatePath) {
+		if !data.HasFeature(feature) {
+			return false
+		}
+	}
+	if target, ok := templateDeployTarget(templatePath); ok && target != data.DeployTarget {
+		return false
+	}
+	if provider, ok := templateCIProvider(templatePath); ok && provider != data.CI {
+		return false
+	}
+	if dbs := templateDatabases(templatePath); len(dbs) > 0 && !slices.Contains(dbs, data.Database) {
+		return false
+	}
+	if routers := templateRouters(templatePath); len(routers) > 0 && !slices.Contains(routers, data.Router) {
+		return false
+	}
+	if archetypes := templateArchetypes(templatePath); len(archetypes) > 0 && !slices.Contains(archetypes, data.Archetype) {
+		return false
+	}
+	if isBrokerTemplate(templatePath) && data.Broker == "" {
+		return false
+	}
+	return true
+}
+
+// processTemplateDir renders the templates of set under dir that include accepts.
+// Template paths start with "templates/", the prefix the output paths drop.
+func (g *Generator) processTemplateDir(set *templateSet, dir string, data *TemplateData, projectDir string, include func(path string) bool) error {
+	if g.cache != nil {
+		if err := g.cache.hashProject(data); err != nil {
 			return err
 		}
+	}
 
-		// Skip directories
-		if d.IsDir() {
-			return nil
+	for _, path := range set.paths {
+		if !strings.HasPrefix(path, dir+"/") || !include(path) {
+			continue
 		}
 
-		// Read template file
-		content, err := templatesFS.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("failed to read template file %s: %w", path, err)
+		// Render once per domain, once per namespace or once per project
+		for _, renderData := range g.templateScopes(path, data) {
+			if err := g.renderTemplate(set.templates[path], path, renderData, projectDir); err != nil {
+				return err
+			}
 		}
+	}
+	return nil
+}
 
-		// Process the template
-		tmpl, err := template.New(path).Parse(string(content))
-		if err != nil {
-			return fmt.Errorf("failed to parse template %s: %w", path, err)
+// writeDotenv copies .env.example to the gitignored .env so the project runs
+// without setup; an existing .env holds local secrets and is left alone, and
+// a template tree without .env.example gets none
+func (g *Generator) writeDotenv(projectDir string) error {
+	envPath := filepath.Join(projectDir, ".env")
+	if _, err := os.Stat(envPath); err == nil {
+		return nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(projectDir, ".env.example"))
+	if errors.Is(err, fs.ErrNotExist) && g.layers.base != nil {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read .env.example: %w", err)
+	}
+	if err := os.WriteFile(envPath, content, 0600); err != nil {
+		return fmt.Errorf("failed to write .env: %w", err)
+	}
+	g.record(projectDir, envPath, content)
+	return nil
+}
+
+// templateScopes returns the data each rendering of a template receives, based on the placeholders in its path
+func (g *Generator) templateScopes(templatePath string, data *TemplateData) []*TemplateData {
+	switch {
+	case isDomainTemplate(templatePath):
+		scopes := make([]*TemplateData, 0, len(data.Domains))
+		for _, ns := range data.Namespaces {
+			for _, d := range ns.Domains {
+				scopes = append(scopes, data.withDomain(d, ns.Domains))
+			}
+		}
+		return scopes
+	case strings.Contains(templatePath, "{{.namespace}}"):
+		scopes := make([]*TemplateData, 0, len(data.Namespaces))
+		for _, ns := range data.Namespaces {
+			scopes = append(scopes, data.withDomain(ns.Domains[0], ns.Domains))
 		}
+		return scopes
+	default:
+		return []*TemplateData{data}
+	}
+}
 
-		// Execute template
-		var buf bytes.Buffer
-		if err := tmpl.Execute(&buf, data); err != nil {
-			return fmt.Errorf("failed to execute template %s: %w", path, err)
+// isDomainTemplate reports whether a template is rendered once per domain
+func isDomainTemplate(templatePath string) bool {
+	for _, placeholder := range []string{"{{.Domain}}", "{{.domain}}", "{{.domain_plural}}", "{{.migration_version}}"} {
+		if strings.Contains(templatePath, placeholder) {
+			return true
 		}
+	}
+	return false
+}
 
-		// Determine output path
-		outputPath := g.getOutputPath(path, data)
-		outputPath = filepath.Join(projectDir, outputPath)
+// withDomain returns a copy of the data focused on a single domain and its namespace
+func (data *TemplateData) withDomain(domain DomainData, namespaceDomains []DomainData) *TemplateData {
+	scoped := *data
+	scoped.DomainData = domain
+	scoped.NamespaceDomains = namespaceDomains
+	return &scoped
+}
 
-		// Create directory if it doesn't exist
-		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-			return fmt.Errorf("failed to create directory for %s: %w", outputPath, err)
+// renderTemplate executes a template and writes the result into the project.
+// With a render cache, a cached output replaces the execution and an output
+// that is already on disk is not written again. Static assets are copied.
+func (g *Generator) renderTemplate(tmpl *parsedTemplate, templatePath string, data *TemplateData, projectDir string) error {
+	// Determine output path
+	outputPath, err := projectPath(projectDir, g.getOutputPath(templatePath, data))
+	if err != nil {
+		return err
+	}
+
+	if tmpl.asset != nil {
+		size, err := copyAsset(tmpl.asset, templatePath, outputPath)
+		if err != nil {
+			return err
 		}
+		g.recordAsset(projectDir, outputPath, size)
+		return nil
+	}
 
-		// Write file
-		if err := os.WriteFile(outputPath, buf.Bytes(), 0644); err != nil {
-			return fmt.Errorf("failed to write file %s: %w", outputPath, err)
+	var key string
+	var content []byte
+	cached := false
+	if g.cache != nil {
+		if key, err = g.cache.key(tmpl.hash, data); err != nil {
+			return err
 		}
+		content, cached = g.cache.get(key)
+	}
 
+	if !cached {
+		if content, err = executeTemplate(tmpl.tmpl, templatePath, data); err != nil {
+			return err
+		}
+		if g.cache != nil {
+			if err := g.cache.put(key, content); err != nil {
+				return err
+			}
+		}
+	}
+	if g.header != nil {
+		if content, err = g.header.apply(reportPath(projectDir, outputPath), g.layers.owner(templatePath), content); err != nil {
+			return err
+		}
+	}
+	g.record(projectDir, outputPath, content)
+
+	if g.cache != nil && g.cache.unchanged(outputPath, content) {
 		return nil
-	})
+	}
+
+	// Write file
+	if err := os.WriteFile(outputPath, content, 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", outputPath, err)
+	}
+
+	return nil
+}
+
+// copyAsset streams a static asset from fsys to outputPath, so large files
+// never sit in memory whole, and returns its size
+func copyAsset(fsys fs.FS, assetPath, outputPath string) (int64, error) {
+	src, err := fsys.Open(assetPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read asset %s: %w", assetPath, err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write file %s: %w", outputPath, err)
+	}
+	size, err := io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write file %s: %w", outputPath, err)
+	}
+	return size, nil
+}
+
+// executeTemplate renders a parsed template, applying the spec's Makefile targets to the Makefile
+func executeTemplate(tmpl *template.Template, templatePath string, data *TemplateData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrTemplateExecute, templatePath, err)
+	}
+
+	content := buf.Bytes()
+	if templatePath == "templates/Makefile.tmpl" && len(data.MakeTargets) > 0 {
+		customized, err := customizeMakefile(content, data.MakeTargets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to customize Makefile: %w", err)
+		}
+		content = customized
+	}
+	return content, nil
 }
 
 // getOutputPath converts template path to output path with substitutions
@@ -173,6 +614,8 @@ func (g *Generator) getOutputPath(templatePath string, data *TemplateData) strin
 	path = strings.ReplaceAll(path, "{{.Domain}}", data.Domain)
 	path = strings.ReplaceAll(path, "{{.domain}}", data.DomainLower)
 	path = strings.ReplaceAll(path, "{{.domain_plural}}", data.DomainPlural)
+	path = strings.ReplaceAll(path, "{{.namespace}}", data.Namespace)
+	path = strings.ReplaceAll(path, "{{.migration_version}}", data.MigrationVersion)
 
 	return path
 }
@@ -190,58 +633,19 @@ func (g *Generator) runCommand(ctx context.Context, projectDir string, name stri
 	return cmd.Run()
 }
 
-// PostProcess runs post-generation validation and setup tasks
+// PostProcess runs post-generation validation and setup tasks, recording
+// their progress in the project's manifest so a failed run can be resumed
 func (g *Generator) PostProcess(projectDir string, data *TemplateData) error {
-	ctx := context.Background()
-
 	fmt.Println("🔄 Running post-generation tasks...")
 
-	// Initialize go module
-	if err := g.runCommand(ctx, projectDir, "go", "mod", "init", data.ModuleName); err != nil {
-		return fmt.Errorf("failed to initialize go module: %w", err)
-	}
-
-	// Generate SQLc code first (before go mod tidy)
-	if err := g.runCommand(ctx, projectDir, "sqlc", "generate"); err != nil {
-		// SQLc might not be installed, so warn but don't fail
-		fmt.Printf("⚠️  SQLc generation failed: %v\n", err)
-		fmt.Println("   Consider installing sqlc: go install github.com/sqlc-dev/sqlc/cmd/sqlc@latest")
-		fmt.Println("   Or run 'make sqlc' in the project directory after setup")
-	} else {
-		fmt.Println("✅ SQLc code generation successful")
-	}
-
-	// Run go mod tidy (after SQLc generation)
-	if err := g.runCommand(ctx, projectDir, "go", "mod", "tidy"); err != nil {
-		return fmt.Errorf("failed to run go mod tidy: %w", err)
-	}
-
-	// Format generated Go code
-	if err := g.runCommand(ctx, projectDir, "go", "fmt", "./..."); err != nil {
-		return fmt.Errorf("failed to format generated code: %w", err)
-	}
-
-	// Fix imports (if goimports is available)
-	if err := g.runCommand(ctx, projectDir, "goimports", "-w", "."); err != nil {
-		// goimports might not be installed, so just warn instead of failing
-		fmt.Printf("⚠️  goimports not available or failed: %v\n", err)
-		fmt.Println("   Consider installing goimports: go install golang.org/x/tools/cmd/goimports@latest")
+	m, err := g.newManifest(data)
+	if err != nil {
+		return err
 	}
-
-	// Try to build to verify syntax (but allow failure)
-	if err := g.runCommand(ctx, projectDir, "go", "build", "./..."); err != nil {
-		fmt.Printf("⚠️  Build failed (this is expected if dependencies require database): %v\n", err)
-		fmt.Println("   Run 'make up' in the project directory to start the database and complete setup")
-	} else {
-		fmt.Println("✅ Build successful")
+	if err := g.runPipeline(projectDir, m); err != nil {
+		return err
 	}
-
-	fmt.Println("✅ Post-generation tasks completed")
-	fmt.Println("")
-	fmt.Println("Next steps:")
-	fmt.Println("  cd " + filepath.Base(projectDir))
-	fmt.Println("  make up      # Start the development environment")
-	fmt.Println("  make help    # See all available commands")
+	printNextSteps(projectDir)
 	return nil
 }
 
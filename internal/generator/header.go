@@ -0,0 +1,194 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ErrInvalidHeader is returned for a header with a malformed template or a
+// language without a comment syntax
+var ErrInvalidHeader = errors.New("invalid file header")
+
+// HeaderSpec is a comment header, such as a copyright notice, an SPDX license
+// line or a "generated by" note, injected at the top of generated source files
+//
+// Example:
+//
+//	header:
+//	  template: |
+//	    Copyright {{.Year}} Acme Corp.
+//	    SPDX-License-Identifier: Apache-2.0
+//	    Generated by go-app-gen {{.Version}} from the {{.Layer}} templates.
+//	  languages: [Go, SQL, Protobuf, Shell, YAML]
+type HeaderSpec struct {
+	// Template is a text/template rendered with HeaderData for every file;
+	// each of its lines becomes a comment line in the syntax of the file's language
+	Template string `yaml:"template"`
+	// Languages are the languages, as named in the generation summary, whose
+	// files get the header; Go, SQL, Protobuf and Shell if empty
+	Languages []string `yaml:"languages"`
+	// Exclude lists paths, relative to the project, that never get the
+	// header; entries ending in "/" match a directory
+	Exclude []string `yaml:"exclude"`
+}
+
+// HeaderData is the data a header template is rendered with
+type HeaderData struct {
+	AppName     string
+	ModuleName  string
+	Author      string
+	Description string
+	Year        int
+	Version     string // of go-app-gen, "dev" for an unreleased build
+	Layer       string // "built-in" or the template pack that rendered the file
+	Path        string // of the file, relative to the project
+}
+
+// defaultHeaderLanguages get the header when HeaderSpec.Languages is empty
+var defaultHeaderLanguages = []string{"Go", "SQL", "Protobuf", "Shell"}
+
+// commentSyntax is how a language comments a header: every line starts with
+// line, or the header is wrapped in open and close
+type commentSyntax struct {
+	line        string
+	open, close string
+}
+
+// commentSyntaxes are the languages a header can be injected into
+var commentSyntaxes = map[string]commentSyntax{
+	"Go":         {line: "//"},
+	"Protobuf":   {line: "//"},
+	"JavaScript": {line: "//"},
+	"TypeScript": {line: "//"},
+	"SQL":        {line: "--"},
+	"YAML":       {line: "#"},
+	"TOML":       {line: "#"},
+	"Shell":      {line: "#"},
+	"Hurl":       {line: "#"},
+	"Makefile":   {line: "#"},
+	"Dockerfile": {line: "#"},
+	"CSS":        {open: "/*", close: "*/"},
+	"HTML":       {open: "<!--", close: "-->"},
+	"Markdown":   {open: "<!--", close: "-->"},
+}
+
+// preamble matches the leading lines a header must go after: a shebang,
+// Dockerfile parser directives, or the front matter of Markdown files
+var preamble = regexp.MustCompile(`\A(?:#![^\n]*\n|(?:# *(?:syntax|escape|check) *=[^\n]*\n)+|---\n(?:[^\n]*\n)*?---\n)`)
+
+// header renders the file header of a generation
+type header struct {
+	tmpl      *template.Template
+	languages []string
+	exclude   []string
+	data      HeaderData
+}
+
+// HeaderLanguages returns the languages a header can be injected into
+func HeaderLanguages() []string {
+	names := make([]string, 0, len(commentSyntaxes))
+	for name := range commentSyntaxes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that the template parses and every language has a comment syntax
+func (s HeaderSpec) Validate() error {
+	_, err := s.compile()
+	return err
+}
+
+// compile parses the template; nil for an empty one
+func (s HeaderSpec) compile() (*header, error) {
+	if strings.TrimSpace(s.Template) == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("header").Option("missingkey=error").Parse(s.Template)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+	}
+	languages := s.Languages
+	if len(languages) == 0 {
+		languages = defaultHeaderLanguages
+	}
+	for _, lang := range languages {
+		if _, ok := commentSyntaxes[lang]; !ok {
+			return nil, fmt.Errorf("%w: %s files cannot hold comments; languages must be among %s", ErrInvalidHeader, lang, strings.Join(HeaderLanguages(), ", "))
+		}
+	}
+	return &header{tmpl: tmpl, languages: languages, exclude: s.Exclude}, nil
+}
+
+// newHeader returns the header of a generation, nil without a template
+func newHeader(config *ProjectConfig) (*header, error) {
+	h, err := config.Header.compile()
+	if h == nil || err != nil {
+		return nil, err
+	}
+	version := config.GeneratorVersion
+	if version == "" {
+		version = "dev"
+	}
+	h.data = HeaderData{
+		AppName:     config.AppName,
+		ModuleName:  config.ModuleName,
+		Author:      config.Author,
+		Description: config.Description,
+		Year:        time.Now().Year(),
+		Version:     version,
+	}
+	return h, nil
+}
+
+// apply prefixes the content of the file at rel, rendered by layer, with the
+// header commented in the file's language; other files are returned as they are
+func (h *header) apply(rel, layer string, content []byte) ([]byte, error) {
+	lang := GeneratedFile{Path: rel}.language()
+	if !slices.Contains(h.languages, lang) || ownsTemplate(h.exclude, rel) {
+		return content, nil
+	}
+
+	data := h.data
+	data.Layer, data.Path = layer, rel
+	var text bytes.Buffer
+	if err := h.tmpl.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("%w: the header of %s: %w", ErrInvalidHeader, rel, err)
+	}
+	lines := strings.Split(strings.TrimRight(text.String(), "\n"), "\n")
+
+	syntax := commentSyntaxes[lang]
+	var comment strings.Builder
+	if syntax.line == "" {
+		comment.WriteString(syntax.open + "\n")
+	}
+	for _, line := range lines {
+		switch {
+		case syntax.line == "":
+			comment.WriteString(line)
+		case line == "":
+			comment.WriteString(syntax.line)
+		default:
+			comment.WriteString(syntax.line + " " + line)
+		}
+		comment.WriteString("\n")
+	}
+	if syntax.line == "" {
+		comment.WriteString(syntax.close + "\n")
+	}
+	// A blank line keeps the header apart from package docs and build constraints
+	comment.WriteString("\n")
+
+	if lead := preamble.Find(content); lead != nil && (lang == "Markdown" || !bytes.HasPrefix(lead, []byte("---"))) {
+		return slices.Concat(lead, []byte(comment.String()), content[len(lead):]), nil
+	}
+	return append([]byte(comment.String()), content...), nil
+}
@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jinzhu/inflection"
+)
+
+// Import is what an existing contract, such as an OpenAPI specification or an
+// SQL schema, describes of the project implementing it
+type Import struct {
+	Name        string // the kebab-cased title, empty if the source has none
+	Description string // the first line of the source's description, without its period
+	// Domains are the resources of the source in its order, the first the
+	// primary domain; a schema or path segment namespaces them (billing.invoice)
+	Domains      []string
+	DomainPlural string // the primary domain's plural when it is not the inflected one
+	// Fields maps domains to the fields of their entity as name:type pairs,
+	// like --fields; domains without any have no entry
+	Fields map[string]string
+	// Warnings list what the import left out or changed: operations without
+	// a generated endpoint, columns and properties without a field type and
+	// plurals it cannot keep
+	Warnings []string
+}
+
+// addDomain adds the domain of a namespace's resource named by a plural,
+// such as a table or a collection path segment
+func (r *Import) addDomain(namespace, plural, source string) string {
+	words := splitWords(plural)
+	singular := slices.Clone(words)
+	singular[len(singular)-1] = inflection.Singular(singular[len(singular)-1])
+	name := strings.Join(singular, "_")
+	domain := name
+	if namespace != "" {
+		domain = strings.Join(splitWords(namespace), "_") + "." + name
+	}
+
+	if inflected := NewDomainNames(name, "", ""); inflected.PluralSnake != strings.Join(words, "_") {
+		if len(r.Domains) == 0 {
+			r.DomainPlural = strings.Join(words, "_")
+		} else {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("%s: only the primary domain's plural can be set, so %s uses the inflected plural %s", domain, source, inflected.PluralSnake))
+		}
+	}
+	r.Domains = append(r.Domains, domain)
+	return domain
+}
+
+// addField appends a field definition for the named column or property,
+// leaving out the fixed columns and, with a warning, what cannot be a field
+func (r *Import) addField(definitions []string, domain, source, typ string) []string {
+	name := strings.Join(splitWords(source), "_")
+	if slices.Contains(fixedFields, name) {
+		return definitions
+	}
+	for _, d := range definitions {
+		if strings.HasPrefix(d, name+":") {
+			return definitions
+		}
+	}
+	if _, err := newFieldSpec(name, typ); err != nil {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("%s: skipped %s: %v", domain, source, err))
+		return definitions
+	}
+	return append(definitions, name+":"+typ)
+}
@@ -0,0 +1,63 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jinzhu/inflection"
+	"gopkg.in/yaml.v3"
+)
+
+// InflectionRules holds custom pluralization rules for domain terms that the
+// default inflection rules get wrong
+//
+// Example rules file:
+//
+//	irregular:
+//	  schema: schemata
+//	  person: people
+//	uncountable:
+//	  - equipment
+//	plural:
+//	  - find: "(?i)(octop)us$"
+//	    replace: "${1}i"
+type InflectionRules struct {
+	Irregular   map[string]string `yaml:"irregular"`
+	Uncountable []string          `yaml:"uncountable"`
+	Plural      []InflectionRule  `yaml:"plural"`
+}
+
+// InflectionRule is a regular expression based pluralization rule
+type InflectionRule struct {
+	Find    string `yaml:"find"`
+	Replace string `yaml:"replace"`
+}
+
+// LoadInflectionRules reads a YAML rules file and registers its rules with the inflection package
+func LoadInflectionRules(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read inflection rules file: %w", err)
+	}
+
+	var rules InflectionRules
+	if err := yaml.Unmarshal(content, &rules); err != nil {
+		return fmt.Errorf("failed to parse inflection rules file %s: %w", path, err)
+	}
+
+	rules.Apply()
+	return nil
+}
+
+// Apply registers the rules with the inflection package
+func (r *InflectionRules) Apply() {
+	for _, rule := range r.Plural {
+		inflection.AddPlural(rule.Find, rule.Replace)
+	}
+	for singular, plural := range r.Irregular {
+		inflection.AddIrregular(singular, plural)
+	}
+	if len(r.Uncountable) > 0 {
+		inflection.AddUncountable(r.Uncountable...)
+	}
+}
@@ -0,0 +1,278 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ErrTemplateConflict is returned when template layers clash without declaring it
+var ErrTemplateConflict = errors.New("template conflict")
+
+// builtinLayer names the embedded templates in layer reports
+const builtinLayer = "built-in"
+
+// readmeTemplate is the path a layer overrides to replace the assembled README
+const readmeTemplate = "README.md.tmpl"
+
+// Layers is the stack of template sources a project is rendered from: the
+// built-in templates, or a template tree replacing them, at the bottom and
+// template packs on top of them, each pack above the ones before it. A pack
+// may only replace a template of a lower layer that it lists under overrides
+// in its manifest; any other clash is a conflict.
+type Layers struct {
+	// Tree is the directory of the template tree replacing the built-in
+	// templates, empty when the project renders from the embedded ones
+	Tree  string
+	Packs []*Pack
+
+	// Overrides lists the templates replaced by a higher layer, by template path
+	Overrides []Override
+
+	// owners maps each template path, relative to templates/, to the layer rendering it
+	owners map[string]string
+	// builtin holds the paths of the built-in templates
+	builtin map[string]bool
+	// base holds the bottom layer's templates under templates/, nil for the
+	// embedded ones; baseSet is parsed from it on first use
+	base    fs.FS
+	baseSet *templateSet
+	// treeReadme is set when the bottom layer has its own README.md.tmpl
+	// instead of the assembled README
+	treeReadme bool
+}
+
+// Override is a template of a lower layer replaced by a template pack
+type Override struct {
+	Path string // relative to templates/
+	Pack string // the overriding pack
+	Base string // the layer whose template is replaced
+}
+
+// LoadLayers loads the template packs in dirs, lowest priority first, verifies
+// them against their checksums.txt and, when keys are given, its signature, and
+// resolves which layer renders each template. All conflicts are reported
+// together. A first directory without a pack.yaml is a template tree that
+// replaces the built-in templates instead of layering over them.
+func LoadLayers(dirs, keys []string) (*Layers, error) {
+	return loadLayers(nil, dirs, keys)
+}
+
+// loadLayers is LoadLayers over the template tree in base, rendered instead of
+// the embedded templates unless nil or dirs starts with a tree of its own
+func loadLayers(base fs.FS, dirs, keys []string) (*Layers, error) {
+	layers := &Layers{owners: make(map[string]string), builtin: make(map[string]bool), base: base}
+	if len(dirs) > 0 && isTemplateTree(dirs[0]) {
+		tree, err := openTemplateTree(dirs[0])
+		if err != nil {
+			return nil, err
+		}
+		layers.Tree, layers.base, dirs = dirs[0], tree, dirs[1:]
+	}
+
+	builtin, err := templatePaths(layers.baseFS())
+	if err != nil {
+		return nil, err
+	}
+	layers.treeReadme = slices.Contains(builtin, readmeTemplate)
+	if !layers.treeReadme {
+		builtin = append(builtin, readmeTemplate)
+	}
+	for _, p := range builtin {
+		layers.owners[p] = builtinLayer
+		layers.builtin[p] = true
+	}
+
+	var conflicts []string
+	featureOwners := make(map[string]string)
+	for _, dir := range dirs {
+		if isTemplateTree(dir) {
+			return nil, fmt.Errorf("%w: %s has no %s; only the first template directory may be a template tree", ErrInvalidPack, dir, PackManifestFile)
+		}
+		pack, err := LoadPack(dir)
+		if err != nil {
+			return nil, err
+		}
+		if err := pack.verify(keys); err != nil {
+			return nil, err
+		}
+		name := pack.Manifest.Name
+		if slices.ContainsFunc(layers.Packs, func(p *Pack) bool { return p.Manifest.Name == name }) {
+			conflicts = append(conflicts, fmt.Sprintf("pack %s is layered twice", name))
+			continue
+		}
+		layers.Packs = append(layers.Packs, pack)
+
+		for _, f := range pack.Manifest.Features {
+			if owner, ok := featureOwners[f.Name]; ok {
+				conflicts = append(conflicts, fmt.Sprintf("feature %s is declared by both %s and %s", f.Name, owner, name))
+				continue
+			}
+			featureOwners[f.Name] = name
+		}
+
+		paths, err := templatePaths(os.DirFS(pack.Dir))
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range paths {
+			base, exists := layers.owners[p]
+			declared := slices.Contains(pack.Manifest.Overrides, p)
+			switch {
+			case exists && !declared:
+				conflicts = append(conflicts, fmt.Sprintf("%s: %s replaces the %s template without listing it under overrides", p, name, base))
+			case exists:
+				layers.Overrides = append(layers.Overrides, Override{Path: p, Pack: name, Base: base})
+			}
+			layers.owners[p] = name
+		}
+		for _, p := range pack.Manifest.Overrides {
+			if !slices.Contains(paths, p) {
+				conflicts = append(conflicts, fmt.Sprintf("%s: %s lists an override it has no template for", p, name))
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w:\n  %s", ErrTemplateConflict, strings.Join(conflicts, "\n  "))
+	}
+	return layers, nil
+}
+
+// Names returns the layer names from the bottom up
+func (l *Layers) Names() []string {
+	names := []string{builtinLayer}
+	if l.Tree != "" {
+		names[0] = l.Tree
+	}
+	for _, p := range l.Packs {
+		names = append(names, p.Manifest.Name)
+	}
+	return names
+}
+
+// FeatureNames returns the built-in features followed by those of the packs
+func (l *Layers) FeatureNames() []string {
+	names := FeatureNames()
+	for _, p := range l.Packs {
+		for _, f := range p.Manifest.Features {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// Features returns the built-in features followed by those of the packs
+func (l *Layers) Features() []Feature {
+	features := slices.Clone(Features)
+	for _, p := range l.Packs {
+		for _, f := range p.Manifest.Features {
+			features = append(features, Feature{Name: f.Name, Description: f.Description, Templates: f.Templates, Requires: f.Requires})
+		}
+	}
+	return features
+}
+
+// ValidateFeatures checks features like the package-level ValidateFeatures,
+// accepting the features of the packs too
+func (l *Layers) ValidateFeatures(features []string) error {
+	var builtin []string
+	for _, name := range features {
+		feature, ok := l.findFeature(name)
+		if !ok {
+			if _, ok := findFeature(name); !ok {
+				return fmt.Errorf("%w: %q (available: %s)", ErrUnknownFeature, name, strings.Join(l.FeatureNames(), ", "))
+			}
+			builtin = append(builtin, name)
+			continue
+		}
+		for _, required := range feature.Requires {
+			if !slices.Contains(features, required) {
+				return fmt.Errorf("%w: %q requires %q", ErrMissingFeature, name, required)
+			}
+		}
+	}
+	return ValidateFeatures(builtin)
+}
+
+// findFeature returns the pack feature called name
+func (l *Layers) findFeature(name string) (PackFeature, bool) {
+	for _, p := range l.Packs {
+		if f, ok := p.findFeature(name); ok {
+			return f, true
+		}
+	}
+	return PackFeature{}, false
+}
+
+// renders reports whether layer is the one rendering a template path
+func (l *Layers) renders(layer, templatePath string) bool {
+	owner, ok := l.owners[strings.TrimPrefix(templatePath, "templates/")]
+	return !ok || owner == layer
+}
+
+// owner returns the layer that renders a template
+func (l *Layers) owner(templatePath string) string {
+	if owner, ok := l.owners[strings.TrimPrefix(templatePath, "templates/")]; ok {
+		return owner
+	}
+	return builtinLayer
+}
+
+// overridesBuiltin reports whether a pack template replaces a built-in one,
+// whose feature and deploy target gating it then keeps
+func (l *Layers) overridesBuiltin(templatePath string) bool {
+	return l.builtin[strings.TrimPrefix(templatePath, "templates/")]
+}
+
+// baseFS returns the bottom layer's templates under templates/
+func (l *Layers) baseFS() fs.FS {
+	if l.base == nil {
+		return templatesFS
+	}
+	return l.base
+}
+
+// baseTemplates returns the bottom layer's templates, parsed the first time
+// they are rendered; the embedded ones are shared by every generation
+func (l *Layers) baseTemplates() (*templateSet, error) {
+	if l.base == nil {
+		return builtinTemplateSet()
+	}
+	if l.baseSet == nil {
+		set, err := parseTemplateSet(l.base, nil)
+		if err != nil {
+			return nil, fmt.Errorf("template tree: %w", err)
+		}
+		l.baseSet = set
+	}
+	return l.baseSet, nil
+}
+
+// assemblesReadme reports whether the README is assembled from the built-in
+// sections: no layer overrides it and the template tree has none of its own
+func (l *Layers) assemblesReadme() bool {
+	return l.renders(builtinLayer, readmeTemplate) && !l.treeReadme
+}
+
+// templatePaths lists the template paths under templates/ in fsys, relative to
+// it. Partials are left out: each layer's templates see their own.
+func templatePaths(fsys fs.FS) ([]string, error) {
+	var paths []string
+	err := fs.WalkDir(fsys, "templates", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(p, partialsDir) {
+			return err
+		}
+		paths = append(paths, strings.TrimPrefix(p, "templates/"))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
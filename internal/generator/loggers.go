@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownLogger is returned when a requested logging library is not supported
+var ErrUnknownLogger = errors.New("unknown logger")
+
+// DefaultLogger is the logging library of projects that do not choose one
+const DefaultLogger = "slog"
+
+// Logger is a logging library the generated project builds its logger with.
+// Code logging through log/slog is routed to it, so every choice writes the
+// same fields.
+type Logger struct {
+	Name        string
+	Title       string
+	Description string
+}
+
+// Loggers lists every logging library the generator supports
+var Loggers = []Logger{
+	{
+		Name:        "slog",
+		Title:       "log/slog",
+		Description: "The standard library's structured logger, no logging dependency",
+	},
+	{
+		Name:        "zerolog",
+		Title:       "zerolog",
+		Description: "rs/zerolog, zero-allocation JSON logging with a console writer for development",
+	},
+	{
+		Name:        "zap",
+		Title:       "zap",
+		Description: "uber-go/zap, typed fields with production and development encoder configs",
+	},
+}
+
+// LoggerNames returns the names of all supported logging libraries
+func LoggerNames() []string {
+	names := make([]string, len(Loggers))
+	for i, l := range Loggers {
+		names[i] = l.Name
+	}
+	return names
+}
+
+// ValidateLogger checks that name is empty, for DefaultLogger, or a supported
+// logging library
+func ValidateLogger(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := lookupLogger(name); ok {
+		return nil
+	}
+	return fmt.Errorf("%w: %q (available: %s)", ErrUnknownLogger, name, strings.Join(LoggerNames(), ", "))
+}
+
+// lookupLogger returns the logging library of a name, DefaultLogger if empty
+func lookupLogger(name string) (Logger, bool) {
+	if name == "" {
+		name = DefaultLogger
+	}
+	for _, l := range Loggers {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return Logger{}, false
+}
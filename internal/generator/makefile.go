@@ -0,0 +1,172 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrInvalidMakeTarget is returned for spec targets that cannot be written to a Makefile
+	ErrInvalidMakeTarget = errors.New("invalid make target")
+
+	// ErrMakeTargetExists is returned when a spec target without a mode clashes with a generated target
+	ErrMakeTargetExists = errors.New("make target already generated")
+
+	// ErrMakeTargetNotFound is returned when a spec target replaces or extends a target that is not generated
+	ErrMakeTargetNotFound = errors.New("make target not generated")
+)
+
+// Modes of a spec Makefile target
+const (
+	// MakeTargetAdd adds a new target; it is the default
+	MakeTargetAdd = ""
+	// MakeTargetReplace replaces the recipe of a generated target
+	MakeTargetReplace = "replace"
+	// MakeTargetAppend runs extra commands after the recipe of a generated target
+	MakeTargetAppend = "append"
+)
+
+var makeTargetName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// MakeTarget is a Makefile target declared in the project spec
+type MakeTarget struct {
+	// Name is the target name
+	Name string `yaml:"name"`
+	// Description is shown by make help
+	Description string `yaml:"description"`
+	// Deps are targets that run first
+	Deps []string `yaml:"deps"`
+	// Commands are the recipe lines, without the leading tab
+	Commands []string `yaml:"commands"`
+	// Mode is empty to add a target, "replace" or "append" to change a generated one
+	Mode string `yaml:"mode"`
+}
+
+// ValidateMakeTargets checks that spec targets have valid names, modes and recipes
+func ValidateMakeTargets(targets []MakeTarget) error {
+	seen := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		if !makeTargetName.MatchString(t.Name) {
+			return fmt.Errorf("%w: name %q", ErrInvalidMakeTarget, t.Name)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("%w: %q is declared twice", ErrInvalidMakeTarget, t.Name)
+		}
+		seen[t.Name] = true
+
+		switch t.Mode {
+		case MakeTargetAdd, MakeTargetReplace, MakeTargetAppend:
+		default:
+			return fmt.Errorf("%w: %q has mode %q (want replace or append)", ErrInvalidMakeTarget, t.Name, t.Mode)
+		}
+		if len(t.Commands) == 0 && len(t.Deps) == 0 {
+			return fmt.Errorf("%w: %q has neither commands nor deps", ErrInvalidMakeTarget, t.Name)
+		}
+		for _, dep := range t.Deps {
+			if !makeTargetName.MatchString(dep) {
+				return fmt.Errorf("%w: %q depends on %q", ErrInvalidMakeTarget, t.Name, dep)
+			}
+		}
+		for _, command := range t.Commands {
+			if strings.ContainsAny(command, "\r\n") {
+				return fmt.Errorf("%w: %q has a multi-line command; use one entry per line", ErrInvalidMakeTarget, t.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// customizeMakefile applies spec targets to a rendered Makefile. Replaced and
+// extended targets stay where they were generated; added targets are appended
+// under a "## Project" section.
+func customizeMakefile(content []byte, targets []MakeTarget) ([]byte, error) {
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+
+	var added []string
+	for _, t := range targets {
+		start, header, end, found := findMakeTarget(lines, t.Name)
+
+		switch {
+		case t.Mode == MakeTargetAdd && found:
+			return nil, fmt.Errorf("%w: %q (set mode to replace or append)", ErrMakeTargetExists, t.Name)
+		case t.Mode == MakeTargetAdd:
+			added = append(added, "", "# From the project spec")
+			added = append(added, t.block(nil)...)
+			continue
+		case !found:
+			return nil, fmt.Errorf("%w: %q", ErrMakeTargetNotFound, t.Name)
+		}
+
+		var block []string
+		if t.Mode == MakeTargetReplace {
+			block = append([]string{"# Replaced by the project spec"}, t.block(nil)...)
+		} else {
+			block = append([]string{"# Extended by the project spec"}, t.block(lines[header:end])...)
+		}
+		lines = append(lines[:start], append(block, lines[end:]...)...)
+	}
+
+	if len(added) > 0 {
+		lines = append(lines, "", "## Project")
+		lines = append(lines, added[1:]...)
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+// block renders the target; generated holds the header and recipe of the target it extends
+func (t MakeTarget) block(generated []string) []string {
+	deps := strings.Join(t.Deps, " ")
+
+	var header string
+	var recipe []string
+	if generated == nil {
+		header = t.Name + ":"
+		if deps != "" {
+			header += " " + deps
+		}
+		if t.Description != "" {
+			header += " ## " + t.Description
+		}
+	} else {
+		header, recipe = generated[0], generated[1:]
+		rest := strings.TrimPrefix(header, t.Name+":")
+		if t.Description != "" {
+			rest, _, _ = strings.Cut(rest, "##")
+			rest = strings.TrimRight(rest, " ") + " ## " + t.Description
+		}
+		if deps != "" {
+			rest = " " + deps + rest
+		}
+		header = t.Name + ":" + rest
+	}
+
+	block := []string{".PHONY: " + t.Name, header}
+	block = append(block, recipe...)
+	for _, command := range t.Commands {
+		block = append(block, "\t"+command)
+	}
+	return block
+}
+
+// findMakeTarget locates a target in the Makefile lines: start is its .PHONY line
+// (or header when there is none), header its rule line and end the line after its recipe
+func findMakeTarget(lines []string, name string) (start, header, end int, found bool) {
+	rule := regexp.MustCompile(`^` + regexp.QuoteMeta(name) + `:([^=]|$)`)
+	for i, line := range lines {
+		if !rule.MatchString(line) {
+			continue
+		}
+
+		start, header, end = i, i, i+1
+		if i > 0 && strings.TrimSpace(lines[i-1]) == ".PHONY: "+name {
+			start = i - 1
+		}
+		for end < len(lines) && strings.HasPrefix(lines[end], "\t") {
+			end++
+		}
+		return start, header, end, true
+	}
+	return 0, 0, 0, false
+}
@@ -0,0 +1,259 @@
+package generator
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the manifest written into every generated project
+const ManifestFile = ".go-app-gen.yaml"
+
+// Manifest is the .go-app-gen.yaml of a generated project
+type Manifest struct {
+	// Generator is the go-app-gen version the project was generated with
+	Generator string `yaml:"generator,omitempty"`
+
+	// Name to AllowHooks record the ProjectConfig the project was generated
+	// with, so later commands render it again the same way
+	Name        string   `yaml:"name,omitempty"`
+	Module      string   `yaml:"module"`
+	Description string   `yaml:"description,omitempty"`
+	Author      string   `yaml:"author,omitempty"`
+	Domains     []string `yaml:"domains,omitempty"`
+	// DomainPlural and DomainTitle override the spellings of the primary domain
+	DomainPlural string   `yaml:"domain_plural,omitempty"`
+	DomainTitle  string   `yaml:"domain_title,omitempty"`
+	Features     []string `yaml:"features,omitempty"`
+	// Fields maps the domains whose entity does not have DefaultFields to
+	// their field definitions
+	Fields       map[string]string `yaml:"fields,omitempty"`
+	DeployTarget string            `yaml:"deploy_target,omitempty"`
+	Database     string            `yaml:"database,omitempty"`
+	Router       string            `yaml:"router,omitempty"`
+	Logger       string            `yaml:"logger,omitempty"`
+	CI           string            `yaml:"ci,omitempty"`
+	Archetype    string            `yaml:"archetype,omitempty"`
+	MakeTargets  []MakeTarget      `yaml:"make_targets,omitempty"`
+	Header       HeaderSpec        `yaml:"header,omitempty"`
+	// Lang is the language of the README and comments, when not DefaultLanguage
+	Lang string `yaml:"lang,omitempty"`
+	// Messages are the absolute paths of the message bundles layered over the
+	// built-in and pack ones
+	Messages []string `yaml:"messages,omitempty"`
+	// TemplateKeys are the public keys the template packs were verified with
+	// and AllowHooks the packs whose hooks may run
+	TemplateKeys []string `yaml:"template_keys,omitempty"`
+	AllowHooks   []string `yaml:"allow_hooks,omitempty"`
+
+	// Templates is the digest of the built-in templates the project was
+	// generated with, or of the template tree replacing them, and Packs the
+	// template packs layered over them
+	Templates string `yaml:"templates"`
+	// TemplateTree is the absolute directory of the template tree, if any, and
+	// TemplateSource the repository and commit the tree or the lowest pack
+	// was checked out from with --template
+	TemplateTree   string         `yaml:"template_tree,omitempty"`
+	TemplateSource string         `yaml:"template_source,omitempty"`
+	Packs          []ManifestPack `yaml:"packs,omitempty"`
+	// Pipeline records the post-processing steps and how far they got, so an
+	// interrupted or failed generation can be resumed
+	Pipeline []PipelineStep `yaml:"pipeline"`
+	// Strict is whether optional steps that fail stop the pipeline, so
+	// resuming a strict generation stays strict
+	Strict bool `yaml:"strict,omitempty"`
+	// Files maps every generated file, slash-separated and relative to the
+	// project, to its sha256 once post-processing completed
+	Files map[string]string `yaml:"files"`
+}
+
+// ManifestPack is a template pack a project was generated with
+type ManifestPack struct {
+	Name   string `yaml:"name"`
+	Dir    string `yaml:"dir"` // absolute, so status finds the pack from the project
+	Digest string `yaml:"digest"`
+}
+
+// builtinDigest is the digest of the built-in templates, listed like a
+// pack's checksums.txt
+var builtinDigest = sync.OnceValues(func() (string, error) {
+	return templatesDigest(templatesFS)
+})
+
+// templatesDigest is the digest of the templates under templates/ in fsys
+func templatesDigest(fsys fs.FS) (string, error) {
+	var lines []string
+	err := fs.WalkDir(fsys, "templates", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s\n", templateHash(p, content), p))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash the templates: %w", err)
+	}
+	sort.Strings(lines)
+	return packDigest([]byte(strings.Join(lines, ""))), nil
+}
+
+// newManifest returns the manifest of a project about to be post-processed,
+// with the files and template layers of the generator's last render
+func (g *Generator) newManifest(data *TemplateData) (*Manifest, error) {
+	templates, err := builtinDigest()
+	if g.layers != nil && g.layers.base != nil {
+		templates, err = templatesDigest(g.layers.base)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{Module: data.ModuleName, Features: data.features, Templates: templates, TemplateSource: g.source, Files: make(map[string]string)}
+	if c := data.config; c != nil {
+		m.Generator, m.Name, m.Description, m.Author = c.GeneratorVersion, c.AppName, c.Description, c.Author
+		m.DomainPlural, m.DomainTitle, m.DeployTarget, m.Database = c.DomainPlural, c.DomainTitle, c.DeployTarget, c.Database
+		m.Router, m.Logger, m.CI, m.Archetype = c.Router, c.Logger, c.CI, c.Archetype
+		m.MakeTargets, m.Header, m.AllowHooks, m.Strict = c.MakeTargets, c.Header, c.AllowHooks, c.Strict
+		if m.Messages, err = absPaths(c.MessageFiles); err != nil {
+			return nil, fmt.Errorf("failed to resolve the message bundles: %w", err)
+		}
+		if m.TemplateKeys, err = absPaths(c.TemplateKeys); err != nil {
+			return nil, fmt.Errorf("failed to resolve the template keys: %w", err)
+		}
+	}
+	for _, d := range data.Domains {
+		m.Domains = append(m.Domains, d.Domain)
+		if fields := FormatFields(d.Fields); fields != DefaultFields {
+			if m.Fields == nil {
+				m.Fields = make(map[string]string)
+			}
+			m.Fields[d.Domain] = fields
+		}
+	}
+	if data.Lang != DefaultLanguage {
+		m.Lang = data.Lang
+	}
+	if g.layers != nil {
+		if g.layers.Tree != "" {
+			if m.TemplateTree, err = filepath.Abs(g.layers.Tree); err != nil {
+				return nil, fmt.Errorf("failed to resolve the template tree: %w", err)
+			}
+		}
+		for _, p := range g.layers.Packs {
+			dir, err := filepath.Abs(p.Dir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve template pack %s: %w", p.Manifest.Name, err)
+			}
+			m.Packs = append(m.Packs, ManifestPack{Name: p.Manifest.Name, Dir: dir, Digest: p.Digest})
+		}
+	}
+	for _, f := range g.report.Files {
+		if !isProjectOwned(f.Path) {
+			m.Files[f.Path] = ""
+		}
+	}
+	for _, step := range postSteps(data.ModuleName, data.features) {
+		m.Pipeline = append(m.Pipeline, PipelineStep{Name: step.name, Status: StepPending})
+	}
+	m.Pipeline = append(m.Pipeline, g.hooks...)
+	return m, nil
+}
+
+// absPaths resolves paths against the working directory, keeping KMS and
+// other URL references as they are
+func absPaths(paths []string) ([]string, error) {
+	var resolved []string
+	for _, p := range paths {
+		if !strings.Contains(p, "://") {
+			abs, err := filepath.Abs(p)
+			if err != nil {
+				return nil, err
+			}
+			p = abs
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
+
+// LoadManifest reads the manifest of a generated project
+func LoadManifest(projectDir string) (*Manifest, error) {
+	content, err := os.ReadFile(filepath.Join(projectDir, ManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: no %s in %s", ErrNotGeneratedProject, ManifestFile, projectDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ManifestFile, err)
+	}
+	return &m, nil
+}
+
+// write saves the manifest into the project
+func (m *Manifest) write(projectDir string) error {
+	content, err := yaml.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, ManifestFile), content, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// checksum records the sha256 of every generated file as post-processing left
+// it; files it removed are dropped
+func (m *Manifest) checksum(projectDir string) error {
+	for p := range m.Files {
+		sum, err := fileSHA256(filepath.Join(projectDir, filepath.FromSlash(p)))
+		if errors.Is(err, fs.ErrNotExist) {
+			delete(m.Files, p)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to checksum %s: %w", p, err)
+		}
+		m.Files[p] = hex.EncodeToString(sum)
+	}
+	return nil
+}
+
+// drifted returns the recorded checksums of the files changed since the
+// generation, so a later post-processing run does not record the changes as
+// generated
+func (m *Manifest) drifted(projectDir string) map[string]string {
+	drifted := make(map[string]string)
+	for p, recorded := range m.Files {
+		sum, err := fileSHA256(filepath.Join(projectDir, filepath.FromSlash(p)))
+		if recorded != "" && (err != nil || hex.EncodeToString(sum) != recorded) {
+			drifted[p] = recorded
+		}
+	}
+	return drifted
+}
+
+// Remaining returns the post-processing steps that have not completed
+func (m *Manifest) Remaining() []string {
+	var remaining []string
+	for _, step := range m.Pipeline {
+		if step.Status != StepDone {
+			remaining = append(remaining, step.Name)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,175 @@
+package generator
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed messages/*.yaml
+var messagesFS embed.FS
+
+// ErrUnknownLanguage is returned for a language no message bundle translates
+var ErrUnknownLanguage = errors.New("unknown language")
+
+// ErrInvalidMessages is returned for a message bundle that does not parse or
+// has keys the templates do not use
+var ErrInvalidMessages = errors.New("invalid message bundle")
+
+// DefaultLanguage is the language of the built-in templates, which every
+// bundle falls back to for the keys it does not translate
+const DefaultLanguage = "en"
+
+// packMessagesDir holds the message bundles of a template pack, one
+// <lang>.yaml per language
+const packMessagesDir = "messages"
+
+// Messages are the human-readable texts of the generated project, such as
+// README sections and code comments, by key
+//
+// Templates use them through TemplateData.Msg: {{call .Msg "readme.testing.title"}}.
+// Bundles are flat YAML maps from key to text, layered in this order:
+//
+//   - the built-in English bundle
+//   - the built-in bundle of the language
+//   - messages/<lang>.yaml of each template pack, lowest priority first
+//   - the bundle files given with --messages or in the spec
+type Messages map[string]string
+
+// Languages returns the languages with a built-in message bundle
+func Languages() []string {
+	entries, _ := fs.ReadDir(messagesFS, "messages")
+	var langs []string
+	for _, e := range entries {
+		langs = append(langs, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// LoadMessages returns the messages of lang: the built-in bundles, those of
+// the template packs and then the given bundle files. Keys missing from them
+// fall back to English; keys English does not have are rejected, since no
+// template would use them.
+func LoadMessages(lang string, layers *Layers, files []string) (Messages, error) {
+	if lang == "" {
+		lang = DefaultLanguage
+	}
+	if !validLanguage(lang) {
+		return nil, fmt.Errorf("%w: %q must be a language tag such as de or pt-BR", ErrUnknownLanguage, lang)
+	}
+
+	content, err := messagesFS.ReadFile("messages/" + DefaultLanguage + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built-in messages: %w", err)
+	}
+	messages, err := parseMessages(content, "built-in "+DefaultLanguage)
+	if err != nil {
+		return nil, err
+	}
+
+	translated := lang == DefaultLanguage
+	overlay := func(content []byte, source string) error {
+		bundle, err := parseMessages(content, source)
+		if err != nil {
+			return err
+		}
+		for key, text := range bundle {
+			if _, ok := messages[key]; !ok {
+				return fmt.Errorf("%w: %s has unknown key %q", ErrInvalidMessages, source, key)
+			}
+			messages[key] = text
+		}
+		translated = true
+		return nil
+	}
+
+	if content, err := messagesFS.ReadFile("messages/" + lang + ".yaml"); err == nil {
+		if err := overlay(content, "built-in "+lang); err != nil {
+			return nil, err
+		}
+	}
+	if layers != nil {
+		for _, p := range layers.Packs {
+			content, err := os.ReadFile(filepath.Join(p.Dir, packMessagesDir, lang+".yaml"))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read messages of %s: %w", p.Manifest.Name, err)
+			}
+			if err := overlay(content, p.Manifest.Name+"/"+packMessagesDir+"/"+lang+".yaml"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read messages: %w", err)
+		}
+		if err := overlay(content, file); err != nil {
+			return nil, err
+		}
+	}
+
+	if !translated {
+		return nil, fmt.Errorf("%w: no message bundle for %q; built-in languages are %s, or add messages/%s.yaml to a template pack or pass --messages", ErrUnknownLanguage, lang, strings.Join(Languages(), ", "), lang)
+	}
+	return messages, nil
+}
+
+// parseMessages decodes a bundle, which must map keys to texts
+func parseMessages(content []byte, source string) (Messages, error) {
+	var messages Messages
+	if len(bytes.TrimSpace(content)) == 0 {
+		return Messages{}, nil
+	}
+	if err := yaml.Unmarshal(content, &messages); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMessages, source, err)
+	}
+	return messages, nil
+}
+
+// validLanguage checks that lang looks like a BCP 47 tag (en, de, pt-BR), as
+// it names bundle files
+func validLanguage(lang string) bool {
+	for i, part := range strings.Split(lang, "-") {
+		if len(part) < 2 || len(part) > 8 || (i == 0 && len(part) > 3) {
+			return false
+		}
+		for _, r := range part {
+			if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || i > 0 && '0' <= r && r <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// message returns the text of key, formatted with args like fmt.Sprintf
+func (m Messages) message(key string, args ...any) (string, error) {
+	text, ok := m[key]
+	if !ok {
+		return "", fmt.Errorf("unknown message %q", key)
+	}
+	if len(args) > 0 {
+		text = fmt.Sprintf(text, args...)
+	}
+	return text, nil
+}
+
+// builtinMessages returns the built-in English messages, for templates
+// rendered without LoadMessages such as in benchmarks
+func builtinMessages() Messages {
+	messages, _ := LoadMessages(DefaultLanguage, nil, nil)
+	return messages
+}
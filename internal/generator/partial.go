@@ -0,0 +1,237 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nhalm/go-app-gen/internal/merge"
+)
+
+// ErrNothingSelected is returned when the filters of a partial generation
+// match none of the project's files
+var ErrNothingSelected = errors.New("no files selected")
+
+// Component is a named part of the project a partial generation selects,
+// with the output paths it covers
+type Component struct {
+	Name        string
+	Description string
+	// Paths are slash-separated output paths; an entry ending in / covers a
+	// directory, and * matches one path segment
+	Paths []string
+}
+
+// Components lists the parts of the project --only selects by name
+var Components = []Component{
+	{Name: "api", Description: "HTTP handlers, routes and the API specification", Paths: []string{"api/", "internal/api/", "internal/*/api/"}},
+	{Name: "service", Description: "Business logic of the domains", Paths: []string{"internal/service/", "internal/*/service/"}},
+	{Name: "repository", Description: "sqlc queries and repositories", Paths: []string{"internal/repository/", "internal/*/repository/", "sqlc.yaml"}},
+	{Name: "migrations", Description: "Database migrations and schemas", Paths: []string{"internal/database/migrations/", "internal/database/schema.sql", "internal/database/*/schema.sql"}},
+	{Name: "docker", Description: "Dockerfiles and compose files", Paths: []string{"Dockerfile", "Dockerfile.dev", ".dockerignore", "docker-compose.yml", "compose.*.yaml"}},
+	{Name: "config", Description: "Configuration files and loading", Paths: []string{"config/", "internal/config/", ".env.example"}},
+	{Name: "ci", Description: "CI workflows", Paths: []string{".github/workflows/", ".github/actions/", ".gitlab-ci.yml", ".circleci/"}},
+	{Name: "makefile", Description: "The Makefile", Paths: []string{"Makefile"}},
+	{Name: "docs", Description: "README and documentation site", Paths: []string{"README.md", "docs/", "mkdocs.yml"}},
+}
+
+// ComponentNames returns the names of the components
+func ComponentNames() []string {
+	names := make([]string, len(Components))
+	for i, c := range Components {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// PartialOptions selects the files GeneratePartial writes
+type PartialOptions struct {
+	// Only are component names or output paths, as Component.Paths
+	Only []string
+	// Force overwrites existing files whose content differs
+	Force bool
+	// DryRun reports what would be written without writing it
+	DryRun bool
+}
+
+// PartialResult reports what GeneratePartial did, with paths relative to the
+// project directory
+type PartialResult struct {
+	Created   []string
+	Updated   []string // existing files overwritten with Force
+	Unchanged []string
+	// Regions lists existing files whose generated regions, between the BEGIN
+	// go-app-gen and END go-app-gen markers, were replaced without Force,
+	// keeping the code around them
+	Regions []string
+	// Skipped lists existing files whose content differs, left alone without Force
+	Skipped []string
+	// Owned lists existing files the project owns, such as the service hooks,
+	// which are never overwritten
+	Owned []string
+}
+
+// GeneratePartial renders the project and writes only the files the filters
+// select into its directory, which may hold a codebase go-app-gen did not
+// generate. Neither the manifest nor the post-processing tools are run;
+// rendered Go files are gofmt-ed.
+func (g *Generator) GeneratePartial(config *ProjectConfig, opts PartialOptions) (*PartialResult, error) {
+	patterns, err := partialPatterns(opts.Only)
+	if err != nil {
+		return nil, err
+	}
+	projectDir := filepath.Join(g.outputDir, config.AppName)
+	if !opts.DryRun {
+		if err := os.MkdirAll(projectDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create project directory: %w", err)
+		}
+	}
+	result := &PartialResult{}
+
+	err = renderTemporary(config, g.templates, func(rendered *Generator, _ *TemplateData, renderDir string) error {
+		g.report = GenerationReport{}
+		var selected []string
+		for _, f := range rendered.report.Files {
+			if f.Path != ".env" && matchesAny(patterns, f.Path) {
+				selected = append(selected, f.Path)
+				g.report.Files = append(g.report.Files, f)
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("%w: --only %s matches none of the rendered files (components: %s)", ErrNothingSelected, strings.Join(opts.Only, ","), strings.Join(ComponentNames(), ", "))
+		}
+		sort.Strings(selected)
+
+		for _, rel := range selected {
+			if err := result.apply(projectDir, renderDir, rel, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// apply writes one selected file of the render into the project
+func (r *PartialResult) apply(projectDir, renderDir, rel string, opts PartialOptions) error {
+	content, err := os.ReadFile(filepath.Join(renderDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return fmt.Errorf("failed to read rendered %s: %w", rel, err)
+	}
+	if path.Ext(rel) == ".go" {
+		content = gofmt(content)
+	}
+
+	var target string
+	if opts.DryRun {
+		target = filepath.Join(projectDir, filepath.FromSlash(rel))
+	} else if target, err = projectPath(projectDir, rel); err != nil {
+		return err
+	}
+	existing, err := os.ReadFile(target)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		r.Created = append(r.Created, rel)
+	case err != nil:
+		return fmt.Errorf("failed to read %s: %w", rel, err)
+	case bytes.Equal(existing, content):
+		r.Unchanged = append(r.Unchanged, rel)
+		return nil
+	case isProjectOwned(rel):
+		r.Owned = append(r.Owned, rel)
+		return nil
+	case !opts.Force && merge.HasRegions(content):
+		replaced, err := merge.ReplaceRegions(existing, content)
+		switch {
+		case err != nil || len(replaced.Missing) > 0:
+			r.Skipped = append(r.Skipped, rel)
+			return nil
+		case len(replaced.Replaced) == 0:
+			r.Unchanged = append(r.Unchanged, rel)
+			return nil
+		}
+		r.Regions = append(r.Regions, rel)
+		content = replaced.Content
+	case !opts.Force:
+		r.Skipped = append(r.Skipped, rel)
+		return nil
+	default:
+		r.Updated = append(r.Updated, rel)
+	}
+
+	if opts.DryRun {
+		return nil
+	}
+	if err := os.WriteFile(target, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	return nil
+}
+
+// partialPatterns expands the --only filters to output path patterns: a
+// component name to its paths, anything else is a path itself
+func partialPatterns(only []string) ([]string, error) {
+	if len(only) == 0 {
+		return nil, fmt.Errorf("%w: no component or path given (components: %s)", ErrNothingSelected, strings.Join(ComponentNames(), ", "))
+	}
+	var patterns []string
+	for _, filter := range only {
+		filter = strings.TrimPrefix(path.Clean(filepath.ToSlash(filter)), "./")
+		if component, ok := findComponent(filter); ok {
+			patterns = append(patterns, component.Paths...)
+			continue
+		}
+		if path.IsAbs(filter) || filter == ".." || strings.HasPrefix(filter, "../") {
+			return nil, fmt.Errorf("--only %s: paths are relative to the project", filter)
+		}
+		if _, err := path.Match(filter, ""); err != nil {
+			return nil, fmt.Errorf("--only %s: %w", filter, err)
+		}
+		// A path selects the file, or everything under the directory
+		patterns = append(patterns, filter, filter+"/")
+	}
+	return patterns, nil
+}
+
+// findComponent returns the component of a name
+func findComponent(name string) (Component, bool) {
+	for _, c := range Components {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Component{}, false
+}
+
+// matchesAny reports whether a slash-separated output path is selected by
+// one of the patterns: a pattern ending in / selects what is below it
+func matchesAny(patterns []string, rel string) bool {
+	segments := strings.Split(rel, "/")
+	for _, pattern := range patterns {
+		dir := strings.HasSuffix(pattern, "/")
+		parts := strings.Split(strings.TrimSuffix(pattern, "/"), "/")
+		if len(parts) > len(segments) || !dir && len(parts) != len(segments) || dir && len(parts) == len(segments) {
+			continue
+		}
+		matched := true
+		for i, part := range parts {
+			if ok, _ := path.Match(part, segments[i]); !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsafePath is returned for a generated path that would be written outside
+// the project directory
+var ErrUnsafePath = errors.New("unsafe output path")
+
+// ValidateAppName checks that the app name is a single path element, since it
+// names the project directory inside the output directory
+func ValidateAppName(name string) error {
+	if !filepath.IsLocal(name) || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("%w: app name %q must be a plain directory name", ErrUnsafePath, name)
+	}
+	return nil
+}
+
+// projectPath joins a rendered output path, relative to the project, to
+// projectDir and creates its directory. Templates of packs choose their own
+// paths, so it refuses absolute paths, paths climbing out with "..", and
+// directories or files that are symlinks leading outside the project.
+func projectPath(projectDir, rel string) (string, error) {
+	rel = filepath.FromSlash(rel)
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%w: %s is not inside the project", ErrUnsafePath, filepath.ToSlash(rel))
+	}
+	root, err := filepath.EvalSymlinks(projectDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	outputPath := filepath.Join(projectDir, rel)
+
+	// Check the deepest directory that exists before creating the missing
+	// ones, so no directory is created through a symlink leading out
+	dir := filepath.Dir(outputPath)
+	for existing := dir; ; existing = filepath.Dir(existing) {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", existing, err)
+		}
+		if !within(root, resolved) {
+			return "", fmt.Errorf("%w: %s leads outside the project through a symlink", ErrUnsafePath, filepath.ToSlash(rel))
+		}
+		break
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory for %s: %w", outputPath, err)
+	}
+
+	if info, err := os.Lstat(outputPath); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		return "", fmt.Errorf("%w: %s is a symlink", ErrUnsafePath, filepath.ToSlash(rel))
+	}
+	return outputPath, nil
+}
+
+// within reports whether path is root or inside it; both must be resolved
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && (rel == "." || filepath.IsLocal(rel))
+}
+
+// checkSymlinks refuses symlinks under dir that resolve outside root, which
+// would let a template pack render or copy files from elsewhere on the machine
+func checkSymlinks(root, dir string) error {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.Type()&fs.ModeSymlink == 0 {
+			return err
+		}
+		resolved, err := filepath.EvalSymlinks(p)
+		if err != nil {
+			return fmt.Errorf("failed to resolve symlink %s: %w", p, err)
+		}
+		if !within(resolvedRoot, resolved) {
+			return fmt.Errorf("symlink %s points outside the pack", p)
+		}
+		return nil
+	})
+}
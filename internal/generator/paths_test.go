@@ -0,0 +1,256 @@
+package generator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// assertEmpty fails the test when anything was written to dir
+func assertEmpty(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		t.Errorf("%s was written outside the project", filepath.Join(dir, entry.Name()))
+	}
+}
+
+// symlink creates a symlink at link pointing to target
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+}
+
+var unsafePathTests = []struct {
+	name string
+	// setup prepares the project directory, given a directory outside of it
+	setup func(t *testing.T, projectDir, outside string)
+	// rel is the output path, where OUTSIDE stands for the outside directory
+	rel string
+}{
+	{name: "a parent directory", rel: "../x.go"},
+	{name: "a parent directory past a subdirectory", rel: "internal/../../x.go"},
+	{name: "an absolute path", rel: "OUTSIDE/x.go"},
+	{
+		name: "a symlinked directory leading outside",
+		setup: func(t *testing.T, projectDir, outside string) {
+			symlink(t, outside, filepath.Join(projectDir, "internal"))
+		},
+		rel: "internal/x.go",
+	},
+	{
+		name: "a missing directory below a symlinked one",
+		setup: func(t *testing.T, projectDir, outside string) {
+			symlink(t, outside, filepath.Join(projectDir, "internal"))
+		},
+		rel: "internal/api/v1/x.go",
+	},
+	{
+		name: "a symlinked directory deeper in the project",
+		setup: func(t *testing.T, projectDir, outside string) {
+			symlink(t, outside, filepath.Join(projectDir, "internal", "api"))
+		},
+		rel: "internal/api/x.go",
+	},
+	{
+		name: "a symlinked file",
+		setup: func(t *testing.T, projectDir, outside string) {
+			target := filepath.Join(filepath.Dir(outside), "target.go")
+			if err := os.WriteFile(target, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+			symlink(t, target, filepath.Join(projectDir, "x.go"))
+		},
+		rel: "x.go",
+	},
+	{
+		name: "a dangling symlink",
+		setup: func(t *testing.T, projectDir, outside string) {
+			symlink(t, filepath.Join(outside, "missing.go"), filepath.Join(projectDir, "x.go"))
+		},
+		rel: "x.go",
+	},
+	{
+		name: "a symlinked file inside the project",
+		setup: func(t *testing.T, projectDir, outside string) {
+			symlink(t, "other.go", filepath.Join(projectDir, "x.go"))
+		},
+		rel: "x.go",
+	},
+}
+
+func TestProjectPathRejectsUnsafePaths(t *testing.T) {
+	for _, tt := range unsafePathTests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			projectDir := filepath.Join(root, "project")
+			outside := filepath.Join(root, "outside")
+			for _, dir := range []string{projectDir, outside} {
+				if err := os.Mkdir(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.setup != nil {
+				tt.setup(t, projectDir, outside)
+			}
+			rel := tt.rel
+			if after, ok := strings.CutPrefix(rel, "OUTSIDE/"); ok {
+				rel = filepath.ToSlash(filepath.Join(outside, after))
+			}
+
+			path, err := projectPath(projectDir, rel)
+			if !errors.Is(err, ErrUnsafePath) {
+				t.Fatalf("projectPath(%q) = %q, %v, want ErrUnsafePath", rel, path, err)
+			}
+			assertEmpty(t, outside)
+		})
+	}
+}
+
+func TestProjectPathCreatesDirectories(t *testing.T) {
+	projectDir := t.TempDir()
+	// A symlink between directories of the project is kept
+	if err := os.Mkdir(filepath.Join(projectDir, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	symlink(t, "pkg", filepath.Join(projectDir, "lib"))
+
+	for _, rel := range []string{"main.go", "internal/api/v1/routes.go", "lib/util/util.go"} {
+		path, err := projectPath(projectDir, rel)
+		if err != nil {
+			t.Fatalf("projectPath(%q) error = %v", rel, err)
+		}
+		if want := filepath.Join(projectDir, filepath.FromSlash(rel)); path != want {
+			t.Errorf("projectPath(%q) = %q, want %q", rel, path, want)
+		}
+		if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+			t.Errorf("projectPath(%q) did not create its directory: %v", rel, err)
+		}
+	}
+}
+
+func TestValidateAppName(t *testing.T) {
+	for _, name := range []string{"shop", "my-app", "app_2"} {
+		if err := ValidateAppName(name); err != nil {
+			t.Errorf("ValidateAppName(%q) error = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "..", "../evil", "a/b", `a\b`, "/abs", "shop/.."} {
+		if err := ValidateAppName(name); !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("ValidateAppName(%q) error = %v, want ErrUnsafePath", name, err)
+		}
+	}
+}
+
+// TestGenerateRejectsUnsafeAppName generates a project whose name climbs out
+// of the output directory, which must write nothing beside it
+func TestGenerateRejectsUnsafeAppName(t *testing.T) {
+	for _, name := range []string{"../evil", "nested/app"} {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			outputDir := filepath.Join(root, "out")
+			if err := os.Mkdir(outputDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			err := New(outputDir).Generate(&ProjectConfig{AppName: name, ModuleName: "example.com/app", Domain: "product"})
+			if !errors.Is(err, ErrUnsafePath) {
+				t.Fatalf("Generate() error = %v, want ErrUnsafePath", err)
+			}
+			assertEmpty(t, outputDir)
+			entries, err := os.ReadDir(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("Generate() wrote %d entries beside the output directory", len(entries)-1)
+			}
+		})
+	}
+}
+
+// TestRenderPackRefusesSymlinkedDirectory renders a pack into a project whose
+// docs directory is a symlink leading outside it
+func TestRenderPackRefusesSymlinkedDirectory(t *testing.T) {
+	root := t.TempDir()
+	pack, err := New(root).InitPack("docs-pack", root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	projectDir := filepath.Join(root, "project")
+	outside := filepath.Join(root, "outside")
+	for _, dir := range []string{projectDir, outside} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	symlink(t, outside, filepath.Join(projectDir, "docs"))
+
+	config := &ProjectConfig{AppName: "shop", ModuleName: "example.com/shop", Domain: "product"}
+	if err := New(root).RenderPack(pack, config, projectDir); !errors.Is(err, ErrUnsafePath) {
+		t.Fatalf("RenderPack() error = %v, want ErrUnsafePath", err)
+	}
+	assertEmpty(t, outside)
+}
+
+func TestLoadPackRejectsEscapingSymlinks(t *testing.T) {
+	tests := []struct {
+		name string
+		// escape makes the templates of the pack in packDir lead to outside
+		escape func(t *testing.T, packDir, outside string)
+	}{
+		{
+			name: "a symlinked templates directory",
+			escape: func(t *testing.T, packDir, outside string) {
+				templates := filepath.Join(packDir, "templates")
+				if err := os.RemoveAll(templates); err != nil {
+					t.Fatal(err)
+				}
+				symlink(t, outside, templates)
+			},
+		},
+		{
+			name: "a symlinked template",
+			escape: func(t *testing.T, packDir, outside string) {
+				symlink(t, filepath.Join(outside, "secret.tmpl"), filepath.Join(packDir, "templates", "secret.tmpl"))
+			},
+		},
+		{
+			name: "a symlinked subdirectory",
+			escape: func(t *testing.T, packDir, outside string) {
+				symlink(t, outside, filepath.Join(packDir, "templates", "etc"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			if _, err := New(root).InitPack("escaping", root); err != nil {
+				t.Fatal(err)
+			}
+			outside := filepath.Join(root, "outside")
+			if err := os.Mkdir(outside, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(outside, "secret.tmpl"), []byte("secret\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			packDir := filepath.Join(root, "escaping")
+			tt.escape(t, packDir, outside)
+
+			if _, err := LoadPack(packDir); !errors.Is(err, ErrInvalidPack) {
+				t.Fatalf("LoadPack() error = %v, want ErrInvalidPack", err)
+			}
+		})
+	}
+}
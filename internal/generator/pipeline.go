@@ -0,0 +1,214 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrNothingToResume is returned by Resume when every post-processing step already ran
+var ErrNothingToResume = errors.New("nothing to resume")
+
+// StepError is the failure of a post-processing step, naming the step so
+// callers can tell what failed apart, e.g. the build from a missing sqlc
+type StepError struct {
+	Step string // the name of the step in the manifest's pipeline
+	Err  error
+}
+
+func (e *StepError) Error() string { return e.Err.Error() }
+
+func (e *StepError) Unwrap() error { return e.Err }
+
+// Statuses of a post-processing step in the manifest
+const (
+	StepPending = "pending"
+	StepDone    = "done"
+	StepWarned  = "warned" // an optional step failed, the generation went on
+	StepFailed  = "failed"
+)
+
+// PipelineStep is the state of a post-processing step
+type PipelineStep struct {
+	Name   string `yaml:"name"`
+	Status string `yaml:"status"`
+	Error  string `yaml:"error,omitempty"`
+	// Run is the command of a pack hook, which the user allowed at generation
+	Run []string `yaml:"run,omitempty"`
+}
+
+// postStep is a post-processing step: a command run in the project directory
+type postStep struct {
+	name string
+	args []string
+	// optional steps warn and print hint when they fail instead of stopping,
+	// unless the generation is strict
+	optional bool
+	// creates is a file the step creates; a step whose file exists already
+	// ran, even if the run was interrupted before its status was recorded
+	creates string
+	failure string // the error or warning prefix on failure
+	success string // printed when the step succeeds
+	hint    []string
+}
+
+// postSteps returns the post-processing steps of a project with features, in order
+func postSteps(module string, features []string) []postStep {
+	steps := []postStep{
+		{name: "mod-init", args: []string{"go", "mod", "init", module}, creates: "go.mod", failure: "failed to initialize go module"},
+		// Generate SQLc code first (before go mod tidy); sqlc might not be installed
+		{
+			name: "sqlc", args: []string{"sqlc", "generate"}, optional: true,
+			failure: "SQLc generation failed", success: "✅ SQLc code generation successful",
+			hint: []string{
+				"   Consider installing sqlc: go install github.com/sqlc-dev/sqlc/cmd/sqlc@latest",
+				"   Or run 'make sqlc' in the project directory after setup",
+			},
+		},
+	}
+	if slices.Contains(features, "grpc") {
+		// The gRPC code in gen/ is imported by the services, so it precedes go mod tidy
+		steps = append(steps, postStep{
+			name: "buf", args: []string{"buf", "generate"}, optional: true,
+			failure: "buf generation failed", success: "✅ gRPC code generation successful",
+			hint: []string{
+				"   Consider installing buf: go install github.com/bufbuild/buf/cmd/buf@latest",
+				"   Or run 'make proto' in the project directory after setup",
+			},
+		})
+	}
+	steps = append(steps, postStep{name: "mod-tidy", args: []string{"go", "mod", "tidy"}, failure: "failed to run go mod tidy"})
+	if slices.Contains(features, "graphql") {
+		// gqlgen runs from the module, which go mod tidy makes require it
+		steps = append(steps, postStep{
+			name: "gqlgen", args: []string{"go", "run", "github.com/99designs/gqlgen", "generate"}, optional: true,
+			failure: "gqlgen generation failed", success: "✅ GraphQL code generation successful",
+			hint: []string{"   Run 'make graphql' in the project directory after setup"},
+		})
+	}
+	return append(steps, []postStep{
+		{name: "fmt", args: []string{"go", "fmt", "./..."}, failure: "failed to format generated code"},
+		{
+			name: "goimports", args: []string{"goimports", "-w", "."}, optional: true,
+			failure: "goimports not available or failed",
+			hint:    []string{"   Consider installing goimports: go install golang.org/x/tools/cmd/goimports@latest"},
+		},
+		// Build to verify syntax, which may fail without the database
+		{
+			name: "build", args: []string{"go", "build", "./..."}, optional: true,
+			failure: "Build failed (this is expected if dependencies require database)", success: "✅ Build successful",
+			hint: []string{"   Run 'make up' in the project directory to start the database and complete setup"},
+		},
+	}...)
+}
+
+// hookSteps returns the pipeline steps of the hooks of the allowed packs,
+// named "hook:<pack>/<hook>"
+func hookSteps(layers *Layers, allowed []string) []PipelineStep {
+	var steps []PipelineStep
+	for _, p := range layers.Packs {
+		if !slices.Contains(allowed, p.Manifest.Name) {
+			continue
+		}
+		for _, h := range p.Manifest.Hooks {
+			steps = append(steps, PipelineStep{Name: "hook:" + p.Manifest.Name + "/" + h.Name, Status: StepPending, Run: h.Run})
+		}
+	}
+	return steps
+}
+
+// Resume runs the post-processing steps of a generated project that did not
+// complete, e.g. after go mod tidy failed during a network outage
+func (g *Generator) Resume(projectDir string) error {
+	m, err := LoadManifest(projectDir)
+	if err != nil {
+		return err
+	}
+	if len(m.Remaining()) == 0 {
+		return fmt.Errorf("%w: every post-processing step of %s completed", ErrNothingToResume, projectDir)
+	}
+	if err := g.runPipeline(projectDir, m); err != nil {
+		return err
+	}
+	printNextSteps(projectDir)
+	return nil
+}
+
+// runPipeline runs the steps of the manifest that are not done, recording
+// the status of each in the project's manifest as it goes
+func (g *Generator) runPipeline(projectDir string, m *Manifest) error {
+	ctx := context.Background()
+	steps := make(map[string]postStep)
+	for _, step := range postSteps(m.Module, m.Features) {
+		steps[step.name] = step
+	}
+	if err := m.write(projectDir); err != nil {
+		return err
+	}
+
+	for i := range m.Pipeline {
+		state := &m.Pipeline[i]
+		if state.Status == StepDone {
+			continue
+		}
+		step, ok := steps[state.Name]
+		if !ok && len(state.Run) > 0 {
+			step, ok = postStep{name: state.Name, args: state.Run, failure: state.Name + " failed"}, true
+			fmt.Printf("🪝 Running %s: %s\n", state.Name, strings.Join(state.Run, " "))
+		}
+		if !ok {
+			return fmt.Errorf("failed to resume: unknown post-processing step %q in %s", state.Name, ManifestFile)
+		}
+
+		var err error
+		if _, statErr := os.Stat(filepath.Join(projectDir, step.creates)); step.creates == "" || statErr != nil {
+			err = g.runCommand(ctx, projectDir, step.args[0], step.args[1:]...)
+		}
+		state.Status, state.Error = StepDone, ""
+		switch {
+		case err != nil && step.optional && !m.Strict:
+			state.Status, state.Error = StepWarned, err.Error()
+			fmt.Printf("⚠️  %s: %v\n", step.failure, err)
+			for _, line := range step.hint {
+				fmt.Println(line)
+			}
+		case err != nil:
+			state.Status, state.Error = StepFailed, err.Error()
+			for _, line := range step.hint {
+				fmt.Println(line)
+			}
+		case step.success != "":
+			fmt.Println(step.success)
+		}
+
+		if writeErr := m.write(projectDir); writeErr != nil {
+			return writeErr
+		}
+		if state.Status == StepFailed {
+			return fmt.Errorf("%s: %w (run 'go-app-gen resume %s' once fixed)", step.failure, &StepError{Step: state.Name, Err: err}, projectDir)
+		}
+	}
+
+	if err := m.checksum(projectDir); err != nil {
+		return err
+	}
+	if err := m.write(projectDir); err != nil {
+		return err
+	}
+
+	fmt.Println("✅ Post-generation tasks completed")
+	return nil
+}
+
+// printNextSteps prints how to start working on a generated project
+func printNextSteps(projectDir string) {
+	fmt.Println("")
+	fmt.Println("Next steps:")
+	fmt.Println("  cd " + filepath.Base(projectDir))
+	fmt.Println("  make up      # Start the development environment")
+	fmt.Println("  make help    # See all available commands")
+}
@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FeaturePreview shows what enabling a feature adds to a project
+type FeaturePreview struct {
+	Feature Feature
+	// Requires lists the features enabled along with it because it needs them
+	Requires []string
+	// Files lists the files the feature and the features it requires add
+	Files []GeneratedFile
+	// Sample is a representative file of Files, the first Go file that is not
+	// a test if there is one, and SampleContent is that file rendered
+	Sample        string
+	SampleContent []byte
+}
+
+// PreviewFeature renders the project with and without a feature of the layers
+// and reports the files the feature adds
+func PreviewFeature(config *ProjectConfig, layers *Layers, name string) (*FeaturePreview, error) {
+	features := layers.Features()
+	i := slices.IndexFunc(features, func(f Feature) bool { return f.Name == name })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownFeature, name, strings.Join(layers.FeatureNames(), ", "))
+	}
+	preview := &FeaturePreview{Feature: features[i]}
+
+	base := *config
+	base.Features = slices.DeleteFunc(slices.Clone(config.Features), func(f string) bool { return f == name })
+	with := base
+	with.Features = slices.Clone(base.Features)
+	for _, f := range withRequirements(features, name) {
+		if !slices.Contains(with.Features, f) {
+			with.Features = append(with.Features, f)
+			if f != name {
+				preview.Requires = append(preview.Requires, f)
+			}
+		}
+	}
+
+	existing := make(map[string]bool)
+	err := renderTemporary(&base, nil, func(g *Generator, _ *TemplateData, _ string) error {
+		for _, f := range g.report.Files {
+			existing[f.Path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = renderTemporary(&with, nil, func(g *Generator, _ *TemplateData, projectDir string) error {
+		for _, f := range g.report.Files {
+			if !existing[f.Path] {
+				preview.Files = append(preview.Files, f)
+			}
+		}
+		preview.Sample = sampleFile(preview.Files)
+		if preview.Sample == "" {
+			return nil
+		}
+		content, err := os.ReadFile(filepath.Join(projectDir, filepath.FromSlash(preview.Sample)))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", preview.Sample, err)
+		}
+		preview.SampleContent = content
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// withRequirements returns a feature after the features it requires, transitively
+func withRequirements(features []Feature, name string) []string {
+	var order []string
+	var visit func(name string)
+	visit = func(name string) {
+		if slices.Contains(order, name) {
+			return
+		}
+		if i := slices.IndexFunc(features, func(f Feature) bool { return f.Name == name }); i >= 0 {
+			for _, required := range features[i].Requires {
+				visit(required)
+			}
+		}
+		order = append(order, name)
+	}
+	visit(name)
+	return order
+}
+
+// sampleFile picks the file that best shows what a feature generates: the
+// first Go file that is not a test, else the first rendered file
+func sampleFile(files []GeneratedFile) string {
+	for _, f := range files {
+		if path.Ext(f.Path) == ".go" && !strings.HasSuffix(f.Path, "_test.go") {
+			return f.Path
+		}
+	}
+	for _, f := range files {
+		if !f.Asset {
+			return f.Path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,137 @@
+package generator
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+//go:embed readme/*.md.tmpl
+var readmeFS embed.FS
+
+// readmeSection is a part of the generated README, a template in readme/
+type readmeSection struct {
+	name string
+	// when decides whether the project gets the section; nil means every project
+	when func(data *TemplateData) bool
+}
+
+// readmeSections lists the README sections in the order they appear. Feature
+// sections sit next to the core section they extend.
+var readmeSections = []readmeSection{
+	{name: "intro"},
+	{name: "quick-start"},
+	{name: "development"},
+	{name: "make-targets", when: func(data *TemplateData) bool { return len(data.MakeTargets) > 0 }},
+	{name: "editor", when: withFeature("editor")},
+	{name: "api"},
+	{name: "openapi", when: withFeature("openapi")},
+	{name: "mockserver", when: withFeature("mockserver")},
+	{name: "docs-site", when: withFeature("docs-site")},
+	{name: "example-requests"},
+	{name: "grpc", when: withFeature("grpc")},
+	{name: "graphql", when: withFeature("graphql")},
+	{name: "graphql-federation", when: withFeature("graphql-federation")},
+	{name: "dataloader", when: withFeature("dataloader")},
+	{name: "webhook-receiver", when: func(data *TemplateData) bool { return data.Archetype == "webhook-receiver" }},
+	{name: "gateway", when: func(data *TemplateData) bool { return data.Archetype == "gateway" }},
+	{name: "pipeline", when: func(data *TemplateData) bool { return data.Archetype == "pipeline" }},
+	{name: "events", when: withFeature("events")},
+	{name: "read-cache", when: withFeature("read-cache")},
+	{name: "redis-cache", when: withFeature("redis-cache")},
+	{name: "kafka", when: withFeature("kafka")},
+	{name: "schema-registry", when: withFeature("schema-registry")},
+	{name: "nats", when: withFeature("nats")},
+	{name: "reports", when: withFeature("reports")},
+	{name: "ai-search", when: withFeature("ai-search")},
+	{name: "llm", when: withFeature("llm")},
+	{name: "contract-tests", when: withFeature("contract-tests")},
+	{name: "fault-injection", when: withFeature("fault-injection")},
+	{name: "http-client", when: withFeature("http-client")},
+	{name: "metrics", when: withFeature("metrics")},
+	{name: "observability-logs", when: withFeature("observability-logs")},
+	{name: "sentry", when: withFeature("sentry")},
+	{name: "service-auth", when: withFeature("service-auth")},
+	{name: "auth-oidc", when: withFeature("auth-oidc")},
+	{name: "rbac", when: withFeature("rbac")},
+	{name: "tracing"},
+	{name: "bounded-contexts", when: func(data *TemplateData) bool { return len(data.Namespaces) > 1 }},
+	{name: "adding-a-migration"},
+	{name: "migrations", when: func(data *TemplateData) bool { return data.Database == "postgres" }},
+	{name: "configuration"},
+	{name: "testing"},
+	{name: "ci", when: func(data *TemplateData) bool { return data.CI != "" }},
+	{name: "dep-updates", when: withFeature("dep-updates")},
+	{name: "repo-hygiene", when: withFeature("repo-hygiene")},
+	{name: "deployment"},
+	{name: "cli-docs"},
+	{name: "system-service", when: withFeature("system-service")},
+	{name: "edge", when: func(data *TemplateData) bool { return data.DeployTarget == "wasm-edge" }},
+}
+
+// withFeature returns a section condition that holds when feature is enabled
+func withFeature(feature string) func(data *TemplateData) bool {
+	return func(data *TemplateData) bool {
+		return data.HasFeature(feature)
+	}
+}
+
+// renderReadme assembles the project README from the sections that apply to it
+func renderReadme(data *TemplateData) ([]byte, error) {
+	var readme bytes.Buffer
+	for _, section := range readmeSections {
+		if section.when != nil && !section.when(data) {
+			continue
+		}
+
+		path := "readme/" + section.name + ".md.tmpl"
+		content, err := readmeFS.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read README section %s: %w", section.name, err)
+		}
+
+		tmpl, err := template.New(path).Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("%w: README section %s: %w", ErrTemplateParse, section.name, err)
+		}
+
+		var rendered bytes.Buffer
+		if err := tmpl.Execute(&rendered, data); err != nil {
+			return nil, fmt.Errorf("%w README section %s: %w", ErrTemplateExecute, section.name, err)
+		}
+
+		// Sections are separated by exactly one blank line, whatever their templates trim
+		if body := bytes.TrimSpace(rendered.Bytes()); len(body) > 0 {
+			if readme.Len() > 0 {
+				readme.WriteString("\n\n")
+			}
+			readme.Write(body)
+		}
+	}
+
+	readme.WriteString("\n")
+	return readme.Bytes(), nil
+}
+
+// writeReadme renders README.md into the project
+func (g *Generator) writeReadme(data *TemplateData, projectDir string) error {
+	readme, err := renderReadme(data)
+	if err != nil {
+		return err
+	}
+	if g.header != nil {
+		if readme, err = g.header.apply("README.md", builtinLayer, readme); err != nil {
+			return err
+		}
+	}
+
+	readmePath := filepath.Join(projectDir, "README.md")
+	if err := os.WriteFile(readmePath, readme, 0644); err != nil {
+		return fmt.Errorf("failed to write README.md: %w", err)
+	}
+	g.record(projectDir, readmePath, readme)
+	return nil
+}
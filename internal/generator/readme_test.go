@@ -0,0 +1,179 @@
+package generator
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// readmeProjects returns projects that between them render every README
+// section and the variants of its paragraphs
+func readmeProjects(t *testing.T) map[string]*ProjectConfig {
+	t.Helper()
+	fields := func(definitions string) []FieldSpec {
+		specs, err := ParseFields(definitions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return specs
+	}
+	project := func(features ...string) *ProjectConfig {
+		return &ProjectConfig{
+			AppName:     "shop",
+			ModuleName:  "example.com/shop",
+			Domain:      "product",
+			Description: "A product management API",
+			Author:      "Developer",
+			Features:    features,
+		}
+	}
+
+	everything := project(slices.DeleteFunc(FeatureNames(), func(f string) bool { return f == "nats" })...)
+	everything.Domains = []string{"billing.invoice", "billing.customer"}
+	everything.Fields = map[string][]FieldSpec{"billing.invoice": fields("number:string,customer_id:uuid")}
+	everything.Author = "@shop-team"
+	everything.CI = "github"
+	everything.Logger = "zap"
+	everything.DeployTarget = "wasm-edge"
+	everything.MakeTargets = []MakeTarget{{Name: "seed-demo", Description: "Load the demo data"}}
+
+	contexts := project("events", "nats", "read-cache", "metrics", "http-client", "contract-tests", "editor", "dep-updates", "repo-hygiene", "auth-oidc", "rbac", "system-service")
+	contexts.Domains = []string{"billing.invoice"}
+	contexts.Database, contexts.Router, contexts.Logger, contexts.CI = "mysql", "echo", "zerolog", "gitlab"
+
+	single := project("editor", "dep-updates", "repo-hygiene", "rbac", "service-auth", "dataloader", "docs-site")
+	single.Database, single.CI = "sqlite", "circleci"
+
+	webhook := project("service-auth")
+	webhook.Archetype = "webhook-receiver"
+	gateway := project("service-auth")
+	gateway.Archetype = "gateway"
+	pipeline := project("metrics")
+	pipeline.Archetype = "pipeline"
+
+	return map[string]*ProjectConfig{
+		"minimal":          project(),
+		"every feature":    everything,
+		"bounded contexts": contexts,
+		"single domain":    single,
+		"webhook receiver": webhook,
+		"gateway":          gateway,
+		"pipeline":         pipeline,
+	}
+}
+
+// TestReadmeIsTranslated renders the README of every project in English and
+// in each built-in language, which must share none of the English prose
+func TestReadmeIsTranslated(t *testing.T) {
+	for _, lang := range Languages() {
+		if lang == DefaultLanguage {
+			continue
+		}
+		messages, err := LoadMessages(lang, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for name, config := range readmeProjects(t) {
+			t.Run(lang+"/"+name, func(t *testing.T) {
+				english, err := renderReadme(newTemplateData(config))
+				if err != nil {
+					t.Fatal(err)
+				}
+				data := newTemplateData(config)
+				data.messages = messages
+				translated, err := renderReadme(data)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, line := range prose(string(english)) {
+					if strings.Contains(string(translated), line) && !userText(config, line) {
+						t.Errorf("%s README has the English %q", lang, line)
+					}
+				}
+			})
+		}
+	}
+}
+
+// prose returns the lines of a README, or the comments of its code blocks,
+// that hold a few lowercase words besides their code spans, leaving out lines
+// of names only
+func prose(readme string) []string {
+	var lines []string
+	fenced := false
+	for _, line := range strings.Split(readme, "\n") {
+		if strings.HasPrefix(line, "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			// only the comments of a code block are prose
+			line = comment(line)
+		}
+
+		table := strings.HasPrefix(line, "|")
+		words := 0
+		for _, cell := range strings.Split(stripCode(line), "|") {
+			// a table cell of one word is a label or a name, such as an environment
+			if n := lowercaseWords(cell); n > 1 || !table {
+				words += n
+			}
+		}
+		if words >= 3 {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
+
+// comment returns the shell or Go comment of a line of code
+func comment(line string) string {
+	for _, marker := range []string{"# ", "// "} {
+		if strings.HasPrefix(strings.TrimSpace(line), marker) {
+			return strings.TrimSpace(line)
+		}
+		if i := strings.Index(line, " "+marker); i >= 0 {
+			return line[i+1:]
+		}
+	}
+	return ""
+}
+
+// lowercaseWords counts the words of text written in lowercase letters only
+func lowercaseWords(text string) int {
+	n := 0
+	for _, field := range strings.Fields(text) {
+		word := strings.Trim(field, ".,;:!?()*\"'-")
+		if word != "" && strings.IndexFunc(word, func(r rune) bool { return r < 'a' || r > 'z' }) < 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// userText reports whether a README line holds text of the project spec,
+// which is rendered as given in every language
+func userText(config *ProjectConfig, line string) bool {
+	if strings.Contains(line, config.Description) {
+		return true
+	}
+	for _, target := range config.MakeTargets {
+		if strings.Contains(line, target.Description) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripCode removes the code spans of a Markdown line
+func stripCode(line string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(line, "`") {
+		if i%2 == 0 {
+			b.WriteString(part)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidTemplateSource is returned for a remote template source that is
+// not a git URL or names a ref the repository does not have
+var ErrInvalidTemplateSource = errors.New("invalid template source")
+
+// remotePrefixes are the URL forms git clones that a template source may use
+var remotePrefixes = []string{"git@", "ssh://", "https://", "http://", "git://", "file://"}
+
+// RemoteTemplates is a template repository checked out in the template cache
+type RemoteTemplates struct {
+	URL    string
+	Ref    string // branch, tag or commit; empty for the default branch
+	Commit string // the commit checked out
+	Dir    string // the checkout, a template tree or a template pack
+	// Stale is set when the repository could not be fetched and the ref was
+	// resolved in the cached clone instead
+	Stale bool
+}
+
+// Source returns the URL pinned to the checked out commit
+func (r *RemoteTemplates) Source() string {
+	return r.URL + "@" + r.Commit
+}
+
+// IsRemoteTemplate reports whether a template source is a git repository URL
+// rather than a local directory
+func IsRemoteTemplate(source string) bool {
+	for _, prefix := range remotePrefixes {
+		if strings.HasPrefix(source, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTemplateSource splits a template source of the form URL[@ref]. The ref
+// ends a URL at ".git@", or follows the last @ past the host and path.
+func ParseTemplateSource(source string) (url, ref string, err error) {
+	if !IsRemoteTemplate(source) {
+		return "", "", fmt.Errorf("%w: %q is not a git URL (%s...)", ErrInvalidTemplateSource, source, strings.Join(remotePrefixes, "..., "))
+	}
+	url = source
+	if i := strings.Index(source, ".git@"); i >= 0 {
+		url, ref = source[:i+len(".git")], source[i+len(".git@"):]
+	} else if i := strings.LastIndex(source, "@"); i > strings.LastIndexAny(source, "/:") {
+		url, ref = source[:i], source[i+1:]
+	}
+	if ref == "" && strings.HasSuffix(source, "@") {
+		return "", "", fmt.Errorf("%w: %q has an empty ref", ErrInvalidTemplateSource, source)
+	}
+	return url, ref, nil
+}
+
+// DefaultTemplateCacheDir returns the directory remote templates are cloned
+// to, in the user's cache directory
+func DefaultTemplateCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "go-app-gen", "templates"), nil
+}
+
+// FetchTemplates clones the template repository of source, URL[@ref], into
+// cacheDir, or fetches it when it was cloned before, and checks out the ref:
+// a branch, a tag or a commit, the default branch without one. A repository
+// that cannot be fetched falls back to the cached clone when it has the ref.
+func FetchTemplates(ctx context.Context, source, cacheDir string) (*RemoteTemplates, error) {
+	url, ref, err := ParseTemplateSource(source)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(url))
+	remote := &RemoteTemplates{URL: url, Ref: ref, Dir: filepath.Join(cacheDir, hex.EncodeToString(sum[:8]))}
+
+	if _, err := os.Stat(filepath.Join(remote.Dir, ".git")); err != nil {
+		// No clone yet, or one interrupted before git set it up
+		if err := os.RemoveAll(remote.Dir); err != nil {
+			return nil, fmt.Errorf("failed to clear the template cache: %w", err)
+		}
+		if err := os.MkdirAll(cacheDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create the template cache: %w", err)
+		}
+		if _, err := gitOutput(ctx, "", "clone", "--quiet", "--no-checkout", url, remote.Dir); err != nil {
+			os.RemoveAll(remote.Dir)
+			return nil, fmt.Errorf("failed to clone %s: %w", url, err)
+		}
+	} else if _, err := gitOutput(ctx, remote.Dir, "fetch", "--quiet", "--force", "--tags", "--prune", "origin"); err != nil {
+		remote.Stale = true
+	}
+
+	if remote.Commit, err = resolveRef(ctx, remote.Dir, ref); err != nil {
+		return nil, err
+	}
+	if _, err := gitOutput(ctx, remote.Dir, "checkout", "--quiet", "--force", "--detach", remote.Commit); err != nil {
+		return nil, fmt.Errorf("failed to check out %s: %w", remote.Commit, err)
+	}
+	if _, err := gitOutput(ctx, remote.Dir, "clean", "--quiet", "-ffdx"); err != nil {
+		return nil, fmt.Errorf("failed to clean the checkout of %s: %w", url, err)
+	}
+	return remote, nil
+}
+
+// resolveRef returns the commit of a branch, tag or commit of the clone in
+// dir, preferring the remote branch so a fetch moves it forward
+func resolveRef(ctx context.Context, dir, ref string) (string, error) {
+	candidates := []string{"origin/HEAD"}
+	if ref != "" {
+		candidates = []string{"origin/" + ref, "refs/tags/" + ref, ref}
+	}
+	for _, candidate := range candidates {
+		out, err := gitOutput(ctx, dir, "rev-parse", "--verify", "--quiet", candidate+"^{commit}")
+		if err == nil {
+			return strings.TrimSpace(string(out)), nil
+		}
+	}
+	if ref == "" {
+		return "", fmt.Errorf("%w: the repository has no default branch", ErrInvalidTemplateSource)
+	}
+	return "", fmt.Errorf("%w: no branch, tag or commit %s", ErrInvalidTemplateSource, ref)
+}
+
+// gitOutput runs git in dir, the current directory if empty, and returns its
+// standard output; standard error is part of the error
+func gitOutput(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
@@ -0,0 +1,256 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidGuardrail is returned for a guardrail with a malformed size
+var ErrInvalidGuardrail = errors.New("invalid guardrail")
+
+// GenerationReport lists the files a generation wrote into the project
+type GenerationReport struct {
+	Files []GeneratedFile
+	// Secrets lists the likely credentials the secrets scan found in the files
+	Secrets []SecretFinding
+}
+
+// GeneratedFile is a file of the generated project
+type GeneratedFile struct {
+	Path  string // relative to the project directory, slash-separated
+	Size  int64
+	Lines int // 0 for static assets, which are not read
+	// Asset is set for static assets of template packs, which are streamed to
+	// disk as they are instead of being rendered in memory
+	Asset bool
+}
+
+// Summary totals a generation's files, overall and by language
+type Summary struct {
+	Files     int
+	Size      int64
+	Lines     int
+	Languages []LanguageSummary // most lines first
+}
+
+// LanguageSummary totals the files of one language
+type LanguageSummary struct {
+	Language string
+	Files    int
+	Lines    int
+}
+
+// Guardrails bound the output of a generation. Exceeding one does not fail
+// the generation but warns, since it usually means a template pack renders
+// far more than intended, e.g. a per-domain template that should be per project.
+type Guardrails struct {
+	MaxFiles int `yaml:"max_files"` // most files a generation should write
+	// MaxTotalSize and MaxFileSize are sizes like "20MiB"; plain numbers are bytes
+	MaxTotalSize string `yaml:"max_total_size"`
+	MaxFileSize  string `yaml:"max_file_size"`
+	// Secrets is what credentials found in the output do: SecretsWarn (the
+	// default), SecretsFail or SecretsOff
+	Secrets string `yaml:"secrets"`
+}
+
+// DefaultGuardrails are generous for the built-in templates: every feature
+// with a dozen domains stays well below them
+var DefaultGuardrails = Guardrails{MaxFiles: 2000, MaxTotalSize: "50MiB", MaxFileSize: "5MiB"}
+
+// languages maps file extensions, and names for files without one, to languages
+var languages = map[string]string{
+	".go":        "Go",
+	".sql":       "SQL",
+	".proto":     "Protobuf",
+	".yaml":      "YAML",
+	".yml":       "YAML",
+	".json":      "JSON",
+	".toml":      "TOML",
+	".md":        "Markdown",
+	".sh":        "Shell",
+	".hurl":      "Hurl",
+	".html":      "HTML",
+	".css":       "CSS",
+	".js":        "JavaScript",
+	".ts":        "TypeScript",
+	"Makefile":   "Makefile",
+	"Dockerfile": "Dockerfile",
+}
+
+// Report returns the files written by the last generation
+func (g *Generator) Report() GenerationReport {
+	return g.report
+}
+
+// record adds a file written to outputPath to the report
+func (g *Generator) record(projectDir, outputPath string, content []byte) {
+	g.report.Files = append(g.report.Files, GeneratedFile{
+		Path:  reportPath(projectDir, outputPath),
+		Size:  int64(len(content)),
+		Lines: bytes.Count(content, []byte("\n")),
+	})
+}
+
+// recordAsset adds a static asset of size bytes written to outputPath to the report
+func (g *Generator) recordAsset(projectDir, outputPath string, size int64) {
+	g.report.Files = append(g.report.Files, GeneratedFile{Path: reportPath(projectDir, outputPath), Size: size, Asset: true})
+}
+
+// reportPath returns outputPath relative to the project, slash-separated
+func reportPath(projectDir, outputPath string) string {
+	rel, err := filepath.Rel(projectDir, outputPath)
+	if err != nil {
+		rel = outputPath
+	}
+	return filepath.ToSlash(rel)
+}
+
+// language returns the language of a generated file; static assets count
+// apart since their lines are not counted
+func (f GeneratedFile) language() string {
+	if f.Asset {
+		return "Static assets"
+	}
+	name := path.Base(f.Path)
+	if lang, ok := languages[path.Ext(name)]; ok {
+		return lang
+	}
+	if lang, ok := languages[name]; ok {
+		return lang
+	}
+	return "Other"
+}
+
+// Summary totals the report
+func (r GenerationReport) Summary() Summary {
+	var summary Summary
+	byLanguage := make(map[string]*LanguageSummary)
+	for _, f := range r.Files {
+		summary.Files++
+		summary.Size += f.Size
+		summary.Lines += f.Lines
+
+		lang := f.language()
+		if byLanguage[lang] == nil {
+			byLanguage[lang] = &LanguageSummary{Language: lang}
+		}
+		byLanguage[lang].Files++
+		byLanguage[lang].Lines += f.Lines
+	}
+
+	for _, lang := range byLanguage {
+		summary.Languages = append(summary.Languages, *lang)
+	}
+	sort.Slice(summary.Languages, func(i, j int) bool {
+		a, b := summary.Languages[i], summary.Languages[j]
+		if a.Lines != b.Lines {
+			return a.Lines > b.Lines
+		}
+		return a.Language < b.Language
+	})
+	return summary
+}
+
+// Validate checks the guardrail sizes and the secrets policy
+func (g Guardrails) Validate() error {
+	if g.MaxFiles < 0 {
+		return fmt.Errorf("%w: max_files %d is negative", ErrInvalidGuardrail, g.MaxFiles)
+	}
+	for _, size := range []string{g.MaxTotalSize, g.MaxFileSize} {
+		if _, err := parseSize(size); err != nil {
+			return err
+		}
+	}
+	return ValidateSecretsPolicy(g.Secrets)
+}
+
+// Check returns a warning for every guardrail the report exceeds; unset
+// guardrails fall back to DefaultGuardrails
+func (r GenerationReport) Check(g Guardrails) ([]string, error) {
+	if g.MaxFiles == 0 {
+		g.MaxFiles = DefaultGuardrails.MaxFiles
+	}
+	if g.MaxTotalSize == "" {
+		g.MaxTotalSize = DefaultGuardrails.MaxTotalSize
+	}
+	if g.MaxFileSize == "" {
+		g.MaxFileSize = DefaultGuardrails.MaxFileSize
+	}
+	maxTotal, err := parseSize(g.MaxTotalSize)
+	if err != nil {
+		return nil, err
+	}
+	maxFile, err := parseSize(g.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	summary := r.Summary()
+	if summary.Files > g.MaxFiles {
+		warnings = append(warnings, fmt.Sprintf("%d files written, more than max_files %d", summary.Files, g.MaxFiles))
+	}
+	if summary.Size > maxTotal {
+		warnings = append(warnings, fmt.Sprintf("%s written, more than max_total_size %s", FormatSize(summary.Size), g.MaxTotalSize))
+	}
+	for _, f := range r.Files {
+		if f.Size > maxFile {
+			warnings = append(warnings, fmt.Sprintf("%s is %s, more than max_file_size %s", f.Path, FormatSize(f.Size), g.MaxFileSize))
+		}
+	}
+	return warnings, nil
+}
+
+// sizeUnits are the suffixes parseSize accepts, longest first
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"GB", 1e9},
+	{"MB", 1e6},
+	{"KB", 1e3},
+	{"B", 1},
+}
+
+// parseSize parses a size like "20MiB", "500KB" or "1024"; empty is 0
+func parseSize(size string) (int64, error) {
+	s := strings.TrimSpace(size)
+	if s == "" {
+		return 0, nil
+	}
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, multiplier = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%w: size %q (want e.g. 20MiB, 500KB or a number of bytes)", ErrInvalidGuardrail, size)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// FormatSize formats a size in bytes with a binary unit, e.g. "1.5 MiB"
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
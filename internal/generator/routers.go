@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownRouter is returned when a requested HTTP router is not supported
+var ErrUnknownRouter = errors.New("unknown router")
+
+// DefaultRouter is the HTTP router of projects that do not choose one
+const DefaultRouter = "chi"
+
+// Router is an HTTP router the API layer registers its routes and handlers
+// with. Every router runs the same net/http middleware.
+type Router struct {
+	Name        string
+	Title       string
+	Description string
+
+	// Templates lists template paths, relative to templates/, that are only
+	// rendered for the routers that list them. Entries ending in "/" match a
+	// directory.
+	Templates []string
+}
+
+// Routers lists every HTTP router the generator supports
+var Routers = []Router{
+	{
+		Name:        "chi",
+		Title:       "chi",
+		Description: "go-chi/chi, net/http handlers with grouped routes and middleware",
+	},
+	{
+		Name:        "stdlib",
+		Title:       "net/http",
+		Description: "The standard library's http.ServeMux with method and wildcard patterns, no router dependency",
+		Templates: []string{
+			"internal/utils/middleware.go.tmpl",
+			"internal/utils/mux.go.tmpl",
+		},
+	},
+	{
+		Name:        "echo",
+		Title:       "Echo",
+		Description: "labstack/echo, handlers taking an echo.Context and returning errors",
+		Templates: []string{
+			"internal/utils/middleware.go.tmpl",
+		},
+	},
+	{
+		Name:        "gin",
+		Title:       "Gin",
+		Description: "gin-gonic/gin, handlers taking a *gin.Context",
+		Templates: []string{
+			"internal/utils/middleware.go.tmpl",
+		},
+	},
+}
+
+// RouterNames returns the names of all supported HTTP routers
+func RouterNames() []string {
+	names := make([]string, len(Routers))
+	for i, r := range Routers {
+		names[i] = r.Name
+	}
+	return names
+}
+
+// ValidateRouter checks that name is empty, for DefaultRouter, or a supported
+// HTTP router
+func ValidateRouter(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := lookupRouter(name); ok {
+		return nil
+	}
+	return fmt.Errorf("%w: %q (available: %s)", ErrUnknownRouter, name, strings.Join(RouterNames(), ", "))
+}
+
+// lookupRouter returns the HTTP router of a name, DefaultRouter if empty
+func lookupRouter(name string) (Router, bool) {
+	if name == "" {
+		name = DefaultRouter
+	}
+	for _, r := range Routers {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Router{}, false
+}
+
+// templateRouters returns the HTTP routers that own a template; a template
+// that none own is rendered for every router
+func templateRouters(templatePath string) []string {
+	path := strings.TrimPrefix(templatePath, "templates/")
+
+	var owners []string
+	for _, r := range Routers {
+		if ownsTemplate(r.Templates, path) {
+			owners = append(owners, r.Name)
+		}
+	}
+	return owners
+}
@@ -0,0 +1,261 @@
+package generator
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+)
+
+// TemplateDataSchema is the version of the data and functions templates are
+// rendered with. It is bumped whenever a TemplateData field is removed, renamed
+// or changes meaning, not when one is added; packs declare the version they
+// target in their manifest.
+const TemplateDataSchema = 1
+
+// minTemplateDataSchema is the oldest schema whose packs still render correctly
+const minTemplateDataSchema = 1
+
+// schemaSources are the files declaring the types of TemplateData and Spec.
+// Their doc comments are the field descriptions of the JSON Schemas, so the
+// structs stay the only definition of the fields.
+//
+//go:embed generator.go domains.go fields.go makefile.go spec.go header.go
+var schemaSources embed.FS
+
+// jsonSchema is the subset of JSON Schema (draft 2020-12) the schemas use
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"` // false, or the schema of map values
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+
+	// Version is the TemplateDataSchema the schema describes
+	Version int `json:"x-template-data-schema,omitempty"`
+	// GoType is set for template functions, which have no JSON type
+	GoType string `json:"x-go-type,omitempty"`
+}
+
+// schemaBuilder turns Go structs into JSON Schema definitions
+type schemaBuilder struct {
+	docs map[string]string // doc comments by type name and by "Type.Field"
+	defs map[string]*jsonSchema
+	// name returns the property name of a field, false to leave it out
+	name func(reflect.StructField) (string, bool)
+	// enums restricts fields, by "Type.Field", to a set of values
+	enums  map[string][]string
+	closed bool // reject properties that are not declared, like LoadSpec does
+}
+
+// TemplateDataJSONSchema returns the JSON Schema of the data templates are
+// rendered with. Property names are the Go field names templates use
+// ({{.DomainTitle}}); every domain-typed field is described once under $defs.
+func TemplateDataJSONSchema() ([]byte, error) {
+	b, err := newSchemaBuilder(func(f reflect.StructField) (string, bool) { return f.Name, true })
+	if err != nil {
+		return nil, err
+	}
+	b.enums["TemplateData.DeployTarget"] = append([]string{""}, DeployTargetNames()...)
+	b.enums["TemplateData.Database"] = DatabaseNames()
+	b.enums["TemplateData.Router"] = RouterNames()
+	b.enums["TemplateData.Logger"] = LoggerNames()
+	b.enums["TemplateData.CI"] = append([]string{""}, CIProviderNames()...)
+	b.enums["TemplateData.Archetype"] = ArchetypeNames()
+	b.enums["TemplateData.Broker"] = append([]string{""}, brokerFeatures...)
+	b.enums["MakeTarget.Mode"] = []string{MakeTargetAdd, MakeTargetReplace, MakeTargetAppend}
+
+	root := b.object(reflect.TypeOf(TemplateData{}))
+	root.Description += fmt.Sprintf("\n\nFeatures HasFeature reports on: %s, and those of the layered template packs.", strings.Join(FeatureNames(), ", "))
+	root.Version = TemplateDataSchema
+	return b.document(root, fmt.Sprintf("template-data/v%d.json", TemplateDataSchema), fmt.Sprintf("go-app-gen TemplateData, schema %d", TemplateDataSchema))
+}
+
+// SpecJSONSchema returns the JSON Schema of project spec files, for editors
+// and linters of spec.yaml; property names are the YAML keys
+func SpecJSONSchema() ([]byte, error) {
+	b, err := newSchemaBuilder(func(f reflect.StructField) (string, bool) {
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		return name, name != "" && name != "-"
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.closed = true
+	b.enums["Spec.DeployTarget"] = append([]string{""}, DeployTargetNames()...)
+	b.enums["Spec.Database"] = append([]string{""}, DatabaseNames()...)
+	b.enums["Spec.Router"] = append([]string{""}, RouterNames()...)
+	b.enums["Spec.Logger"] = append([]string{""}, LoggerNames()...)
+	b.enums["Spec.CI"] = append([]string{""}, CIProviderNames()...)
+	b.enums["Spec.Archetype"] = append([]string{""}, ArchetypeNames()...)
+	b.enums["MakeTarget.Mode"] = []string{MakeTargetAdd, MakeTargetReplace, MakeTargetAppend}
+	b.enums["Guardrails.Secrets"] = []string{"", SecretsWarn, SecretsFail, SecretsOff}
+	b.enums["HeaderSpec.Languages"] = HeaderLanguages()
+
+	root := b.object(reflect.TypeOf(Spec{}))
+	return b.document(root, "spec.json", "go-app-gen project spec")
+}
+
+// newSchemaBuilder collects the doc comments of the schema sources
+func newSchemaBuilder(name func(reflect.StructField) (string, bool)) (*schemaBuilder, error) {
+	docs, err := schemaDocs()
+	if err != nil {
+		return nil, err
+	}
+	return &schemaBuilder{docs: docs, defs: make(map[string]*jsonSchema), name: name, enums: make(map[string][]string)}, nil
+}
+
+// document wraps root into a schema document with the definitions it refers to
+func (b *schemaBuilder) document(root *jsonSchema, id, title string) ([]byte, error) {
+	root.Schema = "https://json-schema.org/draft/2020-12/schema"
+	root.ID = "https://github.com/nhalm/go-app-gen/schema/" + id
+	root.Title = title
+	if len(b.defs) > 0 {
+		root.Defs = b.defs
+	}
+
+	content, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return append(content, '\n'), nil
+}
+
+// object describes a struct, with the fields of embedded structs promoted the
+// way templates see them
+func (b *schemaBuilder) object(t reflect.Type) *jsonSchema {
+	s := &jsonSchema{Type: "object", Description: b.docs[t.Name()], Properties: make(map[string]*jsonSchema)}
+	if b.closed {
+		s.AdditionalProperties = false
+	}
+	b.fields(t, s)
+	return s
+}
+
+// fields adds the exported fields of t, and of the structs it embeds, to s
+func (b *schemaBuilder) fields(t reflect.Type, s *jsonSchema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			b.fields(f.Type, s)
+			continue
+		}
+		name, ok := b.name(f)
+		if !ok {
+			continue
+		}
+		key := t.Name() + "." + f.Name
+		prop := b.value(f.Type)
+		prop.Description = b.docs[key]
+		if enum, ok := b.enums[key]; ok && prop.Items != nil {
+			prop.Items.Enum = enum
+		} else if ok {
+			prop.Enum = enum
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// value describes a field type, referring to named structs through $defs
+func (b *schemaBuilder) value(t reflect.Type) *jsonSchema {
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Slice:
+		return &jsonSchema{Type: "array", Items: b.value(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: b.value(t.Elem())}
+	case reflect.Func:
+		return &jsonSchema{GoType: t.String()}
+	case reflect.Struct:
+		if _, ok := b.defs[t.Name()]; !ok {
+			b.defs[t.Name()] = nil // guards recursive types while the definition is built
+			b.defs[t.Name()] = b.object(t)
+		}
+		return &jsonSchema{Ref: "#/$defs/" + t.Name()}
+	}
+	panic(fmt.Sprintf("schema: unsupported field type %s", t))
+}
+
+// schemaDocs reads the doc comments of the types in the schema sources and of
+// their fields, keyed by type name and by "Type.Field"
+func schemaDocs() (map[string]string, error) {
+	entries, err := schemaSources.ReadDir(".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema sources: %w", err)
+	}
+
+	docs := make(map[string]string)
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		src, err := schemaSources.ReadFile(entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema source %s: %w", entry.Name(), err)
+		}
+		file, err := parser.ParseFile(fset, entry.Name(), src, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema source %s: %w", entry.Name(), err)
+		}
+
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				st, ok := typeSpec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				typeDoc := typeSpec.Doc
+				if typeDoc == nil {
+					typeDoc = gen.Doc
+				}
+				docs[typeSpec.Name.Name] = commentText(typeDoc)
+				for _, field := range st.Fields.List {
+					doc := field.Doc
+					if doc == nil {
+						doc = field.Comment
+					}
+					for _, name := range field.Names {
+						docs[typeSpec.Name.Name+"."+name.Name] = commentText(doc)
+					}
+				}
+			}
+		}
+	}
+	return docs, nil
+}
+
+// commentText returns a doc comment as plain text, without gofmt's example blocks
+func commentText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	var paragraphs []string
+	for _, p := range strings.Split(group.Text(), "\n\n") {
+		if strings.HasPrefix(p, "\t") || strings.HasPrefix(strings.TrimSpace(p), "Example") {
+			continue
+		}
+		paragraphs = append(paragraphs, strings.Join(strings.Fields(p), " "))
+	}
+	return strings.TrimSpace(strings.Join(paragraphs, "\n\n"))
+}
@@ -0,0 +1,169 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrSecretsFound is returned when the secrets scan finds credentials in the
+// output and the policy is SecretsFail
+var ErrSecretsFound = errors.New("secrets found in the generated files")
+
+// Policies for credentials found in the output, see Guardrails.Secrets
+const (
+	SecretsWarn = "warn" // the default: list the findings and go on
+	SecretsFail = "fail" // stop before post-processing
+	SecretsOff  = "off"  // do not scan
+)
+
+// secretAllow marks a line the scan skips, as it does for gitleaks
+const secretAllow = "gitleaks:allow"
+
+// secretRule is a gitleaks-style rule: a regular expression whose secret
+// group, or whole match, must reach minEntropy bits per character
+type secretRule struct {
+	id         string
+	re         *regexp.Regexp
+	secret     int // the submatch holding the secret, 0 for the whole match
+	minEntropy float64
+	// digits requires a digit in the secret, which tells tokens from the
+	// identifiers of code such as "token: auth.NewTokenVerifier"
+	digits bool
+}
+
+// secretRules detect the credentials templates and spec answers most often
+// leak: provider tokens, private keys and passwords in URLs or assignments
+var secretRules = []secretRule{
+	{id: "aws-access-key-id", re: regexp.MustCompile(`\b(?:A3T[A-Z0-9]|AKIA|ASIA|ABIA|ACCA)[A-Z2-7]{16}\b`)},
+	{id: "github-token", re: regexp.MustCompile(`\b(?:gh[pousr]_[0-9A-Za-z]{36}|github_pat_[0-9A-Za-z_]{82})\b`)},
+	{id: "gitlab-token", re: regexp.MustCompile(`\bglpat-[0-9A-Za-z_-]{20}\b`)},
+	{id: "slack-token", re: regexp.MustCompile(`\bxox[baprs]-[0-9A-Za-z-]{10,}`)},
+	{id: "stripe-key", re: regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`)},
+	{id: "google-api-key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{id: "private-key", re: regexp.MustCompile(`-----BEGIN[ A-Z0-9_-]{0,100}PRIVATE KEY(?: BLOCK)?-----`)},
+	{id: "jwt", re: regexp.MustCompile(`\beyJ[0-9A-Za-z_-]{10,}\.eyJ[0-9A-Za-z_-]{10,}\.[0-9A-Za-z_-]{10,}`), minEntropy: 3},
+	{
+		id:         "url-credentials",
+		re:         regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://[^\s:/@]+:([^\s:/@${}]{6,})@`),
+		secret:     1,
+		minEntropy: 3,
+	},
+	{
+		id:         "generic-secret",
+		re:         regexp.MustCompile(`(?i)(?:password|passwd|secret|token|api_?key|access_?key|private_?key)[a-z0-9_-]*["']?\s*[:=]\s*["']?([0-9A-Za-z+/=_.~-]{12,})`),
+		secret:     1,
+		minEntropy: 3.5,
+		digits:     true,
+	},
+}
+
+// SecretFinding is a likely credential in a generated file
+type SecretFinding struct {
+	Rule string
+	Path string // relative to the project directory, slash-separated
+	Line int
+}
+
+func (f SecretFinding) String() string {
+	return fmt.Sprintf("%s:%d: %s", f.Path, f.Line, f.Rule)
+}
+
+// ValidateSecretsPolicy checks a secrets policy; empty is SecretsWarn
+func ValidateSecretsPolicy(policy string) error {
+	switch policy {
+	case "", SecretsWarn, SecretsFail, SecretsOff:
+		return nil
+	}
+	return fmt.Errorf("%w: secrets %q must be %s, %s or %s", ErrInvalidGuardrail, policy, SecretsWarn, SecretsFail, SecretsOff)
+}
+
+// ScanSecrets scans the files of the report for credentials that were
+// templated into the output, e.g. a token answered in a spec or a password
+// in a compose file. Binary files are skipped, and so are lines marked
+// gitleaks:allow.
+func ScanSecrets(projectDir string, report GenerationReport) ([]SecretFinding, error) {
+	var findings []SecretFinding
+	for _, f := range report.Files {
+		found, err := scanFile(filepath.Join(projectDir, filepath.FromSlash(f.Path)), f.Path)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, found...)
+	}
+	return findings, nil
+}
+
+// scanFile reports the lines of a file that match a secret rule, at most one
+// finding per line
+func scanFile(path, rel string) ([]SecretFinding, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", rel, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	if head, _ := reader.Peek(8000); bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+
+	var findings []SecretFinding
+	for number := 1; ; number++ {
+		line, err := reader.ReadString('\n')
+		if rule := matchSecret(line); rule != "" {
+			findings = append(findings, SecretFinding{Rule: rule, Path: rel, Line: number})
+		}
+		if errors.Is(err, io.EOF) {
+			return findings, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", rel, err)
+		}
+	}
+}
+
+// matchSecret returns the first rule the line matches, or ""
+func matchSecret(line string) string {
+	if line == "" || strings.Contains(line, secretAllow) {
+		return ""
+	}
+	for _, rule := range secretRules {
+		for _, match := range rule.re.FindAllStringSubmatch(line, -1) {
+			secret := match[rule.secret]
+			if rule.digits && !strings.ContainsAny(secret, "0123456789") {
+				continue
+			}
+			if shannonEntropy(secret) >= rule.minEntropy {
+				return rule.id
+			}
+		}
+	}
+	return ""
+}
+
+// shannonEntropy returns the bits per character of s, which tells random
+// credentials from placeholders such as "postgres" or "changeme"
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var entropy float64
+	length := float64(len([]rune(s)))
+	for _, n := range counts {
+		p := float64(n) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
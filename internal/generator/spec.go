@@ -0,0 +1,113 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is a project spec file: the create flags plus customizations that are
+// applied again every time the project is regenerated
+//
+// Example spec file:
+//
+//	name: orders
+//	module: github.com/acme/orders
+//	domains: [order, billing.invoice]
+//	features: [metrics, openapi]
+//	makefile:
+//	  targets:
+//	    - name: lint
+//	      mode: append
+//	      commands:
+//	        - docker-compose run --rm dev go run github.com/acme/lint/cmd/acmelint ./...
+//	    - name: deploy
+//	      description: Deploy to the ACME cluster
+//	      deps: [build]
+//	      commands:
+//	        - acme-deploy --service orders
+type Spec struct {
+	Name        string `yaml:"name"`        // project name, like create's argument
+	Module      string `yaml:"module"`      // Go module name
+	Description string `yaml:"description"` // one-line project description
+	Author      string `yaml:"author"`      // name, GitHub @handle or email
+	// Domains are the domain entities, optionally namespaced into bounded
+	// contexts (billing.invoice); the first is the primary domain
+	Domains      []string `yaml:"domains"`
+	DomainPlural string   `yaml:"domain_plural"` // overrides the plural of the primary domain
+	DomainTitle  string   `yaml:"domain_title"`  // overrides the title-cased primary domain (SKU)
+	// Fields maps domains to the fields of their entity as name:type pairs,
+	// like --fields (name:string,price:decimal,released_at:timestamp?);
+	// domains without an entry get the default fields
+	Fields map[string]string `yaml:"fields"`
+	// Features are built-in or template pack features to include
+	Features     []string `yaml:"features"`
+	DeployTarget string   `yaml:"deploy_target"` // additional deploy target, experimental
+	Database     string   `yaml:"database"`      // postgres (the default), mysql or sqlite
+	Router       string   `yaml:"router"`        // chi (the default), stdlib, echo or gin
+	Logger       string   `yaml:"logger"`        // slog (the default), zerolog or zap
+	CI           string   `yaml:"ci"`            // github, gitlab or circleci; no pipeline if empty
+	Archetype    string   `yaml:"archetype"`     // api (the default), webhook-receiver, gateway or pipeline
+	// Template is a git repository of templates, URL[@ref], checked out and
+	// used below TemplateDirs
+	Template string `yaml:"template"`
+	// TemplateDirs are template packs layered over the built-in templates,
+	// lowest priority first and relative to the spec file
+	TemplateDirs []string `yaml:"template_dirs"`
+	// TemplateKeys are the public keys the packs must be signed with, relative
+	// to the spec file unless they are KMS references
+	TemplateKeys []string `yaml:"template_keys"`
+
+	Makefile MakefileSpec `yaml:"makefile"` // Makefile customizations
+	// Guardrails bound the size of the output, where exceeding them warns, and
+	// set what credentials found in it do
+	Guardrails Guardrails `yaml:"guardrails"`
+	// Header is a comment header, such as a copyright notice, injected at the
+	// top of generated source files
+	Header HeaderSpec `yaml:"header"`
+	// Lang is the language of the README and code comments, en if empty
+	Lang string `yaml:"lang"`
+	// Messages are message bundles with the team's own translations, layered
+	// over the built-in and template pack ones and relative to the spec file
+	Messages []string `yaml:"messages"`
+}
+
+// MakefileSpec customizes the generated Makefile
+type MakefileSpec struct {
+	Targets []MakeTarget `yaml:"targets"` // targets to add, replace or append to
+}
+
+// LoadSpec reads a YAML project spec file, rejecting unknown keys
+func LoadSpec(path string) (*Spec, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec file: %w", err)
+	}
+	return ParseSpec(content, path)
+}
+
+// ParseSpec parses a YAML or JSON project spec like LoadSpec, naming it
+// path in errors
+func ParseSpec(content []byte, path string) (*Spec, error) {
+	var spec Spec
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse spec file %s: %w", path, err)
+	}
+
+	if err := ValidateMakeTargets(spec.Makefile.Targets); err != nil {
+		return nil, fmt.Errorf("invalid spec file %s: %w", path, err)
+	}
+	if err := spec.Guardrails.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid spec file %s: %w", path, err)
+	}
+	if err := spec.Header.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid spec file %s: %w", path, err)
+	}
+	return &spec, nil
+}
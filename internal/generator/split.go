@@ -0,0 +1,315 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed split/*.tmpl
+var splitFS embed.FS
+
+var (
+	// ErrNotGeneratedProject is returned when split does not run in a generated project
+	ErrNotGeneratedProject = errors.New("not a generated project")
+
+	// ErrNotBoundedContext is returned when split is asked for something other than a bounded context
+	ErrNotBoundedContext = errors.New("not a bounded context")
+
+	// ErrServiceExists is returned when the directory of the extracted service already exists
+	ErrServiceExists = errors.New("service directory already exists")
+)
+
+// SplitConfig describes a bounded context to extract from a generated project
+type SplitConfig struct {
+	// ProjectDir is the generated project the context is extracted from
+	ProjectDir string
+	// Namespace is the bounded context to extract, e.g. billing
+	Namespace string
+	// AppName names the new service (default: the namespace)
+	AppName string
+	// ModuleName is the new service's module (default: the project module with its
+	// last element replaced by AppName)
+	ModuleName string
+	// Features of the new service (default: the features found in the project)
+	Features    []string
+	Description string
+	Author      string
+}
+
+// SplitResult reports what Split created
+type SplitResult struct {
+	ServiceDir string
+	ModuleName string
+	Domains    []string
+	Features   []string
+	// Copied lists the directories carried over from the project, relative to it
+	Copied []string
+	// ClientDir is the client SDK directory in the project, relative to it
+	ClientDir string
+	// ClientCreated is false when the project already had the client SDK
+	ClientCreated bool
+	// Contract is the messaging contract in the project, relative to it
+	Contract string
+}
+
+// Split extracts a bounded context of a generated project into a new service
+// next to it. The service is generated with the context's domains and then
+// receives the project's own code for them, so hand-written changes move
+// along. The project gets a client SDK for the service and both get the
+// messaging contract for the context's events. The project itself is not
+// changed otherwise; removing the context from it is left to the caller.
+func (g *Generator) Split(cfg SplitConfig) (*SplitResult, error) {
+	if err := ValidateNamespace(cfg.Namespace); err != nil {
+		return nil, err
+	}
+	projectModule, err := readModulePath(cfg.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	contextDir := path.Join("internal", cfg.Namespace)
+	if info, err := os.Stat(filepath.Join(cfg.ProjectDir, contextDir, "service")); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s has no service layer", ErrNotBoundedContext, contextDir)
+	}
+	domains, err := contextDomains(cfg.ProjectDir, cfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.AppName == "" {
+		cfg.AppName = cfg.Namespace
+	}
+	if cfg.ModuleName == "" {
+		cfg.ModuleName = path.Join(path.Dir(projectModule), cfg.AppName)
+	}
+	if cfg.Features == nil {
+		cfg.Features = detectFeatures(cfg.ProjectDir, cfg.Namespace)
+	}
+	if err := ValidateFeatures(cfg.Features); err != nil {
+		return nil, err
+	}
+	if cfg.Description == "" {
+		cfg.Description = fmt.Sprintf("The %s service, split from %s", cfg.Namespace, path.Base(projectModule))
+	}
+
+	serviceDir := filepath.Join(g.outputDir, cfg.AppName)
+	if _, err := os.Stat(serviceDir); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrServiceExists, serviceDir)
+	}
+
+	service := &ProjectConfig{
+		AppName:     cfg.AppName,
+		ModuleName:  cfg.ModuleName,
+		Domain:      domains[0],
+		Domains:     domains[1:],
+		Description: cfg.Description,
+		Author:      cfg.Author,
+		Features:    cfg.Features,
+	}
+	// The project's repository and handler code moves along, so the service
+	// keeps its database, router and logger, and gets the same CI pipeline
+	if manifest, err := LoadManifest(cfg.ProjectDir); err == nil {
+		service.Database, service.Router, service.Logger = manifest.Database, manifest.Router, manifest.Logger
+		service.CI = manifest.CI
+	}
+	data, serviceDir, err := g.render(service)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SplitResult{
+		ServiceDir: serviceDir,
+		ModuleName: cfg.ModuleName,
+		Domains:    domains,
+		Features:   cfg.Features,
+	}
+
+	// The project's code replaces the generated code for the context
+	for _, dir := range contextDirs(cfg.Namespace, data.Namespaces[0].NamespaceData) {
+		copied, err := copyContextDir(cfg.ProjectDir, serviceDir, dir, projectModule, cfg.ModuleName)
+		if err != nil {
+			return nil, err
+		}
+		if copied {
+			result.Copied = append(result.Copied, dir)
+		}
+	}
+
+	if err := g.writeContract(data, serviceDir, "api/asyncapi.yaml"); err != nil {
+		return nil, err
+	}
+	result.Contract = path.Join("contracts", cfg.Namespace, "asyncapi.yaml")
+	if err := g.writeContract(data, cfg.ProjectDir, result.Contract); err != nil {
+		return nil, err
+	}
+
+	result.ClientDir = path.Join("pkg", data.Namespaces[0].ClientPackage)
+	if _, err := os.Stat(filepath.Join(cfg.ProjectDir, result.ClientDir)); errors.Is(err, fs.ErrNotExist) {
+		if err := g.writeClient(data, cfg.ProjectDir); err != nil {
+			return nil, err
+		}
+		result.ClientCreated = true
+	}
+
+	if err := g.PostProcess(serviceDir, data); err != nil {
+		return nil, fmt.Errorf("post-processing failed: %w", err)
+	}
+	return result, nil
+}
+
+// readModulePath returns the module path declared in the go.mod of projectDir
+func readModulePath(projectDir string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(projectDir, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrNotGeneratedProject, err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	return "", fmt.Errorf("%w: go.mod has no module directive", ErrNotGeneratedProject)
+}
+
+// contextDomains returns the namespaced domains of a bounded context, found
+// from the query files of its repository layer
+func contextDomains(projectDir, namespace string) ([]string, error) {
+	queries := filepath.Join(projectDir, "internal", namespace, "repository", "queries")
+	matches, err := filepath.Glob(filepath.Join(queries, "*.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", queries, err)
+	}
+
+	var domains []string
+	for _, match := range matches {
+		domains = append(domains, namespace+"."+strings.TrimSuffix(filepath.Base(match), ".sql"))
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("%w: no domains found in %s", ErrNotBoundedContext, queries)
+	}
+	if err := ValidateDomains(domains, ""); err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
+
+// detectFeatures returns the features whose files are present in a generated project
+func detectFeatures(projectDir, namespace string) []string {
+	var features []string
+	for _, f := range Features {
+		for _, owned := range f.Templates {
+			owned = strings.ReplaceAll(owned, "{{.namespace}}", namespace)
+			owned = strings.TrimSuffix(strings.TrimSuffix(owned, "/"), ".tmpl")
+			if _, err := os.Stat(filepath.Join(projectDir, owned)); err == nil {
+				features = append(features, f.Name)
+				break
+			}
+		}
+	}
+	return features
+}
+
+// contextDirs lists the directories, relative to the project, that hold a bounded context's code
+func contextDirs(namespace string, ns NamespaceData) []string {
+	return []string{
+		ns.NamespaceDir,
+		path.Dir(ns.SchemaFile),
+		ns.MigrationsDir,
+		path.Join("api/requests", namespace),
+		path.Join("proto", namespace),
+		path.Join("pkg", ns.ClientPackage),
+	}
+}
+
+// copyContextDir replaces dir in the service with the project's copy, rewriting
+// imports of the project module to the service module. It reports false when
+// the project has no such directory.
+func copyContextDir(projectDir, serviceDir, dir, fromModule, toModule string) (bool, error) {
+	src := filepath.Join(projectDir, dir)
+	if _, err := os.Stat(src); errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	dst := filepath.Join(serviceDir, dir)
+	if err := os.RemoveAll(dst); err != nil {
+		return false, fmt.Errorf("failed to remove generated %s: %w", dir, err)
+	}
+
+	err := filepath.WalkDir(src, func(srcPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, srcPath)
+		if err != nil {
+			return err
+		}
+		dstPath := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(dstPath, 0755)
+		}
+
+		content, err := os.ReadFile(srcPath)
+		if err != nil {
+			return err
+		}
+		if ext := filepath.Ext(srcPath); ext == ".go" || ext == ".proto" {
+			content = bytes.ReplaceAll(content, []byte(`"`+fromModule+`/`), []byte(`"`+toModule+`/`))
+			content = bytes.ReplaceAll(content, []byte(`"`+fromModule+`"`), []byte(`"`+toModule+`"`))
+		}
+		return os.WriteFile(dstPath, content, 0644)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to copy %s: %w", dir, err)
+	}
+	return true, nil
+}
+
+// writeClient renders the client SDK of the service's context into the
+// project, without the Pact tests that need the contract-tests feature
+func (g *Generator) writeClient(service *TemplateData, projectDir string) error {
+	builtin, err := g.layers.baseTemplates()
+	if err != nil {
+		return err
+	}
+	return g.processTemplateDir(builtin, "templates/pkg/{{.namespace}}client", service, projectDir, func(path string) bool {
+		return !strings.HasSuffix(path, "_test.go.tmpl")
+	})
+}
+
+// writeContract renders the messaging contract of the service's context to name
+func (g *Generator) writeContract(service *TemplateData, projectDir, name string) error {
+	content, err := splitFS.ReadFile("split/asyncapi.yaml.tmpl")
+	if err != nil {
+		return fmt.Errorf("failed to read the contract template: %w", err)
+	}
+
+	tmpl, err := template.New("asyncapi.yaml").Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse the contract template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, service); err != nil {
+		return fmt.Errorf("failed to execute the contract template: %w", err)
+	}
+
+	outputPath := filepath.Join(projectDir, name)
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", outputPath, err)
+	}
+	if err := os.WriteFile(outputPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", outputPath, err)
+	}
+	return nil
+}
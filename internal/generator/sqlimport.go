@@ -0,0 +1,644 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidSQLSchema is returned when an SQL schema cannot be imported
+var ErrInvalidSQLSchema = errors.New("invalid SQL schema")
+
+// ignoredTables are the bookkeeping tables of migration tools
+var ignoredTables = []string{
+	"schema_migrations", "goose_db_version", "atlas_schema_revisions", "gorp_migrations",
+	"flyway_schema_history", "databasechangelog", "databasechangeloglock",
+}
+
+// sqlName matches a name, quoted as PostgreSQL or as MySQL quotes it
+const sqlName = `(?:"[^"]+"|` + "`[^`]+`" + `|[A-Za-z_][A-Za-z0-9_$]*)`
+
+// sqlIdentifier matches a possibly quoted and schema-qualified name
+const sqlIdentifier = `(` + sqlName + `(?:\s*\.\s*` + sqlName + `)?)`
+
+// createTablePrefix matches CREATE TABLE up to the table name, the first
+// group set for temporary and unlogged tables
+const createTablePrefix = `(?is)^CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:(TEMP|TEMPORARY|UNLOGGED)\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + sqlIdentifier
+
+var (
+	createTablePattern = regexp.MustCompile(createTablePrefix + `\s*\(`)
+	// copyTablePattern matches the CREATE TABLE statements that take their
+	// columns from elsewhere: a query, another table or a composite type
+	copyTablePattern = regexp.MustCompile(createTablePrefix + `\s+(AS|LIKE|OF|PARTITION\s+OF)\s`)
+	// copyTailPattern matches the INHERITS or AS SELECT after the columns of
+	// a CREATE TABLE that takes more columns from elsewhere
+	copyTailPattern    = regexp.MustCompile(`(?is)^\s*(INHERITS|AS)\b`)
+	alterTablePattern  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?` + sqlIdentifier + `\s+(.*)$`)
+	dropTablePattern   = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?(.*?)(?:\s+(?:CASCADE|RESTRICT))?$`)
+	renameTablePattern = regexp.MustCompile(`(?is)^RENAME\s+TABLE\s+(.*)$`)
+	renamePairPattern  = regexp.MustCompile(`(?is)^` + sqlIdentifier + `\s+TO\s+` + sqlIdentifier + `$`)
+	qualifiedPattern   = regexp.MustCompile(`^(` + sqlName + `)\s*\.\s*(` + sqlName + `)$`)
+	primaryKeyPattern  = regexp.MustCompile(`(?is)PRIMARY\s+KEY\s*\(([^)]*)\)`)
+
+	// The actions of ALTER TABLE that change the columns
+	addColumnPattern    = regexp.MustCompile(`(?is)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(.*)$`)
+	dropColumnPattern   = regexp.MustCompile(`(?is)^DROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?(` + sqlName + `)(?:\s+(?:CASCADE|RESTRICT))?$`)
+	renameColumnPattern = regexp.MustCompile(`(?is)^RENAME\s+(?:COLUMN\s+)?(` + sqlName + `)\s+TO\s+(` + sqlName + `)$`)
+	renameToPattern     = regexp.MustCompile(`(?is)^RENAME\s+(?:TO|AS)\s+` + sqlIdentifier + `$`)
+	alterNullPattern    = regexp.MustCompile(`(?is)^ALTER\s+(?:COLUMN\s+)?(` + sqlName + `)\s+(SET|DROP)\s+NOT\s+NULL$`)
+	alterTypePattern    = regexp.MustCompile(`(?is)^ALTER\s+(?:COLUMN\s+)?(` + sqlName + `)\s+(?:SET\s+DATA\s+)?TYPE\s+(.+?)(?:\s+(?:USING|COLLATE)\s.*)?$`)
+	modifyColumnPattern = regexp.MustCompile(`(?is)^MODIFY\s+(?:COLUMN\s+)?(.*)$`)
+	changeColumnPattern = regexp.MustCompile(`(?is)^CHANGE\s+(?:COLUMN\s+)?(` + sqlName + `)\s+(.*)$`)
+	inheritPattern      = regexp.MustCompile(`(?is)^INHERIT\s`)
+)
+
+// sqlTable is a table of an SQL schema
+type sqlTable struct {
+	schema, name string
+	columns      []*sqlColumn
+}
+
+// sqlColumn is a column of a table
+type sqlColumn struct {
+	name       string
+	typ        string // lowercase, such as character varying(255)
+	notNull    bool
+	primaryKey bool
+	computed   bool // GENERATED ALWAYS AS (...) STORED
+}
+
+// ImportSQL reads a PostgreSQL or MySQL schema, such as the output of pg_dump
+// --schema-only, mysqldump --no-data or concatenated migrations, and derives
+// the domains and fields of a project over its tables. Tables become domains,
+// namespaced by their schema unless it is public, and columns with a field
+// type the fields; NOT NULL columns are required. Tables whose columns come
+// from a query, another table or a type are refused rather than imported
+// without them. Every generated entity has a UUID id and the
+// temporal and soft delete columns, so the other primary keys are left out
+// and tables missing those columns are reported.
+func ImportSQL(path string) (*Import, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SQL schema: %w", err)
+	}
+	return importSQLSchema(string(content), path)
+}
+
+// ImportDatabase imports the schema of a live PostgreSQL database, dumped
+// with pg_dump --schema-only, like ImportSQL
+func ImportDatabase(ctx context.Context, url string) (*Import, error) {
+	cmd := exec.CommandContext(ctx, "pg_dump", "--schema-only", "--no-owner", "--no-privileges", url)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("failed to dump the database schema with pg_dump: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("failed to dump the database schema with pg_dump: %w", err)
+	}
+	return importSQLSchema(string(out), "the database")
+}
+
+// importSQLSchema imports the tables of an SQL schema read from source
+func importSQLSchema(content, source string) (*Import, error) {
+	var tables []*sqlTable
+	byName := make(map[string]*sqlTable)
+	for _, statement := range splitSQLStatements(content) {
+		if m := createTablePattern.FindStringSubmatchIndex(statement); m != nil {
+			if m[2] >= 0 {
+				continue // temporary and unlogged tables hold no entities
+			}
+			schema, name := splitSQLName(statement[m[4]:m[5]])
+			body, ok := parenthesized(statement[m[1]-1:])
+			if !ok {
+				return nil, fmt.Errorf("%w: %s: CREATE TABLE %s has no closing parenthesis", ErrInvalidSQLSchema, source, name)
+			}
+			if copied := copyTailPattern.FindStringSubmatch(statement[m[1]+len(body)+1:]); copied != nil {
+				return nil, fmt.Errorf("%w: %s: %w", ErrInvalidSQLSchema, source, copiedColumns(name, copied[1]))
+			}
+			if byName[schema+"."+name] != nil {
+				continue // CREATE TABLE IF NOT EXISTS of a table created before
+			}
+			table := &sqlTable{schema: schema, name: name}
+			for _, element := range splitSQLList(body) {
+				if err := table.addElement(element); err != nil {
+					return nil, fmt.Errorf("%w: %s: %w", ErrInvalidSQLSchema, source, err)
+				}
+			}
+			tables = append(tables, table)
+			byName[schema+"."+name] = table
+			continue
+		}
+		if m := copyTablePattern.FindStringSubmatch(statement); m != nil {
+			if m[1] != "" || strings.HasPrefix(strings.ToUpper(m[3]), "PARTITION") {
+				continue // partitions have the columns of their table
+			}
+			_, name := splitSQLName(m[2])
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidSQLSchema, source, copiedColumns(name, m[3]))
+		}
+		if m := alterTablePattern.FindStringSubmatch(statement); m != nil {
+			schema, name := splitSQLName(m[1])
+			table := byName[schema+"."+name]
+			if table == nil {
+				continue
+			}
+			for _, action := range splitSQLList(m[2]) {
+				if err := table.alter(action); err != nil {
+					return nil, fmt.Errorf("%w: %s: %w", ErrInvalidSQLSchema, source, err)
+				}
+			}
+			delete(byName, schema+"."+name)
+			byName[table.schema+"."+table.name] = table
+			continue
+		}
+		if m := renameTablePattern.FindStringSubmatch(statement); m != nil {
+			for _, pair := range splitSQLList(m[1]) {
+				names := renamePairPattern.FindStringSubmatch(pair)
+				if names == nil {
+					continue
+				}
+				schema, name := splitSQLName(names[1])
+				if table := byName[schema+"."+name]; table != nil {
+					delete(byName, schema+"."+name)
+					table.schema, table.name = splitSQLName(names[2])
+					byName[table.schema+"."+table.name] = table
+				}
+			}
+			continue
+		}
+		if m := dropTablePattern.FindStringSubmatch(statement); m != nil {
+			for _, name := range splitSQLList(m[1]) {
+				schema, name := splitSQLName(name)
+				if table := byName[schema+"."+name]; table != nil {
+					delete(byName, schema+"."+name)
+					tables = slices.DeleteFunc(tables, func(t *sqlTable) bool { return t == table })
+				}
+			}
+		}
+	}
+
+	result := &Import{Fields: make(map[string]string)}
+	for _, table := range tables {
+		if slices.Contains(ignoredTables, table.name) {
+			continue
+		}
+		namespace := table.schema
+		if namespace == "public" {
+			namespace = ""
+		}
+		domain := result.addDomain(namespace, table.name, "the table "+table.name)
+
+		var definitions, missing []string
+		for _, fixed := range fixedFields {
+			if !slices.ContainsFunc(table.columns, func(c *sqlColumn) bool { return c.name == fixed }) {
+				missing = append(missing, fixed)
+			}
+		}
+		for _, c := range table.columns {
+			switch {
+			case c.primaryKey && c.name != "id":
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s: skipped the primary key %s; every entity is identified by a UUID id", domain, c.name))
+				continue
+			case c.name == "id" && !isUUIDColumn(c.typ):
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s: the id of the table %s is %s; every entity is identified by a UUID id", domain, table.name, c.typ))
+				continue
+			case c.computed:
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s: skipped the generated column %s", domain, c.name))
+				continue
+			}
+			typ, ok := sqlFieldType(c.typ)
+			if !ok {
+				if !slices.Contains(fixedFields, c.name) {
+					result.Warnings = append(result.Warnings, fmt.Sprintf("%s: skipped %s: no field type for %s", domain, c.name, c.typ))
+				}
+				continue
+			}
+			if !c.notNull {
+				typ += "?"
+			}
+			definitions = result.addField(definitions, domain, c.name, typ)
+		}
+		if len(definitions) > 0 {
+			result.Fields[domain] = strings.Join(definitions, ",")
+		} else {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: no columns with a field type, using the default fields", domain))
+		}
+		if len(missing) > 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: the table %s lacks %s, which the generated migration creates and the generated queries use", domain, table.name, strings.Join(missing, ", ")))
+		}
+	}
+	if len(result.Domains) == 0 {
+		return nil, fmt.Errorf("%w: %s has no CREATE TABLE statements", ErrInvalidSQLSchema, source)
+	}
+	return result, nil
+}
+
+// copiedColumns is the error of a table whose columns come from elsewhere,
+// by the keyword that names their source
+func copiedColumns(table, keyword string) error {
+	source := map[string]string{
+		"AS":       "a query",
+		"LIKE":     "another table",
+		"OF":       "a composite type",
+		"INHERITS": "the tables it inherits",
+		"INHERIT":  "the tables it inherits",
+	}[strings.ToUpper(keyword)]
+	return fmt.Errorf("the table %s takes columns from %s, which cannot be imported; declare them in the table instead", table, source)
+}
+
+// addElement adds a column or table constraint of a CREATE TABLE statement
+func (t *sqlTable) addElement(element string) error {
+	words := splitSQLWords(element)
+	if len(words) == 0 {
+		return nil
+	}
+	switch strings.ToUpper(words[0]) {
+	case "LIKE":
+		return copiedColumns(t.name, "LIKE")
+	case "KEY", "INDEX":
+		if !mysqlIndex(words) {
+			break // a column named key or index
+		}
+		return nil
+	case "CONSTRAINT", "PRIMARY", "UNIQUE", "FOREIGN", "CHECK", "EXCLUDE", "FULLTEXT", "SPATIAL", "PARTITION":
+		if m := primaryKeyPattern.FindStringSubmatch(element); m != nil {
+			for _, name := range strings.Split(m[1], ",") {
+				if c := t.column(unquoteSQL(strings.TrimSpace(name))); c != nil {
+					c.primaryKey, c.notNull = true, true
+				}
+			}
+		}
+		return nil
+	}
+
+	c := &sqlColumn{name: unquoteSQL(words[0])}
+	constraints := len(words)
+	for i, w := range words[1:] {
+		switch strings.ToLower(w) {
+		case "not", "null", "default", "primary", "unique", "references", "check", "constraint", "collate", "generated",
+			"as", "auto_increment", "comment", "on", "charset", "after", "first", "visible", "invisible":
+			constraints = i + 1
+		case "character":
+			// MySQL's CHARACTER SET, after the type rather than the character type
+			if i > 0 {
+				constraints = i + 1
+			}
+		}
+		if constraints != len(words) {
+			break
+		}
+	}
+	c.typ = normalizeSQLType(strings.Join(words[1:constraints], " "))
+	rest := strings.ToLower(" " + strings.Join(words[constraints:], " ") + " ")
+	c.notNull = strings.Contains(rest, " not null ")
+	c.primaryKey = strings.Contains(rest, " primary key ")
+	c.computed = strings.Contains(rest, " generated ") && !strings.Contains(rest, " identity ") || strings.HasPrefix(rest, " as ")
+	if c.primaryKey {
+		c.notNull = true
+	}
+	if existing := t.column(c.name); existing != nil {
+		*existing = *c
+		return nil
+	}
+	t.columns = append(t.columns, c)
+	return nil
+}
+
+// isUUIDColumn reports whether a column type holds UUIDs, a uuid or CHAR(36)
+func isUUIDColumn(typ string) bool {
+	field, _ := sqlFieldType(typ)
+	return field == "uuid"
+}
+
+// mysqlIndex reports whether the words of a table element starting with KEY
+// or INDEX are a MySQL index, an optional name before its columns, rather
+// than a column of that name
+func mysqlIndex(words []string) bool {
+	for _, w := range words[1:min(len(words), 3)] {
+		if strings.HasPrefix(w, "(") {
+			return true
+		}
+		if _, ok := sqlFieldType(strings.ToLower(w)); ok {
+			return false
+		}
+	}
+	return false
+}
+
+// normalizeSQLType writes a column type as addElement compares it: lowercase,
+// such as character varying(255) or numeric(12,2)
+func normalizeSQLType(typ string) string {
+	typ = strings.ToLower(strings.Join(strings.Fields(typ), " "))
+	return strings.ReplaceAll(strings.ReplaceAll(typ, " (", "("), ", ", ",")
+}
+
+// alter applies an action of an ALTER TABLE statement: the primary keys,
+// columns and nullability pg_dump and migrations declare there, and the
+// columns and names later migrations change. Other actions, such as indexes
+// and defaults, leave the fields as they are.
+func (t *sqlTable) alter(action string) error {
+	action = strings.TrimSpace(action)
+	if inheritPattern.MatchString(action) {
+		return copiedColumns(t.name, "INHERIT")
+	}
+	if m := addColumnPattern.FindStringSubmatch(action); m != nil {
+		return t.addElement(m[1])
+	}
+	if m := dropColumnPattern.FindStringSubmatch(action); m != nil {
+		t.columns = slices.DeleteFunc(t.columns, func(c *sqlColumn) bool { return c.name == unquoteSQL(m[1]) })
+		return nil
+	}
+	if m := renameColumnPattern.FindStringSubmatch(action); m != nil {
+		if c := t.column(unquoteSQL(m[1])); c != nil {
+			c.name = unquoteSQL(m[2])
+		}
+		return nil
+	}
+	if m := renameToPattern.FindStringSubmatch(action); m != nil {
+		_, t.name = splitSQLName(m[1])
+		return nil
+	}
+	if m := alterNullPattern.FindStringSubmatch(action); m != nil {
+		if c := t.column(unquoteSQL(m[1])); c != nil {
+			c.notNull = strings.EqualFold(m[2], "SET")
+		}
+		return nil
+	}
+	if m := alterTypePattern.FindStringSubmatch(action); m != nil {
+		if c := t.column(unquoteSQL(m[1])); c != nil {
+			c.typ = normalizeSQLType(m[2])
+		}
+		return nil
+	}
+	if m := modifyColumnPattern.FindStringSubmatch(action); m != nil {
+		return t.addElement(m[1])
+	}
+	if m := changeColumnPattern.FindStringSubmatch(action); m != nil {
+		if words := splitSQLWords(m[2]); len(words) > 0 {
+			if c := t.column(unquoteSQL(m[1])); c != nil {
+				c.name = unquoteSQL(words[0])
+			}
+		}
+		return t.addElement(m[2])
+	}
+	return nil
+}
+
+// column returns the column of a name, nil if the table has none
+func (t *sqlTable) column(name string) *sqlColumn {
+	for _, c := range t.columns {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// sqlFieldType maps a PostgreSQL or MySQL column type to a field type
+func sqlFieldType(typ string) (string, bool) {
+	typ = strings.TrimPrefix(typ, "pg_catalog.")
+	if strings.HasSuffix(typ, "]") {
+		return "", false
+	}
+	typ, unsigned := strings.CutSuffix(strings.TrimSuffix(typ, " zerofill"), " unsigned")
+	// The length of varchar(64); timestamp(3) with time zone is a timestamp
+	base, args, _ := strings.Cut(typ, "(")
+	length, _ := strconv.Atoi(strings.TrimSuffix(args, ")"))
+	switch base {
+	case "character varying", "varchar", "character", "char", "bpchar":
+		if args == "" && (base == "character" || base == "char") {
+			length = 1
+		}
+		// Generated MySQL schemas store UUIDs as CHAR(36), which pg_dump
+		// would write as character(36)
+		if base == "char" && length == 36 {
+			return "uuid", true
+		}
+		if length > 0 && length <= fieldTypes["string"].maxLength {
+			return "string", true
+		}
+		return "text", true
+	case "text", "citext", "tinytext", "mediumtext", "longtext":
+		return "text", true
+	case "tinyint":
+		// MySQL writes BOOLEAN columns as tinyint(1)
+		if length == 1 && !unsigned {
+			return "bool", true
+		}
+		return "int", true
+	case "integer", "int", "int4":
+		// An unsigned MySQL INT exceeds the range of int32
+		if unsigned {
+			return "bigint", true
+		}
+		return "int", true
+	case "smallint", "int2", "mediumint", "smallserial", "serial2", "serial", "serial4":
+		return "int", true
+	case "bigint", "int8", "bigserial", "serial8":
+		return "bigint", true
+	case "real", "float4", "double precision", "double", "float8", "float":
+		return "float", true
+	case "numeric", "decimal":
+		return "decimal", true
+	case "boolean", "bool":
+		return "bool", true
+	case "date", "datetime", "timestamp", "timestamptz", "timestamp with time zone", "timestamp without time zone":
+		return "timestamp", true
+	case "uuid":
+		return "uuid", true
+	case "json", "jsonb":
+		return "json", true
+	}
+	return "", false
+}
+
+// splitSQLStatements splits SQL into its statements, dropping comments and
+// keeping semicolons in quoted strings, identifiers and dollar-quoted bodies
+func splitSQLStatements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			statements = append(statements, s)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			i += end - 1
+			current.WriteByte(' ')
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			depth := 0
+			for ; i < len(sql); i++ {
+				if strings.HasPrefix(sql[i:], "/*") {
+					depth++
+					i++
+				} else if strings.HasPrefix(sql[i:], "*/") {
+					depth--
+					i++
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			current.WriteByte(' ')
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(sql) {
+				if sql[end] == c {
+					if end+1 < len(sql) && sql[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			current.WriteString(sql[i:min(end+1, len(sql))])
+			i = end
+		case c == '$':
+			if tag := dollarTag.FindString(sql[i:]); tag != "" {
+				end := strings.Index(sql[i+len(tag):], tag)
+				if end < 0 {
+					end = len(sql) - i - len(tag)
+				} else {
+					end += len(tag)
+				}
+				current.WriteString(sql[i:min(i+len(tag)+end, len(sql))])
+				i += len(tag) + end - 1
+				continue
+			}
+			current.WriteByte(c)
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
+
+// dollarTag matches the opening of a dollar-quoted string, such as $$ or $body$
+var dollarTag = regexp.MustCompile(`^\$(?:[A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// parenthesized returns what is inside the parenthesis s starts with
+func parenthesized(s string) (string, bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[1:i], true
+			}
+		case '\'', '"', '`':
+			if end := strings.IndexByte(s[i+1:], s[i]); end >= 0 {
+				i += end + 1
+			}
+		}
+	}
+	return "", false
+}
+
+// splitSQLList splits a comma-separated list at the commas outside
+// parentheses and quotes
+func splitSQLList(s string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '\'', '"', '`':
+			if end := strings.IndexByte(s[i+1:], s[i]); end >= 0 {
+				i += end + 1
+			}
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" {
+		items = append(items, rest)
+	}
+	return items
+}
+
+// splitSQLWords splits a column definition into its words, keeping a
+// parenthesized group, such as the (12, 2) of numeric (12, 2), as one
+func splitSQLWords(s string) []string {
+	var words []string
+	depth, start := 0, -1
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '(':
+			if depth == 0 && start < 0 {
+				start = i
+			}
+			depth++
+		case c == ')':
+			depth--
+		case c == '\'' || c == '"' || c == '`':
+			if start < 0 {
+				start = i
+			}
+			if end := strings.IndexByte(s[i+1:], c); end >= 0 {
+				i += end + 1
+			}
+		case depth == 0 && (c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+			if start >= 0 {
+				words = append(words, s[start:i])
+				start = -1
+			}
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	if start >= 0 {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// splitSQLName splits a possibly schema-qualified name, the schema public if
+// it has none
+func splitSQLName(name string) (schema, table string) {
+	name = strings.TrimSpace(name)
+	if m := qualifiedPattern.FindStringSubmatch(name); m != nil {
+		return unquoteSQL(m[1]), unquoteSQL(m[2])
+	}
+	return "public", unquoteSQL(name)
+}
+
+// unquoteSQL returns an identifier as PostgreSQL resolves it: quoted ones,
+// in double quotes or MySQL's backticks, verbatim, others folded to lowercase
+func unquoteSQL(name string) string {
+	if len(name) >= 2 && (name[0] == '"' || name[0] == '`') && name[len(name)-1] == name[0] {
+		quote := name[:1]
+		return strings.ReplaceAll(name[1:len(name)-1], quote+quote, quote)
+	}
+	return strings.ToLower(name)
+}
@@ -0,0 +1,281 @@
+package generator
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// temporalColumns are the fixed columns importSQLSchema expects besides id
+const temporalColumns = `
+	effective_start timestamptz NOT NULL,
+	effective_end timestamptz NOT NULL,
+	created_at timestamptz NOT NULL,
+	updated_at timestamptz NOT NULL,
+	deleted_at timestamptz`
+
+var importSQLTests = []struct {
+	name    string
+	schema  string
+	domains []string
+	fields  map[string]string
+	// warnings are parts of the warnings the import must give, in order
+	warnings []string
+}{
+	{
+		name: "postgres types",
+		schema: `CREATE TABLE products (
+	id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+	sku character varying(64) NOT NULL,
+	description text,
+	name varchar NOT NULL,
+	code char NOT NULL,
+	quantity integer NOT NULL,
+	views bigint NOT NULL,
+	rating double precision,
+	price numeric(12, 2) NOT NULL,
+	active boolean NOT NULL DEFAULT true,
+	released_at timestamp(3) with time zone,
+	owner_id uuid NOT NULL REFERENCES users (id),
+	attributes jsonb,` + temporalColumns + `
+);`,
+		domains: []string{"product"},
+		fields: map[string]string{
+			"product": "sku:string,description:text?,name:text,code:string,quantity:int,views:bigint,rating:float?,price:decimal,active:bool,released_at:timestamp?,owner_id:uuid,attributes:json?",
+		},
+	},
+	{
+		name: "mysql types",
+		schema: "DROP TABLE IF EXISTS `products`;\n" +
+			"/*!40101 SET character_set_client = utf8mb4 */;\n" +
+			"CREATE TABLE `products` (\n" +
+			"  `id` char(36) NOT NULL DEFAULT (uuid()),\n" +
+			"  `sku` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL COMMENT 'stock keeping unit, unique',\n" +
+			"  `body` longtext,\n" +
+			"  `active` tinyint(1) NOT NULL DEFAULT '1',\n" +
+			"  `stock` int(11) NOT NULL,\n" +
+			"  `views` int unsigned NOT NULL,\n" +
+			"  `rank` tinyint NOT NULL,\n" +
+			"  `rating` double DEFAULT NULL,\n" +
+			"  `price` decimal(12,2) NOT NULL,\n" +
+			"  `owner_id` char(36) NOT NULL,\n" +
+			"  `seen_at` datetime(6) DEFAULT NULL,\n" +
+			"  `meta` json DEFAULT NULL,\n" +
+			"  `effective_start` datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),\n" +
+			"  `effective_end` datetime(6) NOT NULL DEFAULT '9999-12-31 23:59:59.000000',\n" +
+			"  `created_at` datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),\n" +
+			"  `updated_at` datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),\n" +
+			"  `deleted_at` datetime(6) DEFAULT NULL,\n" +
+			"  PRIMARY KEY (`id`),\n" +
+			"  UNIQUE KEY `products_sku` (`sku`),\n" +
+			"  KEY `idx_products_deleted_at` (`deleted_at`)\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;\n",
+		domains: []string{"product"},
+		fields: map[string]string{
+			"product": "sku:string,body:text?,active:bool,stock:int,views:bigint,rank:int,rating:float?,price:decimal,owner_id:uuid,seen_at:timestamp?,meta:json?",
+		},
+	},
+	{
+		name: "quoted identifiers",
+		schema: `CREATE TABLE "Billing"."Invoice_Lines" (
+	id uuid PRIMARY KEY,
+	"Amount" numeric NOT NULL,
+	"Note Text" text DEFAULT 'a; b',` + temporalColumns + `
+);
+CREATE TABLE public."orders" (id uuid PRIMARY KEY, "total" bigint NOT NULL,` + temporalColumns + `);`,
+		domains: []string{"billing.invoice_line", "order"},
+		fields: map[string]string{
+			"billing.invoice_line": "amount:decimal,note_text:text?",
+			"order":                "total:bigint",
+		},
+	},
+	{
+		name: "composite primary key",
+		schema: `CREATE TABLE order_items (
+	order_id uuid NOT NULL,
+	product_id uuid NOT NULL,
+	quantity integer NOT NULL,
+	PRIMARY KEY (order_id, product_id)
+);`,
+		domains: []string{"order_item"},
+		fields:  map[string]string{"order_item": "quantity:int"},
+		warnings: []string{
+			"skipped the primary key order_id",
+			"skipped the primary key product_id",
+			"lacks id, effective_start",
+		},
+	},
+	{
+		name: "primary key added by pg_dump",
+		schema: `CREATE TABLE public.tags (
+    id integer NOT NULL,
+    label character varying(40)
+);
+ALTER TABLE ONLY public.tags ADD CONSTRAINT tags_pkey PRIMARY KEY (id);
+ALTER TABLE public.tags ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (SEQUENCE NAME public.tags_id_seq START WITH 1);
+ALTER TABLE public.tags OWNER TO app;`,
+		domains:  []string{"tag"},
+		fields:   map[string]string{"tag": "label:string?"},
+		warnings: []string{"the id of the table tags is integer", "lacks effective_start"},
+	},
+	{
+		name: "nullable columns changed by migrations",
+		schema: `CREATE TABLE notes (id uuid PRIMARY KEY, title text, body text NOT NULL,` + temporalColumns + `);
+ALTER TABLE notes ALTER COLUMN title SET NOT NULL;
+ALTER TABLE notes ALTER body DROP NOT NULL;`,
+		domains: []string{"note"},
+		fields:  map[string]string{"note": "title:text,body:text?"},
+	},
+	{
+		name: "columns changed by migrations",
+		schema: `CREATE TABLE IF NOT EXISTS accounts (id uuid PRIMARY KEY, handle text NOT NULL, legacy text, score integer,` + temporalColumns + `);
+CREATE TABLE IF NOT EXISTS accounts (id uuid PRIMARY KEY);
+ALTER TABLE accounts ADD email varchar(255) NOT NULL, ADD COLUMN IF NOT EXISTS bio text;
+ALTER TABLE accounts DROP COLUMN legacy;
+ALTER TABLE accounts RENAME COLUMN handle TO username;
+ALTER TABLE accounts ALTER COLUMN score TYPE bigint USING score::bigint;
+ALTER TABLE accounts RENAME TO members;
+CREATE TABLE scratch (id uuid PRIMARY KEY);
+DROP TABLE scratch;`,
+		domains: []string{"member"},
+		fields:  map[string]string{"member": "username:text,score:bigint?,email:string,bio:text?"},
+	},
+	{
+		name: "mysql migrations",
+		schema: "CREATE TABLE users (id char(36) NOT NULL PRIMARY KEY, name varchar(40), age int," + temporalColumns + ");\n" +
+			"ALTER TABLE users MODIFY COLUMN name varchar(80) NOT NULL AFTER id;\n" +
+			"ALTER TABLE users CHANGE age years smallint unsigned;\n" +
+			"ALTER TABLE users ADD INDEX idx_users_name (name);\n" +
+			"RENAME TABLE users TO people;\n",
+		domains: []string{"person"},
+		fields:  map[string]string{"person": "name:string,years:int?"},
+	},
+	{
+		name: "skipped columns and tables",
+		schema: `CREATE TABLE schema_migrations (version bigint NOT NULL);
+CREATE TEMPORARY TABLE staging (id uuid);
+CREATE TABLE events (
+	id uuid PRIMARY KEY,
+	tags text[],
+	kind mood NOT NULL,
+	total numeric GENERATED ALWAYS AS (price * 2) STORED,
+	price numeric NOT NULL,` + temporalColumns + `
+) PARTITION BY RANGE (created_at);
+CREATE TABLE events_2024 PARTITION OF events FOR VALUES FROM ('2024-01-01') TO ('2025-01-01');
+CREATE FUNCTION touch() RETURNS trigger AS $$ BEGIN NEW.updated_at = now(); RETURN NEW; END; $$ LANGUAGE plpgsql;`,
+		domains: []string{"event"},
+		fields:  map[string]string{"event": "price:decimal"},
+		warnings: []string{
+			"skipped tags: no field type for text[]",
+			"skipped kind: no field type for mood",
+			"skipped the generated column total",
+		},
+	},
+}
+
+func TestImportSQLSchema(t *testing.T) {
+	for _, tt := range importSQLTests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := importSQLSchema(tt.schema, "schema.sql")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(result.Domains, tt.domains) {
+				t.Errorf("domains = %v, want %v", result.Domains, tt.domains)
+			}
+			if !maps.Equal(result.Fields, tt.fields) {
+				t.Errorf("fields = %v, want %v", result.Fields, tt.fields)
+			}
+			assertWarnings(t, result.Warnings, tt.warnings)
+		})
+	}
+}
+
+// assertWarnings checks that the warnings hold each of wants, in order, and
+// nothing else
+func assertWarnings(t *testing.T, warnings, wants []string) {
+	t.Helper()
+	if len(warnings) != len(wants) {
+		t.Fatalf("warnings = %q, want %d", warnings, len(wants))
+	}
+	for i, want := range wants {
+		if !strings.Contains(warnings[i], want) {
+			t.Errorf("warning %d = %q, want it to mention %q", i, warnings[i], want)
+		}
+	}
+}
+
+var importSQLErrorTests = []struct {
+	name   string
+	schema string
+	wants  string
+}{
+	{name: "no tables", schema: "CREATE INDEX idx ON products (name);", wants: "has no CREATE TABLE statements"},
+	{name: "unclosed table", schema: "CREATE TABLE products (id uuid", wants: "has no closing parenthesis"},
+	{name: "columns of a query", schema: "CREATE TABLE archived AS SELECT * FROM products;", wants: "the table archived takes columns from a query"},
+	{name: "named columns of a query", schema: "CREATE TABLE archived (id, name) AS SELECT id, name FROM products;", wants: "the table archived takes columns from a query"},
+	{name: "columns of another table", schema: "CREATE TABLE drafts (LIKE products INCLUDING ALL, note text);", wants: "the table drafts takes columns from another table"},
+	{name: "mysql copy of a table", schema: "CREATE TABLE `drafts` LIKE `products`;", wants: "the table drafts takes columns from another table"},
+	{name: "columns of a type", schema: "CREATE TABLE points OF point_type;", wants: "the table points takes columns from a composite type"},
+	{name: "inherited columns", schema: "CREATE TABLE cities (name text);\nCREATE TABLE capitals (state char(2)) INHERITS (cities);", wants: "the table capitals takes columns from the tables it inherits"},
+	{name: "inheritance added later", schema: "CREATE TABLE cities (name text);\nCREATE TABLE capitals (state char(2));\nALTER TABLE capitals INHERIT cities;", wants: "the table capitals takes columns from the tables it inherits"},
+}
+
+func TestImportSQLSchemaErrors(t *testing.T) {
+	for _, tt := range importSQLErrorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := importSQLSchema(tt.schema, "schema.sql")
+			if !errors.Is(err, ErrInvalidSQLSchema) {
+				t.Fatalf("importSQLSchema() error = %v, want ErrInvalidSQLSchema", err)
+			}
+			if !strings.Contains(err.Error(), tt.wants) {
+				t.Errorf("importSQLSchema() error = %v, want it to mention %q", err, tt.wants)
+			}
+		})
+	}
+}
+
+// TestImportSQLReadsGeneratedSchema imports the schema a generated project
+// declares for each database, which must give back the fields it was
+// generated with
+func TestImportSQLReadsGeneratedSchema(t *testing.T) {
+	const definitions = "title:string,body:text,stock:int,views:bigint,rating:float,price:decimal,active:bool,published_at:timestamp,owner_id:uuid,meta:json,note:string?"
+	fields, err := ParseFields(definitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, database := range []string{"postgres", "mysql"} {
+		t.Run(database, func(t *testing.T) {
+			config := &ProjectConfig{
+				AppName:    "shop",
+				ModuleName: "example.com/shop",
+				Domain:     "product",
+				Database:   database,
+				Fields:     map[string][]FieldSpec{"product": fields},
+			}
+			_, projectDir, err := New(t.TempDir()).render(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := ImportSQL(filepath.Join(projectDir, "internal", "database", "schema.sql"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(result.Domains, []string{"product"}) || result.Fields["product"] != definitions {
+				t.Errorf("ImportSQL() = %v %v, want product with %s", result.Domains, result.Fields, definitions)
+			}
+			assertWarnings(t, result.Warnings, nil)
+		})
+	}
+}
+
+func TestImportSQLMissingFile(t *testing.T) {
+	if _, err := ImportSQL(filepath.Join(t.TempDir(), "schema.sql")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ImportSQL() error = %v, want a missing file", err)
+	}
+}
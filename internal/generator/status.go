@@ -0,0 +1,138 @@
+package generator
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+)
+
+// toolConfigs lists the generated configuration of the tools a project uses,
+// as paths relative to the project; entries ending in "/" match a directory
+var toolConfigs = []struct {
+	Tool  string
+	Paths []string
+}{
+	{Tool: "Docker", Paths: []string{"Dockerfile", "Dockerfile.dev", ".dockerignore"}},
+	{Tool: "Docker Compose", Paths: []string{"docker-compose.yml", "compose.override.yaml", "compose.staging.yaml", "compose.prod.yaml"}},
+	{Tool: "Make", Paths: []string{"Makefile"}},
+	{Tool: "golangci-lint", Paths: []string{".golangci.yml"}},
+	{Tool: "sqlc", Paths: []string{"sqlc.yaml"}},
+	{Tool: "buf", Paths: []string{"buf.yaml", "buf.gen.yaml"}},
+	{Tool: "reflex", Paths: []string{".reflex.conf"}},
+	{Tool: "direnv", Paths: []string{".envrc"}},
+	{Tool: "EditorConfig", Paths: []string{".editorconfig"}},
+	{Tool: "GitHub Actions", Paths: []string{".github/workflows/", ".github/actions/"}},
+	{Tool: "GitLab CI", Paths: []string{".gitlab-ci.yml"}},
+	{Tool: "CircleCI", Paths: []string{".circleci/"}},
+	{Tool: "Renovate", Paths: []string{".github/renovate.json"}},
+	{Tool: "GoReleaser", Paths: []string{".goreleaser.yaml"}},
+	{Tool: "Prometheus", Paths: []string{"deploy/prometheus/", "deploy/slo.yaml"}},
+	{Tool: "Grafana", Paths: []string{"deploy/grafana/"}},
+	{Tool: "Vector", Paths: []string{"deploy/vector/"}},
+	{Tool: "MkDocs", Paths: []string{"mkdocs.yml", "docs/requirements.txt"}},
+	{Tool: "VS Code", Paths: []string{".vscode/"}},
+	{Tool: "GoLand", Paths: []string{".idea/"}},
+}
+
+// ProjectStatus reports how a generated project compares with its manifest
+// and with the templates go-app-gen would generate it with today
+type ProjectStatus struct {
+	Module   string   `json:"module"`
+	Features []string `json:"features,omitempty"`
+	// Templates compares the built-in templates, then each template pack
+	Templates []TemplateStatus `json:"templates,omitempty"`
+	// Modified and Deleted list the generated files changed or removed since
+	// the generation, relative to the project
+	Modified []string `json:"modified,omitempty"`
+	Deleted  []string `json:"deleted,omitempty"`
+	// Drifted lists the tools whose generated configuration was changed
+	Drifted []ToolDrift `json:"drifted,omitempty"`
+	// Pending lists the post-processing steps that did not complete
+	Pending []string `json:"pending,omitempty"`
+}
+
+// TemplateStatus compares the templates a project was generated with to the current ones
+type TemplateStatus struct {
+	Name      string `json:"name"`
+	Generated string `json:"generated"` // digest at generation
+	Current   string `json:"current"`   // digest now, empty when the pack is no longer found
+}
+
+// UpToDate reports whether the templates did not change since the generation
+func (t TemplateStatus) UpToDate() bool {
+	return t.Generated == t.Current
+}
+
+// ToolDrift is a tool whose generated configuration files were changed or removed
+type ToolDrift struct {
+	Tool  string   `json:"tool"`
+	Files []string `json:"files"`
+}
+
+// Status reports the template versions, features, modified files and drifted
+// tool configuration of a generated project from its manifest
+func Status(projectDir string) (*ProjectStatus, error) {
+	m, err := LoadManifest(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	status := &ProjectStatus{Module: m.Module, Features: m.Features, Pending: m.Remaining()}
+
+	if m.TemplateTree != "" {
+		t := TemplateStatus{Name: m.TemplateTree, Generated: m.Templates}
+		if tree, err := openTemplateTree(m.TemplateTree); err == nil {
+			t.Current, _ = templatesDigest(tree)
+		}
+		status.Templates = append(status.Templates, t)
+	} else {
+		current, err := builtinDigest()
+		if err != nil {
+			return nil, err
+		}
+		status.Templates = append(status.Templates, TemplateStatus{Name: builtinLayer, Generated: m.Templates, Current: current})
+	}
+	for _, p := range m.Packs {
+		t := TemplateStatus{Name: p.Name, Generated: p.Digest}
+		if pack, err := LoadPack(p.Dir); err == nil {
+			t.Current = pack.Digest
+		}
+		status.Templates = append(status.Templates, t)
+	}
+
+	paths := make([]string, 0, len(m.Files))
+	for p := range m.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	changed := make(map[string]bool)
+	for _, p := range paths {
+		sum, err := fileSHA256(filepath.Join(projectDir, filepath.FromSlash(p)))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			status.Deleted = append(status.Deleted, p)
+		case err != nil:
+			return nil, fmt.Errorf("failed to checksum %s: %w", p, err)
+		case m.Files[p] != "" && hex.EncodeToString(sum) != m.Files[p]:
+			status.Modified = append(status.Modified, p)
+		default:
+			continue
+		}
+		changed[p] = true
+	}
+
+	for _, tool := range toolConfigs {
+		drift := ToolDrift{Tool: tool.Tool}
+		for _, p := range paths {
+			if changed[p] && ownsTemplate(tool.Paths, p) {
+				drift.Files = append(drift.Files, p)
+			}
+		}
+		if len(drift.Files) > 0 {
+			status.Drifted = append(status.Drifted, drift)
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownDeployTarget is returned when a requested deploy target is not supported
+var ErrUnknownDeployTarget = errors.New("unknown deploy target")
+
+// DeployTarget is a runtime the project is built for in addition to its container image
+type DeployTarget struct {
+	Name        string
+	Description string
+	// Experimental targets cover a subset of the project and may change between releases
+	Experimental bool
+
+	// Templates lists template paths, relative to templates/, that are only
+	// rendered for this target. Entries ending in "/" match a directory.
+	Templates []string
+}
+
+// DeployTargets lists every deploy target the generator supports
+var DeployTargets = []DeployTarget{
+	{
+		Name:         "wasm-edge",
+		Description:  "TinyGo-compiled edge component for Spin (WASI) with health checks and an origin proxy",
+		Experimental: true,
+		Templates: []string{
+			"edge/",
+			"internal/edge/",
+		},
+	},
+}
+
+// DeployTargetNames returns the names of all supported deploy targets
+func DeployTargetNames() []string {
+	names := make([]string, len(DeployTargets))
+	for i, t := range DeployTargets {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// ValidateDeployTarget checks that target is empty or a supported deploy target
+func ValidateDeployTarget(target string) error {
+	if target == "" {
+		return nil
+	}
+	for _, t := range DeployTargets {
+		if t.Name == target {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q (available: %s)", ErrUnknownDeployTarget, target, strings.Join(DeployTargetNames(), ", "))
+}
+
+// templateDeployTarget returns the deploy target that owns a template, if any
+func templateDeployTarget(templatePath string) (string, bool) {
+	path := strings.TrimPrefix(templatePath, "templates/")
+	for _, t := range DeployTargets {
+		if ownsTemplate(t.Templates, path) {
+			return t.Name, true
+		}
+	}
+	return "", false
+}
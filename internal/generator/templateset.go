@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+)
+
+// Errors of templates that do not parse or render, which usually come from a
+// template pack or tree rather than from the project settings
+var (
+	ErrTemplateParse   = errors.New("failed to parse templates")
+	ErrTemplateExecute = errors.New("failed to execute template")
+)
+
+// partialsDir holds a layer's partials: templates that are never rendered to a
+// file themselves but included by the others as {{template "name" .}}, where
+// name is the file name without .tmpl
+const partialsDir = "templates/_partials/"
+
+// templateSet is the templates of a layer, each parsed once together with the
+// layer's partials and then executed for every output and every generation
+type templateSet struct {
+	paths     []string // template paths in walk order, starting with "templates/"
+	templates map[string]*parsedTemplate
+	partials  map[string]*parse.Tree
+}
+
+// parsedTemplate is a template of a set with the hash the render cache keys
+// it by, or a static asset
+type parsedTemplate struct {
+	tmpl *template.Template
+	hash string
+	// asset holds a file without the .tmpl extension, which is copied to the
+	// project as it is and never read into memory
+	asset fs.FS
+}
+
+// builtinTemplateSet parses the embedded templates on first use; they never
+// change, so every generation of the process shares them
+var builtinTemplateSet = sync.OnceValues(func() (*templateSet, error) {
+	return parseTemplateSet(templatesFS, nil)
+})
+
+// parseTemplateSet parses the templates under templates/ in fsys, reporting
+// every template that fails to parse. The partials of base, if any, are
+// available to the templates too, unless fsys has a partial of the same name.
+func parseTemplateSet(fsys fs.FS, base *templateSet) (*templateSet, error) {
+	set := &templateSet{templates: make(map[string]*parsedTemplate), partials: make(map[string]*parse.Tree)}
+	if base != nil {
+		for name, tree := range base.partials {
+			set.partials[name] = tree
+		}
+	}
+
+	var sources []string
+	contents := make(map[string][]byte)
+	err := fs.WalkDir(fsys, "templates", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if isAsset(p) {
+			sources = append(sources, p)
+			return nil
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return fmt.Errorf("failed to read template file %s: %w", p, err)
+		}
+		contents[p] = content
+		sources = append(sources, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var failures []string
+	for _, p := range sources {
+		if !strings.HasPrefix(p, partialsDir) {
+			continue
+		}
+		partial, err := template.New(strings.TrimSuffix(path.Base(p), ".tmpl")).Parse(string(contents[p]))
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		// A partial file may {{define}} more partials besides itself
+		for _, t := range partial.Templates() {
+			if t.Tree != nil {
+				set.partials[t.Name()] = t.Tree
+			}
+		}
+	}
+
+	for _, p := range sources {
+		if strings.HasPrefix(p, partialsDir) {
+			continue
+		}
+		if isAsset(p) {
+			set.paths = append(set.paths, p)
+			set.templates[p] = &parsedTemplate{asset: fsys}
+			continue
+		}
+		tmpl := template.New(p)
+		for name, tree := range set.partials {
+			if _, err := tmpl.AddParseTree(name, tree); err != nil {
+				return nil, fmt.Errorf("failed to add partial %s to %s: %w", name, p, err)
+			}
+		}
+		if _, err := tmpl.Parse(string(contents[p])); err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		set.paths = append(set.paths, p)
+		set.templates[p] = &parsedTemplate{tmpl: tmpl, hash: templateHash(p, contents[p])}
+	}
+
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("%w:\n  %s", ErrTemplateParse, strings.Join(failures, "\n  "))
+	}
+	return set, nil
+}
+
+// isAsset reports whether a file of a template set is a static asset
+func isAsset(templatePath string) bool {
+	return !strings.HasSuffix(templatePath, ".tmpl") && !strings.HasPrefix(templatePath, partialsDir)
+}
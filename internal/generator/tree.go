@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidTemplateTree is returned when a template tree cannot be rendered from
+var ErrInvalidTemplateTree = errors.New("invalid template tree")
+
+// isTemplateTree reports whether dir is a template tree rather than a pack:
+// a directory without a pack.yaml
+func isTemplateTree(dir string) bool {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(dir, PackManifestFile))
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// openTemplateTree opens a template tree replacing the built-in templates: a
+// directory laid out like the generated project, as internal/generator/templates
+// is, with the same path placeholders, partials and feature gating
+func openTemplateTree(dir string) (fs.FS, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplateTree, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s is not a directory", ErrInvalidTemplateTree, dir)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "templates")); err == nil && info.IsDir() {
+		return nil, fmt.Errorf("%w: %s has a templates directory but no %s; a template pack needs both", ErrInvalidTemplateTree, dir, PackManifestFile)
+	}
+	if err := checkSymlinks(dir, dir); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplateTree, err)
+	}
+
+	tree := treeFS{os.DirFS(dir)}
+	paths, err := templatePaths(tree)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplateTree, err)
+	}
+	if !containsTemplate(paths) {
+		return nil, fmt.Errorf("%w: %s has no .tmpl files", ErrInvalidTemplateTree, dir)
+	}
+	return tree, nil
+}
+
+// treeFS serves a template tree held at the root of fsys under templates/,
+// the directory the embedded templates and the packs keep theirs in. The
+// .git directory of a tree checked out with git is not part of it.
+type treeFS struct {
+	fsys fs.FS
+}
+
+func (t treeFS) Open(name string) (fs.File, error) {
+	rel, ok := t.rel(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return t.fsys.Open(rel)
+}
+
+func (t treeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	rel, ok := t.rel(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := fs.ReadDir(t.fsys, rel)
+	if rel == "." {
+		entries = slices.DeleteFunc(entries, func(e fs.DirEntry) bool { return e.Name() == ".git" })
+	}
+	return entries, err
+}
+
+// rel returns the path in fsys of a path under templates/
+func (t treeFS) rel(name string) (string, bool) {
+	if name == "templates" {
+		return ".", true
+	}
+	rel, ok := strings.CutPrefix(name, "templates/")
+	if !ok || rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return "", false
+	}
+	return rel, true
+}
+
+// containsTemplate reports whether paths holds a template and not only assets
+func containsTemplate(paths []string) bool {
+	for _, p := range paths {
+		if strings.HasSuffix(p, ".tmpl") {
+			return true
+		}
+	}
+	return false
+}
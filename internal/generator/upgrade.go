@@ -0,0 +1,329 @@
+package generator
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrOriginalMismatch is returned when the original templates given to
+// upgrade are not the ones the project was generated from
+var ErrOriginalMismatch = errors.New("original templates do not match the project")
+
+// Where the built-in templates of a go-app-gen release are found
+const (
+	builtinRepository   = "https://github.com/nhalm/go-app-gen"
+	builtinTemplatesDir = "internal/generator/templates"
+)
+
+// releaseVersion matches the go-app-gen versions that are tagged releases
+var releaseVersion = regexp.MustCompile(`^v?\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?$`)
+
+// UpgradeOptions are the templates a project is upgraded to and how the ones
+// it was generated from are found
+type UpgradeOptions struct {
+	// Version is the go-app-gen version upgrading, which the manifest records
+	Version string
+	// Template is the remote template source, URL[@ref], to upgrade a project
+	// generated with --template to; the recorded commit if empty
+	Template string
+	// Original is a template tree holding the templates the project was
+	// generated from, such as internal/generator/templates of a go-app-gen
+	// checkout at the recorded version, for when they cannot be fetched
+	Original string
+	// CacheDir is where remote templates and go-app-gen releases are cloned
+	CacheDir string
+}
+
+// UpgradeResult lists what upgrading a project changed
+type UpgradeResult struct {
+	From, To string // the go-app-gen versions of the generation and the upgrade
+	// Created lists the files the new templates add
+	Created []string
+	// Updated lists the files unchanged since the generation, which take the
+	// new render as it is
+	Updated []string
+	// Merged lists the edited files that received the template changes
+	Merged []string
+	// Conflicts lists the edited files whose template changes clash with the
+	// edits; they hold conflict markers to resolve by hand
+	Conflicts []string
+	// Kept lists the edited files left as they are because the templates the
+	// project was generated from were not found to merge against
+	Kept []string
+	// Obsolete lists the generated files the new templates no longer render;
+	// they are left in place for you to delete
+	Obsolete []string
+	// Warnings lists what the upgrade could not do
+	Warnings []string
+}
+
+// Upgrade re-renders a generated project with the current templates and the
+// configuration its manifest records, and merges the changes into it. The
+// project is also rendered with the templates it was generated from, by the
+// version of go-app-gen or the commit of the template source the manifest
+// records: files unchanged since the generation take the new render, and the
+// changes between the two renders are merged three ways into the files edited
+// since, leaving conflict markers where they clash. The post-processing then
+// runs again.
+func (g *Generator) Upgrade(projectDir string, opts UpgradeOptions) (*UpgradeResult, error) {
+	manifest, err := LoadManifest(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	config, err := DetectProject(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	result := &UpgradeResult{From: manifest.Generator, To: opts.Version}
+	drifted := manifest.drifted(projectDir)
+
+	if opts.Template != "" && manifest.TemplateSource == "" {
+		return nil, fmt.Errorf("%w: %s was not generated with --template, so it cannot be upgraded to %s", ErrInvalidTemplateSource, projectDir, opts.Template)
+	}
+
+	ctx := context.Background()
+	original, err := originalConfig(ctx, config, manifest, opts, result)
+	if err != nil {
+		return nil, err
+	}
+
+	upgraded := *config
+	upgraded.GeneratorVersion = opts.Version
+	source := cmp.Or(opts.Template, manifest.TemplateSource)
+	render := func(baseDir string) error {
+		// Fetched once the original render is done, the source may move the
+		// clone the original templates were checked out in
+		if source != "" {
+			remote, err := FetchTemplates(ctx, source, opts.CacheDir)
+			if err != nil {
+				return err
+			}
+			upgraded.TemplateDirs = slices.Clone(config.TemplateDirs)
+			if i := slices.Index(upgraded.TemplateDirs, remoteDir(manifest)); i >= 0 {
+				upgraded.TemplateDirs[i] = remote.Dir
+			}
+			upgraded.TemplateSource = remote.Source()
+		}
+		return renderTemporary(&upgraded, nil, func(rendered *Generator, data *TemplateData, renderDir string) error {
+			for _, f := range rendered.report.Files {
+				recorded, generated := manifest.Files[f.Path]
+				_, edited := drifted[f.Path]
+				clean := generated && recorded != "" && !edited
+				if err := result.apply(projectDir, baseDir, renderDir, f.Path, clean); err != nil {
+					return err
+				}
+			}
+			upgradedManifest, err := rendered.newManifest(data)
+			if err != nil {
+				return err
+			}
+			for p := range manifest.Files {
+				if _, ok := upgradedManifest.Files[p]; !ok && !isProjectOwned(p) {
+					result.Obsolete = append(result.Obsolete, p)
+				}
+			}
+			slices.Sort(result.Obsolete)
+			upgradedManifest.Pipeline = nil
+			for _, step := range postSteps(upgradedManifest.Module, upgradedManifest.Features) {
+				upgradedManifest.Pipeline = append(upgradedManifest.Pipeline, PipelineStep{Name: step.name, Status: StepPending})
+			}
+			manifest = upgradedManifest
+			return nil
+		})
+	}
+	if original != nil {
+		err = renderTemporary(original, nil, func(_ *Generator, _ *TemplateData, baseDir string) error {
+			return render(baseDir)
+		})
+	} else {
+		err = render("")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("🔄 Running post-generation tasks...")
+	if err := g.runPipeline(projectDir, manifest); err != nil {
+		return result, err
+	}
+	// Files edited since the generation keep their generated checksum, so
+	// status still reports them as modified
+	if len(drifted) > 0 {
+		for p, sum := range drifted {
+			if _, ok := manifest.Files[p]; ok {
+				manifest.Files[p] = sum
+			}
+		}
+		if err := manifest.write(projectDir); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// originalConfig returns the configuration rendering the project as it was
+// generated: config itself when its templates have not changed since, and
+// config on the original templates when they are found. It returns nil, with
+// a warning, when they are not.
+func originalConfig(ctx context.Context, config *ProjectConfig, manifest *Manifest, opts UpgradeOptions, result *UpgradeResult) (*ProjectConfig, error) {
+	// A template tree or the lowest pack checked out with --template is
+	// checked out again at the recorded commit
+	if manifest.TemplateSource != "" {
+		if _, err := FetchTemplates(ctx, manifest.TemplateSource, opts.CacheDir); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to check out the templates the project was generated from: %v", err))
+			return nil, nil
+		}
+	}
+	for _, p := range manifest.Packs {
+		if pack, err := LoadPack(p.Dir); err == nil && pack.Digest != p.Digest {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("template pack %s changed since the generation; only files you did not edit take its changes", p.Name))
+		}
+	}
+
+	var tree string
+	switch {
+	case opts.Original != "":
+		if err := checkOriginal(opts.Original, manifest); err != nil {
+			return nil, err
+		}
+		tree = opts.Original
+
+	case manifest.TemplateTree != "":
+		err := checkOriginal(manifest.TemplateTree, manifest)
+		if err == nil {
+			return config, nil
+		}
+		result.Warnings = append(result.Warnings, fmt.Sprintf("the template tree %s is not the one the project was generated from: %v", manifest.TemplateTree, err))
+
+	default:
+		if digest, err := builtinDigest(); err == nil && digest == manifest.Templates {
+			return config, nil
+		}
+		if !releaseVersion.MatchString(manifest.Generator) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("the project was generated by go-app-gen %s, which is not a release to fetch the templates of", cmp.Or(manifest.Generator, "of an unknown version")))
+			break
+		}
+		ref := "v" + strings.TrimPrefix(manifest.Generator, "v")
+		remote, err := FetchTemplates(ctx, builtinRepository+"@"+ref, opts.CacheDir)
+		if err == nil {
+			tree = filepath.Join(remote.Dir, filepath.FromSlash(builtinTemplatesDir))
+			err = checkOriginal(tree, manifest)
+		}
+		if err != nil {
+			tree = ""
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to fetch the templates of go-app-gen %s: %v", ref, err))
+		}
+	}
+	if tree == "" {
+		result.Warnings = append(result.Warnings, "without the templates the project was generated from, files edited since are kept as they are; pass them with --original to merge")
+		return nil, nil
+	}
+
+	// The tree replaces the built-in templates, or the recorded tree
+	original := *config
+	packs := config.TemplateDirs
+	if manifest.TemplateTree != "" && len(packs) > 0 {
+		packs = packs[1:]
+	}
+	original.TemplateDirs = append([]string{tree}, packs...)
+	return &original, nil
+}
+
+// remoteDir returns the template layer the manifest's template source was
+// checked out in: the template tree, or else the lowest pack
+func remoteDir(manifest *Manifest) string {
+	if manifest.TemplateTree != "" || len(manifest.Packs) == 0 {
+		return manifest.TemplateTree
+	}
+	return manifest.Packs[0].Dir
+}
+
+// checkOriginal returns an error unless the template tree in dir is the one
+// the manifest records the project was generated from
+func checkOriginal(dir string, manifest *Manifest) error {
+	tree, err := openTemplateTree(dir)
+	if err != nil {
+		return err
+	}
+	digest, err := templatesDigest(tree)
+	if err != nil {
+		return err
+	}
+	if digest != manifest.Templates {
+		return fmt.Errorf("%w: %s has the digest %s, the project was generated from %s", ErrOriginalMismatch, dir, digest, manifest.Templates)
+	}
+	return nil
+}
+
+// apply brings one file of the new render into the project. Files unchanged
+// since the generation, clean, take the new render; edited ones receive the
+// changes between the original render in baseDir and the new one, and are
+// kept without a baseDir.
+func (r *UpgradeResult) apply(projectDir, baseDir, renderDir, rel string, clean bool) error {
+	theirs, err := os.ReadFile(filepath.Join(renderDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return fmt.Errorf("failed to read rendered %s: %w", rel, err)
+	}
+	var base []byte
+	if baseDir != "" {
+		base, err = os.ReadFile(filepath.Join(baseDir, filepath.FromSlash(rel)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to read rendered %s: %w", rel, err)
+		}
+	}
+
+	target, err := projectPath(projectDir, rel)
+	if err != nil {
+		return err
+	}
+	ours, err := os.ReadFile(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		r.Created = append(r.Created, rel)
+		return os.WriteFile(target, theirs, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", rel, err)
+	}
+	if isProjectOwned(rel) || baseDir != "" && bytes.Equal(base, theirs) {
+		return nil
+	}
+
+	formatted := theirs
+	if path.Ext(rel) == ".go" {
+		formatted = gofmt(theirs)
+	}
+	switch {
+	case bytes.Equal(formatted, ours):
+		return nil
+	case clean:
+		r.Updated = append(r.Updated, rel)
+		return os.WriteFile(target, theirs, 0644)
+	case baseDir == "":
+		r.Kept = append(r.Kept, rel)
+		return nil
+	}
+
+	merged := mergeFile(rel, base, ours, theirs, "go-app-gen upgrade")
+	if bytes.Equal(merged.Content, ours) {
+		return nil
+	}
+	if merged.Conflicts > 0 {
+		r.Conflicts = append(r.Conflicts, rel)
+	} else {
+		r.Merged = append(r.Merged, rel)
+	}
+	if err := os.WriteFile(target, merged.Content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+// Package merge merges text files line by line the way diff3 does.
+//
+// A merge takes the base a file was derived from and two changed versions,
+// ours (the file as the user edited it) and theirs (the file as it would be
+// generated now). Lines changed on one side only are taken from that side;
+// lines both sides changed differently become a conflict, written with the
+// familiar <<<<<<< ======= >>>>>>> markers so editors and git recognize it.
+//
+// Mixed files, part generated and part written by hand, mark their generated
+// regions with BEGIN go-app-gen and END go-app-gen comments; ReplaceRegions
+// updates only those and leaves the code around them alone.
+package merge
+
+import (
+	"bytes"
+)
+
+// maxMatrix bounds the cells of the longest common subsequence table. Larger
+// differences are treated as replacing every line, which merges only when one
+// side is unchanged.
+const maxMatrix = 1 << 25
+
+// Labels name the sides in conflict markers
+type Labels struct {
+	Ours   string
+	Theirs string
+}
+
+// Result is a merged file
+type Result struct {
+	Content []byte
+	// Conflicts counts the regions both sides changed differently
+	Conflicts int
+}
+
+// Merge applies the changes from base to theirs onto ours
+func Merge(base, ours, theirs []byte, labels Labels) Result {
+	o, a, b := splitLines(base), splitLines(ours), splitLines(theirs)
+	matchA, matchB := match(o, a), match(o, b)
+
+	var out bytes.Buffer
+	var result Result
+	i, j, k := 0, 0, 0
+	for i < len(o) || j < len(a) || k < len(b) {
+		// Emit the lines all three versions share
+		stable := 0
+		for i+stable < len(o) && matchA[i+stable] == j+stable && matchB[i+stable] == k+stable {
+			stable++
+		}
+		if stable > 0 {
+			for _, line := range o[i : i+stable] {
+				out.WriteString(line)
+			}
+			i, j, k = i+stable, j+stable, k+stable
+			continue
+		}
+
+		// The changed region runs up to the next base line both sides kept
+		next := i
+		for next < len(o) && (matchA[next] < 0 || matchB[next] < 0) {
+			next++
+		}
+		endA, endB := len(a), len(b)
+		if next < len(o) {
+			endA, endB = matchA[next], matchB[next]
+		}
+		result.Conflicts += resolve(&out, o[i:next], a[j:endA], b[k:endB], labels)
+		i, j, k = next, endA, endB
+	}
+
+	result.Content = out.Bytes()
+	return result
+}
+
+// resolve writes the merge of a changed region and reports whether it conflicted
+func resolve(out *bytes.Buffer, base, ours, theirs []string, labels Labels) int {
+	var lines []string
+	switch {
+	case equal(ours, base):
+		lines = theirs
+	case equal(theirs, base), equal(ours, theirs):
+		lines = ours
+	default:
+		out.WriteString("<<<<<<< " + labels.Ours + "\n")
+		writeLines(out, ours)
+		out.WriteString("=======\n")
+		writeLines(out, theirs)
+		out.WriteString(">>>>>>> " + labels.Theirs + "\n")
+		return 1
+	}
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+	return 0
+}
+
+// writeLines writes lines of a conflict, ending the last one with a newline
+// so the marker after it starts a line
+func writeLines(out *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+		if line[len(line)-1] != '\n' {
+			out.WriteByte('\n')
+		}
+	}
+}
+
+// splitLines splits content after every newline, keeping them
+func splitLines(content []byte) []string {
+	var lines []string
+	for len(content) > 0 {
+		n := bytes.IndexByte(content, '\n') + 1
+		if n == 0 {
+			n = len(content)
+		}
+		lines = append(lines, string(content[:n]))
+		content = content[n:]
+	}
+	return lines
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// match returns, for every line of base, the index of the line of other it
+// is matched to by a longest common subsequence, or -1
+func match(base, other []string) []int {
+	matched := make([]int, len(base))
+	for i := range matched {
+		matched[i] = -1
+	}
+
+	// Common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(base) && prefix < len(other) && base[prefix] == other[prefix] {
+		matched[prefix] = prefix
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(base)-prefix && suffix < len(other)-prefix && base[len(base)-1-suffix] == other[len(other)-1-suffix] {
+		matched[len(base)-1-suffix] = len(other) - 1 - suffix
+		suffix++
+	}
+
+	o, a := base[prefix:len(base)-suffix], other[prefix:len(other)-suffix]
+	if len(o) == 0 || len(a) == 0 || (len(o)+1)*(len(a)+1) > maxMatrix {
+		return matched
+	}
+
+	// lengths[x][y] is the LCS length of o[x:] and a[y:]
+	width := len(a) + 1
+	lengths := make([]int32, (len(o)+1)*width)
+	for x := len(o) - 1; x >= 0; x-- {
+		for y := len(a) - 1; y >= 0; y-- {
+			switch {
+			case o[x] == a[y]:
+				lengths[x*width+y] = lengths[(x+1)*width+y+1] + 1
+			case lengths[(x+1)*width+y] >= lengths[x*width+y+1]:
+				lengths[x*width+y] = lengths[(x+1)*width+y]
+			default:
+				lengths[x*width+y] = lengths[x*width+y+1]
+			}
+		}
+	}
+	for x, y := 0, 0; x < len(o) && y < len(a); {
+		switch {
+		case o[x] == a[y]:
+			matched[prefix+x] = prefix + y
+			x, y = x+1, y+1
+		case lengths[(x+1)*width+y] >= lengths[x*width+y+1]:
+			x++
+		default:
+			y++
+		}
+	}
+	return matched
+}
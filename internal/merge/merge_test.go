@@ -0,0 +1,310 @@
+package merge
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var labels = Labels{Ours: "ours", Theirs: "theirs"}
+
+// numbered returns n distinct lines, with the lines of changed replaced
+func numbered(n int, changed map[int]string) string {
+	var b strings.Builder
+	for i := range n {
+		if line, ok := changed[i]; ok {
+			b.WriteString(line + "\n")
+			continue
+		}
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	return b.String()
+}
+
+var mergeTests = []struct {
+	name                      string
+	base, ours, theirs, wants string
+	conflicts                 int
+}{
+	{
+		name:   "only ours changed",
+		base:   "a\nb\nc\n",
+		ours:   "a\nB\nc\n",
+		theirs: "a\nb\nc\n",
+		wants:  "a\nB\nc\n",
+	},
+	{
+		name:   "only theirs changed",
+		base:   "a\nb\nc\n",
+		ours:   "a\nb\nc\n",
+		theirs: "a\nb\nC\n",
+		wants:  "a\nb\nC\n",
+	},
+	{
+		name:   "each side changed other lines",
+		base:   "a\nb\nc\nd\n",
+		ours:   "A\nb\nc\nd\n",
+		theirs: "a\nb\nc\nD\n",
+		wants:  "A\nb\nc\nD\n",
+	},
+	{
+		name:   "both sides made the same change",
+		base:   "a\nb\nc\n",
+		ours:   "a\nB\nc\n",
+		theirs: "a\nB\nc\n",
+		wants:  "a\nB\nc\n",
+	},
+	{
+		name:      "both sides changed a line differently",
+		base:      "a\nb\nc\n",
+		ours:      "a\nX\nc\n",
+		theirs:    "a\nY\nc\n",
+		wants:     "a\n<<<<<<< ours\nX\n=======\nY\n>>>>>>> theirs\nc\n",
+		conflicts: 1,
+	},
+	{
+		name:      "two separate conflicts",
+		base:      "a\nb\nc\nd\ne\n",
+		ours:      "a\nB1\nc\nD1\ne\n",
+		theirs:    "a\nB2\nc\nD2\ne\n",
+		wants:     "a\n<<<<<<< ours\nB1\n=======\nB2\n>>>>>>> theirs\nc\n<<<<<<< ours\nD1\n=======\nD2\n>>>>>>> theirs\ne\n",
+		conflicts: 2,
+	},
+	{
+		name:      "one side deleted a line the other changed",
+		base:      "a\nb\nc\n",
+		ours:      "a\nc\n",
+		theirs:    "a\nB\nc\n",
+		wants:     "a\n<<<<<<< ours\n=======\nB\n>>>>>>> theirs\nc\n",
+		conflicts: 1,
+	},
+	{
+		name:      "different inserts at the same spot",
+		base:      "a\nc\n",
+		ours:      "a\nb1\nc\n",
+		theirs:    "a\nb2\nc\n",
+		wants:     "a\n<<<<<<< ours\nb1\n=======\nb2\n>>>>>>> theirs\nc\n",
+		conflicts: 1,
+	},
+	{
+		name:   "the same insert at the same spot",
+		base:   "a\nc\n",
+		ours:   "a\nb\nc\n",
+		theirs: "a\nb\nc\n",
+		wants:  "a\nb\nc\n",
+	},
+	{
+		name:   "inserts at the start and the end",
+		base:   "a\nb\n",
+		ours:   "first\na\nb\n",
+		theirs: "a\nb\nlast\n",
+		wants:  "first\na\nb\nlast\n",
+	},
+	{
+		name:   "ours lacks the final newline",
+		base:   "a\nb\n",
+		ours:   "A\nb",
+		theirs: "a\nb\n",
+		wants:  "A\nb",
+	},
+	{
+		name:   "theirs lacks the final newline",
+		base:   "a\nb\n",
+		ours:   "first\na\nb\n",
+		theirs: "a\nb",
+		wants:  "first\na\nb",
+	},
+	{
+		name:      "a conflict on a line without a newline",
+		base:      "a\nb\n",
+		ours:      "a\nb",
+		theirs:    "a\nb\nc\n",
+		wants:     "a\n<<<<<<< ours\nb\n=======\nb\nc\n>>>>>>> theirs\n",
+		conflicts: 1,
+	},
+	{
+		name:   "an empty base with the same content on both sides",
+		base:   "",
+		ours:   "a\nb\n",
+		theirs: "a\nb\n",
+		wants:  "a\nb\n",
+	},
+	{
+		name:   "an empty base with only theirs written",
+		base:   "",
+		ours:   "",
+		theirs: "a\n",
+		wants:  "a\n",
+	},
+	{
+		name:      "an empty base with different content",
+		base:      "",
+		ours:      "a\n",
+		theirs:    "b\n",
+		wants:     "<<<<<<< ours\na\n=======\nb\n>>>>>>> theirs\n",
+		conflicts: 1,
+	},
+	{
+		name:   "everything empty",
+		base:   "",
+		ours:   "",
+		theirs: "",
+		wants:  "",
+	},
+}
+
+func TestMerge(t *testing.T) {
+	for _, tt := range mergeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Merge([]byte(tt.base), []byte(tt.ours), []byte(tt.theirs), labels)
+			if got := string(result.Content); got != tt.wants {
+				t.Errorf("Merge() content =\n%s\nwant\n%s", got, tt.wants)
+			}
+			if result.Conflicts != tt.conflicts {
+				t.Errorf("Merge() conflicts = %d, want %d", result.Conflicts, tt.conflicts)
+			}
+		})
+	}
+}
+
+// TestMergeBeyondMaxMatrix merges files whose difference is too large for the
+// subsequence table, which then counts as ours replacing every line
+func TestMergeBeyondMaxMatrix(t *testing.T) {
+	n := 6000
+	if (n-1)*(n-1) <= maxMatrix {
+		t.Fatalf("%d lines fit the table of %d cells", n, maxMatrix)
+	}
+	base := numbered(n, nil)
+	// Changing the first and last lines leaves no common prefix or suffix
+	ours := numbered(n, map[int]string{0: "changed first", n - 1: "changed last"})
+
+	t.Run("theirs unchanged", func(t *testing.T) {
+		result := Merge([]byte(base), []byte(ours), []byte(base), labels)
+		if string(result.Content) != ours || result.Conflicts != 0 {
+			t.Fatalf("Merge() = %d conflicts, content equal to ours %t", result.Conflicts, string(result.Content) == ours)
+		}
+	})
+
+	t.Run("theirs changed", func(t *testing.T) {
+		theirs := numbered(n, map[int]string{n / 2: "changed middle"})
+		result := Merge([]byte(base), []byte(ours), []byte(theirs), labels)
+		want := "<<<<<<< ours\n" + ours + "=======\n" + theirs + ">>>>>>> theirs\n"
+		if string(result.Content) != want || result.Conflicts != 1 {
+			t.Fatalf("Merge() = %d conflicts, want the whole file as one conflict", result.Conflicts)
+		}
+	})
+}
+
+var replaceRegionsTests = []struct {
+	name                string
+	ours, theirs, wants string
+	replaced, missing   []string
+	unbalanced          bool
+}{
+	{
+		name:     "a changed region",
+		ours:     "a\n// BEGIN go-app-gen routes\nold\n// END go-app-gen routes\nb\n",
+		theirs:   "x\n// BEGIN go-app-gen routes\nnew\nnewer\n// END go-app-gen routes\ny\n",
+		wants:    "a\n// BEGIN go-app-gen routes\nnew\nnewer\n// END go-app-gen routes\nb\n",
+		replaced: []string{"routes"},
+	},
+	{
+		name:   "an unchanged region",
+		ours:   "a\n// BEGIN go-app-gen routes\nsame\n// END go-app-gen routes\nb\n",
+		theirs: "// BEGIN go-app-gen routes\nsame\n// END go-app-gen routes\n",
+		wants:  "a\n// BEGIN go-app-gen routes\nsame\n// END go-app-gen routes\nb\n",
+	},
+	{
+		name:     "regions in shell, SQL and HTML comments",
+		ours:     "# BEGIN go-app-gen env\nA=1\n# END go-app-gen env\n-- BEGIN go-app-gen schema\nold\n-- END go-app-gen schema\n<!-- BEGIN go-app-gen docs -->\nold\n<!-- END go-app-gen docs -->\n",
+		theirs:   "# BEGIN go-app-gen env\nA=2\n# END go-app-gen env\n-- BEGIN go-app-gen schema\nnew\n-- END go-app-gen schema\n<!-- BEGIN go-app-gen docs -->\nnew\n<!-- END go-app-gen docs -->\n",
+		wants:    "# BEGIN go-app-gen env\nA=2\n# END go-app-gen env\n-- BEGIN go-app-gen schema\nnew\n-- END go-app-gen schema\n<!-- BEGIN go-app-gen docs -->\nnew\n<!-- END go-app-gen docs -->\n",
+		replaced: []string{"env", "schema", "docs"},
+	},
+	{
+		name:   "a region theirs lacks",
+		ours:   "// BEGIN go-app-gen custom\nmine\n// END go-app-gen custom\n",
+		theirs: "nothing generated\n",
+		wants:  "// BEGIN go-app-gen custom\nmine\n// END go-app-gen custom\n",
+	},
+	{
+		name:    "a region ours lacks",
+		ours:    "// BEGIN go-app-gen routes\nold\n// END go-app-gen routes\n",
+		theirs:  "// BEGIN go-app-gen routes\nold\n// END go-app-gen routes\n// BEGIN go-app-gen imports\nnew\n// END go-app-gen imports\n",
+		wants:   "// BEGIN go-app-gen routes\nold\n// END go-app-gen routes\n",
+		missing: []string{"imports"},
+	},
+	{
+		name:       "nested regions",
+		ours:       "// BEGIN go-app-gen outer\n// BEGIN go-app-gen inner\n// END go-app-gen inner\n// END go-app-gen outer\n",
+		theirs:     "",
+		unbalanced: true,
+	},
+	{
+		name:       "a region never ended",
+		ours:       "// BEGIN go-app-gen routes\nline\n",
+		unbalanced: true,
+	},
+	{
+		name:       "an end without a begin",
+		ours:       "line\n// END go-app-gen routes\n",
+		unbalanced: true,
+	},
+	{
+		name:       "an end of another region",
+		ours:       "// BEGIN go-app-gen routes\n// END go-app-gen imports\n",
+		unbalanced: true,
+	},
+	{
+		name:       "two regions of one name",
+		ours:       "// BEGIN go-app-gen routes\n// END go-app-gen routes\n// BEGIN go-app-gen routes\n// END go-app-gen routes\n",
+		unbalanced: true,
+	},
+	{
+		name:       "unbalanced regions in theirs",
+		ours:       "// BEGIN go-app-gen routes\n// END go-app-gen routes\n",
+		theirs:     "// BEGIN go-app-gen routes\n",
+		unbalanced: true,
+	},
+}
+
+func TestReplaceRegions(t *testing.T) {
+	for _, tt := range replaceRegionsTests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ReplaceRegions([]byte(tt.ours), []byte(tt.theirs))
+			if tt.unbalanced {
+				if !errors.Is(err, ErrUnbalancedRegions) {
+					t.Fatalf("ReplaceRegions() error = %v, want ErrUnbalancedRegions", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(result.Content); got != tt.wants {
+				t.Errorf("ReplaceRegions() content =\n%s\nwant\n%s", got, tt.wants)
+			}
+			if !slices.Equal(result.Replaced, tt.replaced) {
+				t.Errorf("ReplaceRegions() replaced = %v, want %v", result.Replaced, tt.replaced)
+			}
+			if !slices.Equal(result.Missing, tt.missing) {
+				t.Errorf("ReplaceRegions() missing = %v, want %v", result.Missing, tt.missing)
+			}
+		})
+	}
+}
+
+func TestHasRegions(t *testing.T) {
+	for content, want := range map[string]bool{
+		"// BEGIN go-app-gen routes\n// END go-app-gen routes\n": true,
+		"// BEGIN go-app-gen routes\n":                           false,
+		"// go-app-gen wrote this file\n":                        false,
+		"no markers\n":                                           false,
+	} {
+		if got := HasRegions([]byte(content)); got != want {
+			t.Errorf("HasRegions(%q) = %t, want %t", content, got, want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package merge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrUnbalancedRegions is returned when the region markers of a file do not
+// pair up
+var ErrUnbalancedRegions = errors.New("unbalanced go-app-gen region markers")
+
+// regionMarker matches the comment that opens or closes a generated region of
+// a mixed file, in the comment syntax of Go, shell, SQL or HTML:
+//
+//	// BEGIN go-app-gen routes
+//	// END go-app-gen routes
+var regionMarker = regexp.MustCompile(`^\s*(?://|#|--|<!--)\s*(BEGIN|END) go-app-gen(?:\s+([A-Za-z0-9_.-]+))?\s*(?:-->)?\s*$`)
+
+// region is a generated region of a file, named by its markers
+type region struct {
+	Name string
+	// Begin and End are the line indexes of the markers
+	Begin, End int
+}
+
+// RegionResult is a file whose generated regions were replaced
+type RegionResult struct {
+	Content []byte
+	// Replaced names the regions whose lines changed
+	Replaced []string
+	// Missing names the regions of the new content the file lacks
+	Missing []string
+}
+
+// HasRegions reports whether content has generated regions with markers
+// that pair up
+func HasRegions(content []byte) bool {
+	if !bytes.Contains(content, []byte(" go-app-gen")) {
+		return false
+	}
+	regions, err := checkRegions(splitLines(content))
+	return err == nil && len(regions) > 0
+}
+
+// ReplaceRegions replaces the lines inside each generated region of ours with
+// those of the region of the same name in theirs, leaving every line outside
+// the regions, and the regions theirs lacks, as ours has them
+func ReplaceRegions(ours, theirs []byte) (RegionResult, error) {
+	a, b := splitLines(ours), splitLines(theirs)
+	regionsA, err := checkRegions(a)
+	if err != nil {
+		return RegionResult{}, err
+	}
+	regionsB, err := checkRegions(b)
+	if err != nil {
+		return RegionResult{}, err
+	}
+	byName := make(map[string]region, len(regionsB))
+	for _, r := range regionsB {
+		byName[r.Name] = r
+	}
+
+	var out bytes.Buffer
+	var result RegionResult
+	next := 0
+	for _, r := range regionsA {
+		for _, line := range a[next : r.Begin+1] {
+			out.WriteString(line)
+		}
+		next = r.End
+		lines := a[r.Begin+1 : r.End]
+		if replacement, ok := byName[r.Name]; ok {
+			if generated := b[replacement.Begin+1 : replacement.End]; !equal(lines, generated) {
+				lines = generated
+				result.Replaced = append(result.Replaced, r.Name)
+			}
+			delete(byName, r.Name)
+		}
+		for _, line := range lines {
+			out.WriteString(line)
+		}
+	}
+	for _, line := range a[next:] {
+		out.WriteString(line)
+	}
+	for _, r := range regionsB {
+		if _, ok := byName[r.Name]; ok {
+			result.Missing = append(result.Missing, r.Name)
+		}
+	}
+	result.Content = out.Bytes()
+	return result, nil
+}
+
+// checkRegions returns the regions of lines, failing unless every BEGIN
+// marker is closed by an END marker of the same name before the next one
+// and no two regions share a name
+func checkRegions(lines []string) ([]region, error) {
+	var regions []region
+	open := -1
+	seen := make(map[string]bool)
+	for i, line := range lines {
+		m := regionMarker.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		switch {
+		case m[1] == "BEGIN" && open >= 0:
+			return nil, fmt.Errorf("%w: region %q begins on line %d inside region %q", ErrUnbalancedRegions, m[2], i+1, regions[open].Name)
+		case m[1] == "BEGIN" && seen[m[2]]:
+			return nil, fmt.Errorf("%w: region %q begins again on line %d", ErrUnbalancedRegions, m[2], i+1)
+		case m[1] == "BEGIN":
+			seen[m[2]] = true
+			regions = append(regions, region{Name: m[2], Begin: i})
+			open = len(regions) - 1
+		case open < 0 || regions[open].Name != m[2]:
+			return nil, fmt.Errorf("%w: line %d ends region %q, which is not open", ErrUnbalancedRegions, i+1, m[2])
+		default:
+			regions[open].End = i
+			open = -1
+		}
+	}
+	if open >= 0 {
+		return nil, fmt.Errorf("%w: region %q is never ended", ErrUnbalancedRegions, regions[open].Name)
+	}
+	return regions, nil
+}
@@ -0,0 +1,73 @@
+package scm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// github creates repositories through the GitHub REST API
+type github struct {
+	api apiClient
+}
+
+func (g *github) pushUser() string {
+	return "x-access-token"
+}
+
+// create creates the repository under the authenticated user or, when the
+// owner is someone else, under the organization
+func (g *github) create(ctx context.Context, repo Repository, opts Options) (string, string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := g.api.do(ctx, http.MethodGet, "/user", nil, &user); err != nil {
+		return "", "", err
+	}
+	path := "/user/repos"
+	if user.Login != repo.Owner {
+		path = "/orgs/" + url.PathEscape(repo.Owner) + "/repos"
+	}
+
+	var created struct {
+		CloneURL string `json:"clone_url"`
+		HTMLURL  string `json:"html_url"`
+	}
+	body := map[string]any{"name": repo.Name, "description": opts.Description, "private": !opts.Public, "auto_init": false}
+	if err := g.api.do(ctx, http.MethodPost, path, body, &created); err != nil {
+		return "", "", err
+	}
+	return created.CloneURL, created.HTMLURL, nil
+}
+
+// protect requires a reviewed pull request to change the branch and forbids
+// force pushes and deletion
+func (g *github) protect(ctx context.Context, repo Repository, branch string) error {
+	body := map[string]any{
+		"required_status_checks": nil,
+		"enforce_admins":         false,
+		"required_pull_request_reviews": map[string]any{
+			"required_approving_review_count": 1,
+			"dismiss_stale_reviews":           true,
+		},
+		"restrictions":       nil,
+		"allow_force_pushes": false,
+		"allow_deletions":    false,
+	}
+	return g.api.do(ctx, http.MethodPut, fmt.Sprintf("%s/branches/%s/protection", g.repoPath(repo), url.PathEscape(branch)), body, nil)
+}
+
+// label creates a label; one that exists already is left as it is
+func (g *github) label(ctx context.Context, repo Repository, label Label) error {
+	body := map[string]string{"name": label.Name, "color": label.Color, "description": label.Description}
+	err := g.api.do(ctx, http.MethodPost, g.repoPath(repo)+"/labels", body, nil)
+	if hasStatus(err, http.StatusUnprocessableEntity) {
+		return nil
+	}
+	return err
+}
+
+func (g *github) repoPath(repo Repository) string {
+	return "/repos/" + url.PathEscape(repo.Owner) + "/" + url.PathEscape(repo.Name)
+}
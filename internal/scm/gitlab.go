@@ -0,0 +1,79 @@
+package scm
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// gitlab creates projects through the GitLab REST API
+type gitlab struct {
+	api apiClient
+}
+
+func (g *gitlab) pushUser() string {
+	return "oauth2"
+}
+
+// create creates the project in the owner's namespace, a user or a group
+func (g *gitlab) create(ctx context.Context, repo Repository, opts Options) (string, string, error) {
+	var namespace struct {
+		ID int `json:"id"`
+	}
+	if err := g.api.do(ctx, http.MethodGet, "/namespaces/"+url.PathEscape(repo.Owner), nil, &namespace); err != nil {
+		return "", "", err
+	}
+
+	visibility := "private"
+	if opts.Public {
+		visibility = "public"
+	}
+	var created struct {
+		HTTPURL string `json:"http_url_to_repo"`
+		WebURL  string `json:"web_url"`
+	}
+	body := map[string]any{
+		"name":                   repo.Name,
+		"path":                   repo.Name,
+		"namespace_id":           namespace.ID,
+		"description":            opts.Description,
+		"visibility":             visibility,
+		"initialize_with_readme": false,
+	}
+	if err := g.api.do(ctx, http.MethodPost, "/projects", body, &created); err != nil {
+		return "", "", err
+	}
+	return created.HTTPURL, created.WebURL, nil
+}
+
+// protect lets maintainers merge into the branch and nobody push to it or
+// force push; GitLab may already protect the first pushed branch, which is
+// replaced with these rules
+func (g *gitlab) protect(ctx context.Context, repo Repository, branch string) error {
+	path := g.projectPath(repo) + "/protected_branches"
+	if err := g.api.do(ctx, http.MethodDelete, path+"/"+url.PathEscape(branch), nil, nil); err != nil && !hasStatus(err, http.StatusNotFound) {
+		return err
+	}
+	body := map[string]any{
+		"name":                  branch,
+		"push_access_level":     0,
+		"merge_access_level":    40,
+		"allowed_to_force_push": false,
+	}
+	return g.api.do(ctx, http.MethodPost, path, body, nil)
+}
+
+// label creates a label; one that exists already is left as it is
+func (g *gitlab) label(ctx context.Context, repo Repository, label Label) error {
+	body := map[string]string{"name": label.Name, "color": "#" + label.Color, "description": label.Description}
+	err := g.api.do(ctx, http.MethodPost, g.projectPath(repo)+"/labels", body, nil)
+	if hasStatus(err, http.StatusConflict) {
+		return nil
+	}
+	return err
+}
+
+// projectPath refers to the project by its URL-encoded full path
+func (g *gitlab) projectPath(repo Repository) string {
+	return "/projects/" + url.PathEscape(repo.String())
+}
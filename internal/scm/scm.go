@@ -0,0 +1,290 @@
+// Package scm creates the remote repository of a generated project on a source
+// code host and pushes the project to it.
+//
+// The host's REST API creates the repository, protects its default branch and
+// adds the labels the generated issue forms and Renovate config use. git pushes
+// the initial commit with the token passed in a header, so the token is never
+// written to the project's git config.
+package scm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrUnknownProvider is returned for a provider other than github and gitlab
+	ErrUnknownProvider = errors.New("unknown SCM provider")
+
+	// ErrMissingToken is returned when the provider's token variable is not set
+	ErrMissingToken = errors.New("missing SCM token")
+
+	// ErrInvalidRepository is returned for a repository path without an owner
+	ErrInvalidRepository = errors.New("invalid repository")
+)
+
+// DefaultBranch is the branch the initial commit is pushed to and protected
+const DefaultBranch = "main"
+
+// Label is an issue and pull request label of the repository
+type Label struct {
+	Name        string
+	Color       string // hex without "#"
+	Description string
+}
+
+// DefaultLabels are the labels the generated issue forms and Renovate config apply
+var DefaultLabels = []Label{
+	{Name: "bug", Color: "d73a4a", Description: "Something isn't working"},
+	{Name: "enhancement", Color: "a2eeef", Description: "New feature or request"},
+	{Name: "dependencies", Color: "0366d6", Description: "Dependency updates"},
+	{Name: "security", Color: "b60205", Description: "Security fixes"},
+}
+
+// Repository is a repository on a host; Owner is a user, an organization or,
+// on GitLab, a group path such as "acme/platform"
+type Repository struct {
+	Owner string
+	Name  string
+}
+
+func (r Repository) String() string {
+	return r.Owner + "/" + r.Name
+}
+
+// ParseRepository splits "owner/name" into a repository
+func ParseRepository(path string) (Repository, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 || slices.Contains(parts, "") {
+		return Repository{}, fmt.Errorf("%w: %q has no owner and name", ErrInvalidRepository, path)
+	}
+	return Repository{Owner: strings.Join(parts[:len(parts)-1], "/"), Name: parts[len(parts)-1]}, nil
+}
+
+// ModuleRepository returns the repository of a module path such as
+// github.com/acme/orders, whose first element is the host
+func ModuleRepository(module string) (Repository, error) {
+	_, path, _ := strings.Cut(module, "/")
+	repo, err := ParseRepository(path)
+	if err != nil {
+		return Repository{}, fmt.Errorf("%w: module %q has no owner and name after the host", ErrInvalidRepository, module)
+	}
+	return repo, nil
+}
+
+// Options describe the repository to create
+type Options struct {
+	Description string
+	Public      bool
+	Labels      []Label
+}
+
+// Result reports the created repository
+type Result struct {
+	URL string // web page of the repository
+	// Warnings lists the settings that could not be applied, e.g. branch
+	// protection on a plan without it; the repository is usable regardless
+	Warnings []string
+}
+
+// host is the API of a source code host
+type host interface {
+	// create creates the repository and returns its clone and web URLs
+	create(ctx context.Context, repo Repository, opts Options) (cloneURL, webURL string, err error)
+	protect(ctx context.Context, repo Repository, branch string) error
+	label(ctx context.Context, repo Repository, label Label) error
+	// pushUser is the basic auth user git pushes with the token as password
+	pushUser() string
+}
+
+// providers maps each provider to the variables its token is read from, in order
+var providers = map[string][]string{
+	"github": {"GITHUB_TOKEN", "GH_TOKEN"},
+	"gitlab": {"GITLAB_TOKEN"},
+}
+
+// ProviderNames returns the supported providers
+func ProviderNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Token returns the provider's token from the environment
+func Token(provider string) (string, error) {
+	vars, ok := providers[provider]
+	if !ok {
+		return "", fmt.Errorf("%w: %q (available: %s)", ErrUnknownProvider, provider, strings.Join(ProviderNames(), ", "))
+	}
+	for _, v := range vars {
+		if token := os.Getenv(v); token != "" {
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf("%w: set %s", ErrMissingToken, strings.Join(vars, " or "))
+}
+
+// newHost returns the API client of a provider; GITHUB_API_URL and
+// GITLAB_API_URL point it at a self-hosted instance
+func newHost(provider, token string) (host, error) {
+	switch provider {
+	case "github":
+		return &github{api: apiClient{base: envOr("GITHUB_API_URL", "https://api.github.com"), auth: func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+token)
+			r.Header.Set("Accept", "application/vnd.github+json")
+			r.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		}}}, nil
+	case "gitlab":
+		return &gitlab{api: apiClient{base: envOr("GITLAB_API_URL", "https://gitlab.com/api/v4"), auth: func(r *http.Request) {
+			r.Header.Set("PRIVATE-TOKEN", token)
+		}}}, nil
+	}
+	return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownProvider, provider, strings.Join(ProviderNames(), ", "))
+}
+
+// Bootstrap creates the repository on the provider's host, commits the
+// project in projectDir as the initial commit, pushes it and then protects
+// the default branch and adds the labels
+func Bootstrap(ctx context.Context, projectDir, provider string, repo Repository, opts Options) (*Result, error) {
+	token, err := Token(provider)
+	if err != nil {
+		return nil, err
+	}
+	h, err := newHost(provider, token)
+	if err != nil {
+		return nil, err
+	}
+
+	cloneURL, webURL, err := h.create(ctx, repo, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repository %s: %w", repo, err)
+	}
+	result := &Result{URL: webURL}
+
+	auth := base64.StdEncoding.EncodeToString([]byte(h.pushUser() + ":" + token))
+	steps := [][]string{
+		{"init", "--quiet", "--initial-branch", DefaultBranch},
+		{"add", "-A"},
+		{"commit", "--quiet", "-m", "Initial commit from go-app-gen"},
+		{"remote", "add", "origin", cloneURL},
+		{"-c", "http.extraHeader=Authorization: Basic " + auth, "push", "--quiet", "-u", "origin", DefaultBranch},
+	}
+	for _, args := range steps {
+		if err := git(ctx, projectDir, args...); err != nil {
+			return nil, fmt.Errorf("failed to push to %s: %w", repo, err)
+		}
+	}
+
+	if err := h.protect(ctx, repo, DefaultBranch); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("branch protection of %s not applied: %v", DefaultBranch, err))
+	}
+	for _, label := range opts.Labels {
+		if err := h.label(ctx, repo, label); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("label %q not created: %v", label.Name, err))
+		}
+	}
+	return result, nil
+}
+
+// git runs git in dir; the error carries its output, with the arguments
+// left out since they may hold the token
+func git(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// envOr returns the environment variable key, or fallback when it is empty
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return strings.TrimSuffix(v, "/")
+	}
+	return fallback
+}
+
+// apiClient sends JSON requests to a host's REST API
+type apiClient struct {
+	base string
+	auth func(*http.Request)
+}
+
+// apiError is a response with an unexpected status
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.Status, http.StatusText(e.Status), e.Message)
+}
+
+// do sends body as JSON and decodes the response into out, if not nil;
+// statuses of 300 and above are returned as *apiError
+func (c apiClient) do(ctx context.Context, method, path string, body, out any) error {
+	var payload io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		payload = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.auth(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var msg struct {
+			Message any `json:"message"`
+			Error   any `json:"error"`
+		}
+		_ = json.Unmarshal(content, &msg)
+		text := fmt.Sprint(msg.Message)
+		if msg.Message == nil {
+			text = fmt.Sprint(msg.Error)
+		}
+		return &apiError{Status: resp.StatusCode, Message: text}
+	}
+	if out != nil {
+		if err := json.Unmarshal(content, out); err != nil {
+			return fmt.Errorf("failed to decode response of %s %s: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+// hasStatus reports whether err is an API response with the status
+func hasStatus(err error, status int) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.Status == status
+}
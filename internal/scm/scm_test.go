@@ -0,0 +1,419 @@
+package scm
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// response is the answer of the fake host to a request
+type response struct {
+	Status int // 200 if zero
+	Body   string
+}
+
+// request is an API request the fake host received
+type request struct {
+	Method string
+	Path   string // as sent, with its escapes
+	Header http.Header
+	Body   map[string]any
+}
+
+// key is how responses refer to the request
+func (r request) key() string {
+	return r.Method + " " + r.Path
+}
+
+// fakeHost serves the responses by "METHOD path" and records the requests it
+// received; other requests are answered with 404
+func fakeHost(t *testing.T, responses map[string]response) (*httptest.Server, func() []request) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		received []request
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{Method: r.Method, Path: r.URL.EscapedPath(), Header: r.Header.Clone()}
+		if err := json.NewDecoder(r.Body).Decode(&req.Body); err != nil && r.ContentLength > 0 {
+			t.Errorf("%s: body is not JSON: %v", req.key(), err)
+		}
+		mu.Lock()
+		received = append(received, req)
+		mu.Unlock()
+
+		resp, ok := responses[req.key()]
+		if !ok {
+			resp = response{Status: http.StatusNotFound, Body: `{"message": "Not Found"}`}
+		}
+		if resp.Status != 0 {
+			w.WriteHeader(resp.Status)
+		}
+		_, _ = w.Write([]byte(resp.Body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []request {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(received)
+	}
+}
+
+// testHost returns the provider's client, sent to srv through its API URL variable
+func testHost(t *testing.T, provider string, srv *httptest.Server) host {
+	t.Helper()
+	t.Setenv(strings.ToUpper(provider)+"_API_URL", srv.URL+"/")
+	h, err := newHost(provider, "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// assertRequests checks the method and path of each request, in order
+func assertRequests(t *testing.T, got []request, want ...string) {
+	t.Helper()
+	keys := make([]string, len(got))
+	for i, r := range got {
+		keys[i] = r.key()
+	}
+	if !slices.Equal(keys, want) {
+		t.Errorf("requests =\n%s\nwant\n%s", strings.Join(keys, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// assertBody checks the fields of a JSON request body
+func assertBody(t *testing.T, r request, want map[string]any) {
+	t.Helper()
+	for k, v := range want {
+		got, _ := json.Marshal(r.Body[k])
+		expected, _ := json.Marshal(v)
+		if string(got) != string(expected) {
+			t.Errorf("%s: %s = %s, want %s", r.key(), k, got, expected)
+		}
+	}
+}
+
+func TestGitHubCreate(t *testing.T) {
+	tests := []struct {
+		name   string
+		repo   Repository
+		public bool
+		path   string // where the repository is created
+	}{
+		{name: "the authenticated user's repository", repo: Repository{Owner: "octocat", Name: "orders"}, path: "/user/repos"},
+		{name: "an organization's repository", repo: Repository{Owner: "acme", Name: "orders"}, public: true, path: "/orgs/acme/repos"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := fakeHost(t, map[string]response{
+				"GET /user":       {Body: `{"login": "octocat"}`},
+				"POST " + tt.path: {Status: http.StatusCreated, Body: `{"clone_url": "https://github.com/x/orders.git", "html_url": "https://github.com/x/orders"}`},
+			})
+			h := testHost(t, "github", srv)
+
+			cloneURL, webURL, err := h.create(t.Context(), tt.repo, Options{Description: "Orders service", Public: tt.public})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cloneURL != "https://github.com/x/orders.git" || webURL != "https://github.com/x/orders" {
+				t.Errorf("create() = %q, %q", cloneURL, webURL)
+			}
+			got := requests()
+			assertRequests(t, got, "GET /user", "POST "+tt.path)
+			assertBody(t, got[1], map[string]any{"name": "orders", "description": "Orders service", "private": !tt.public, "auto_init": false})
+			for _, r := range got {
+				if r.Header.Get("Authorization") != "Bearer s3cr3t" || r.Header.Get("X-GitHub-Api-Version") == "" {
+					t.Errorf("%s: headers = %v", r.key(), r.Header)
+				}
+			}
+		})
+	}
+}
+
+func TestGitHubProtectAndLabel(t *testing.T) {
+	srv, requests := fakeHost(t, map[string]response{
+		"PUT /repos/acme/orders/branches/main/protection": {},
+		"POST /repos/acme/orders/labels":                  {Status: http.StatusUnprocessableEntity, Body: `{"message": "Validation Failed"}`},
+	})
+	h := testHost(t, "github", srv)
+	repo := Repository{Owner: "acme", Name: "orders"}
+
+	if err := h.protect(t.Context(), repo, "main"); err != nil {
+		t.Errorf("protect() error = %v", err)
+	}
+	// An existing label is left as it is
+	if err := h.label(t.Context(), repo, DefaultLabels[0]); err != nil {
+		t.Errorf("label() error = %v", err)
+	}
+
+	got := requests()
+	assertRequests(t, got, "PUT /repos/acme/orders/branches/main/protection", "POST /repos/acme/orders/labels")
+	assertBody(t, got[0], map[string]any{
+		"required_pull_request_reviews": map[string]any{"required_approving_review_count": 1, "dismiss_stale_reviews": true},
+		"allow_force_pushes":            false,
+		"allow_deletions":               false,
+	})
+	assertBody(t, got[1], map[string]any{"name": "bug", "color": "d73a4a", "description": "Something isn't working"})
+}
+
+func TestGitLabCreate(t *testing.T) {
+	srv, requests := fakeHost(t, map[string]response{
+		"GET /namespaces/acme%2Fplatform": {Body: `{"id": 42}`},
+		"POST /projects":                  {Status: http.StatusCreated, Body: `{"http_url_to_repo": "https://gitlab.com/acme/platform/orders.git", "web_url": "https://gitlab.com/acme/platform/orders"}`},
+	})
+	h := testHost(t, "gitlab", srv)
+
+	cloneURL, webURL, err := h.create(t.Context(), Repository{Owner: "acme/platform", Name: "orders"}, Options{Description: "Orders service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cloneURL != "https://gitlab.com/acme/platform/orders.git" || webURL != "https://gitlab.com/acme/platform/orders" {
+		t.Errorf("create() = %q, %q", cloneURL, webURL)
+	}
+	got := requests()
+	assertRequests(t, got, "GET /namespaces/acme%2Fplatform", "POST /projects")
+	assertBody(t, got[1], map[string]any{
+		"name":                   "orders",
+		"path":                   "orders",
+		"namespace_id":           42,
+		"description":            "Orders service",
+		"visibility":             "private",
+		"initialize_with_readme": false,
+	})
+	for _, r := range got {
+		if r.Header.Get("PRIVATE-TOKEN") != "s3cr3t" {
+			t.Errorf("%s: PRIVATE-TOKEN = %q", r.key(), r.Header.Get("PRIVATE-TOKEN"))
+		}
+	}
+}
+
+func TestGitLabProtect(t *testing.T) {
+	const branches = "/projects/acme%2Fplatform%2Forders/protected_branches"
+	tests := []struct {
+		name string
+		// unprotect answers the removal of GitLab's default protection
+		unprotect response
+		wants     []string
+	}{
+		{name: "a protected branch", unprotect: response{Status: http.StatusNoContent}, wants: []string{"DELETE " + branches + "/main", "POST " + branches}},
+		{name: "an unprotected branch", unprotect: response{Status: http.StatusNotFound}, wants: []string{"DELETE " + branches + "/main", "POST " + branches}},
+		{name: "a failed removal", unprotect: response{Status: http.StatusForbidden, Body: `{"message": "403 Forbidden"}`}, wants: []string{"DELETE " + branches + "/main"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := fakeHost(t, map[string]response{
+				"DELETE " + branches + "/main": tt.unprotect,
+				"POST " + branches:             {Status: http.StatusCreated, Body: `{}`},
+			})
+			h := testHost(t, "gitlab", srv)
+
+			err := h.protect(t.Context(), Repository{Owner: "acme/platform", Name: "orders"}, "main")
+			if failed := tt.unprotect.Status == http.StatusForbidden; failed != hasStatus(err, http.StatusForbidden) {
+				t.Errorf("protect() error = %v", err)
+			}
+			got := requests()
+			assertRequests(t, got, tt.wants...)
+			if len(got) == 2 {
+				assertBody(t, got[1], map[string]any{"name": "main", "push_access_level": 0, "merge_access_level": 40, "allowed_to_force_push": false})
+			}
+		})
+	}
+}
+
+func TestGitLabLabel(t *testing.T) {
+	srv, requests := fakeHost(t, map[string]response{
+		"POST /projects/acme%2Forders/labels": {Status: http.StatusConflict, Body: `{"message": "Label already exists"}`},
+	})
+	h := testHost(t, "gitlab", srv)
+
+	if err := h.label(t.Context(), Repository{Owner: "acme", Name: "orders"}, DefaultLabels[0]); err != nil {
+		t.Errorf("label() error = %v", err)
+	}
+	got := requests()
+	assertRequests(t, got, "POST /projects/acme%2Forders/labels")
+	assertBody(t, got[0], map[string]any{"name": "bug", "color": "#d73a4a"})
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "a message", body: `{"message": "boom"}`, want: "500 Internal Server Error: boom"},
+		{name: "an error", body: `{"error": "insufficient_scope"}`, want: "500 Internal Server Error: insufficient_scope"},
+		{name: "validation messages", body: `{"message": {"name": ["has already been taken"]}}`, want: "500 Internal Server Error: map[name:[has already been taken]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := fakeHost(t, map[string]response{"GET /user": {Status: http.StatusInternalServerError, Body: tt.body}})
+			err := apiClient{base: srv.URL, auth: func(*http.Request) {}}.do(t.Context(), http.MethodGet, "/user", nil, nil)
+			var apiErr *apiError
+			if !errors.As(err, &apiErr) || err.Error() != tt.want {
+				t.Errorf("do() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// gitEnv sets the identity git commits with and keeps the user's git config out
+func gitEnv(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	for _, v := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(v, "go-app-gen")
+	}
+	for _, v := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(v, "go-app-gen@example.com")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+}
+
+// TestBootstrap creates a repository whose clone URL is a local bare
+// repository, on a host without branch protection
+func TestBootstrap(t *testing.T) {
+	gitEnv(t)
+	remote := filepath.Join(t.TempDir(), "orders.git")
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	created, _ := json.Marshal(map[string]string{"clone_url": remote, "html_url": "https://github.com/acme/orders"})
+	srv, requests := fakeHost(t, map[string]response{
+		"GET /user":             {Body: `{"login": "octocat"}`},
+		"POST /orgs/acme/repos": {Status: http.StatusCreated, Body: string(created)},
+		"PUT /repos/acme/orders/branches/main/protection": {Status: http.StatusForbidden, Body: `{"message": "Upgrade to GitHub Pro"}`},
+		"POST /repos/acme/orders/labels":                  {Status: http.StatusCreated, Body: `{}`},
+	})
+	t.Setenv("GITHUB_API_URL", srv.URL)
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "s3cr3t")
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module github.com/acme/orders\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	labels := DefaultLabels[:2]
+	result, err := Bootstrap(t.Context(), projectDir, "github", Repository{Owner: "acme", Name: "orders"}, Options{Labels: labels})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.URL != "https://github.com/acme/orders" {
+		t.Errorf("URL = %q", result.URL)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "branch protection of main not applied: 403 Forbidden: Upgrade to GitHub Pro") {
+		t.Errorf("Warnings = %q, want the branch protection", result.Warnings)
+	}
+	assertRequests(t, requests(),
+		"GET /user",
+		"POST /orgs/acme/repos",
+		"PUT /repos/acme/orders/branches/main/protection",
+		"POST /repos/acme/orders/labels",
+		"POST /repos/acme/orders/labels",
+	)
+
+	pushed, err := exec.Command("git", "-C", remote, "log", "--format=%s", DefaultBranch).Output()
+	if err != nil || strings.TrimSpace(string(pushed)) != "Initial commit from go-app-gen" {
+		t.Errorf("pushed %s = %q, %v", DefaultBranch, pushed, err)
+	}
+	config, err := os.ReadFile(filepath.Join(projectDir, ".git", "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(config), "s3cr3t") || strings.Contains(string(config), "Authorization") {
+		t.Errorf("the token was written to the project's git config:\n%s", config)
+	}
+}
+
+func TestBootstrapErrors(t *testing.T) {
+	srv, _ := fakeHost(t, map[string]response{
+		"GET /user":             {Body: `{"login": "octocat"}`},
+		"POST /orgs/acme/repos": {Status: http.StatusUnprocessableEntity, Body: `{"message": "name already exists on this account"}`},
+	})
+	t.Setenv("GITHUB_API_URL", srv.URL)
+	t.Setenv("GITLAB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "s3cr3t")
+	repo := Repository{Owner: "acme", Name: "orders"}
+
+	projectDir := t.TempDir()
+	if _, err := Bootstrap(t.Context(), projectDir, "bitbucket", repo, Options{}); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("Bootstrap(bitbucket) error = %v, want ErrUnknownProvider", err)
+	}
+	if _, err := Bootstrap(t.Context(), projectDir, "gitlab", repo, Options{}); !errors.Is(err, ErrMissingToken) {
+		t.Errorf("Bootstrap(gitlab) error = %v, want ErrMissingToken", err)
+	}
+	_, err := Bootstrap(t.Context(), projectDir, "github", repo, Options{})
+	if !hasStatus(err, http.StatusUnprocessableEntity) || !strings.Contains(err.Error(), "failed to create repository acme/orders") {
+		t.Errorf("Bootstrap(github) error = %v, want the creation to fail", err)
+	}
+	// Nothing is committed for a repository that was not created
+	if _, err := os.Stat(filepath.Join(projectDir, ".git")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Bootstrap() initialized git after failing: %v", err)
+	}
+}
+
+func TestToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "from-gh")
+	if token, err := Token("github"); err != nil || token != "from-gh" {
+		t.Errorf("Token(github) = %q, %v, want GH_TOKEN", token, err)
+	}
+	t.Setenv("GITHUB_TOKEN", "from-github")
+	if token, err := Token("github"); err != nil || token != "from-github" {
+		t.Errorf("Token(github) = %q, %v, want GITHUB_TOKEN first", token, err)
+	}
+}
+
+var parseRepositoryTests = []struct {
+	path string
+	want Repository
+	err  bool
+}{
+	{path: "acme/orders", want: Repository{Owner: "acme", Name: "orders"}},
+	{path: "/acme/platform/orders/", want: Repository{Owner: "acme/platform", Name: "orders"}},
+	{path: "orders", err: true},
+	{path: "acme//orders", err: true},
+	{path: "", err: true},
+}
+
+func TestParseRepository(t *testing.T) {
+	for _, tt := range parseRepositoryTests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParseRepository(tt.path)
+			if tt.err {
+				if !errors.Is(err, ErrInvalidRepository) {
+					t.Errorf("ParseRepository(%q) = %v, %v, want ErrInvalidRepository", tt.path, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseRepository(%q) = %v, %v, want %v", tt.path, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestModuleRepository(t *testing.T) {
+	if got, err := ModuleRepository("gitlab.com/acme/platform/orders"); err != nil || got != (Repository{Owner: "acme/platform", Name: "orders"}) {
+		t.Errorf("ModuleRepository() = %v, %v", got, err)
+	}
+	if _, err := ModuleRepository("orders"); !errors.Is(err, ErrInvalidRepository) {
+		t.Errorf("ModuleRepository(orders) error = %v, want ErrInvalidRepository", err)
+	}
+}
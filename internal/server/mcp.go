@@ -0,0 +1,382 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+// mcpProtocolVersion is the Model Context Protocol revision the MCP server speaks
+const mcpProtocolVersion = "2025-06-18"
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+// maxMessageBytes bounds the size of a JSON-RPC message, one spec and its envelope
+const maxMessageBytes = maxSpecBytes + 64<<10
+
+// MCP serves the generator over the Model Context Protocol, so editors and
+// assistants can list the options, validate and explain specs, generate
+// projects and add domains to them. Messages are JSON-RPC 2.0, one per line.
+//
+// Unlike the HTTP API, MCP runs as a process of the client's user: projects
+// are generated into, and domains added to, directories of the local disk.
+// Specs are still limited to the built-in templates.
+type MCP struct {
+	version string
+}
+
+// NewMCP returns an MCP server recording version in the generated manifests
+func NewMCP(version string) *MCP {
+	return &MCP{version: version}
+}
+
+// rpcRequest is a JSON-RPC request, or a notification when it has no ID
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve answers the messages read from r on w until r ends
+func (m *MCP) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxMessageBytes)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		resp := m.handle(line)
+		if resp == nil {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handle answers one message, returning nil for notifications
+func (m *MCP) handle(message []byte) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(message, &req); err != nil {
+		return &rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: err.Error()}}
+	}
+	if req.ID == nil {
+		// Notifications, such as notifications/initialized, need no answer
+		return nil
+	}
+	resp := &rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{Code: rpcInvalidRequest, Message: "not a JSON-RPC 2.0 request"}
+		return resp
+	}
+
+	switch req.Method {
+	case "initialize":
+		resp.Result = map[string]any{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": "go-app-gen", "version": m.version},
+		}
+	case "ping":
+		resp.Result = map[string]any{}
+	case "tools/list":
+		resp.Result = map[string]any{"tools": mcpTools}
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			resp.Error = &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+			return resp
+		}
+		call, ok := m.tools()[params.Name]
+		if !ok {
+			resp.Error = &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("unknown tool %q", params.Name)}
+			return resp
+		}
+		if len(params.Arguments) == 0 {
+			params.Arguments = json.RawMessage("{}")
+		}
+		// A failing tool is reported in its result, for the model to read
+		text, err := call(params.Arguments)
+		if err != nil {
+			resp.Result = toolResult(err.Error(), true)
+		} else {
+			resp.Result = toolResult(text, false)
+		}
+	default:
+		resp.Error = &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)}
+	}
+	return resp
+}
+
+func toolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// mcpTool describes a tool to tools/list
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// specProperty is the schema of a spec argument
+var specProperty = map[string]any{
+	"type":        []string{"string", "object"},
+	"description": "Project spec, the YAML or JSON of a create --spec file, or the spec as an object",
+}
+
+var mcpTools = []mcpTool{
+	{
+		Name:        "list_options",
+		Description: "List the archetypes, features, databases, routers, loggers, CI providers, deploy targets and field types a project spec can choose",
+		InputSchema: objectSchema(nil),
+	},
+	{
+		Name:        "validate_spec",
+		Description: "Check that a project spec can be generated, without generating it",
+		InputSchema: objectSchema(map[string]any{"spec": specProperty}, "spec"),
+	},
+	{
+		Name:        "explain_spec",
+		Description: "List the files, HTTP endpoints and make targets a project spec would generate",
+		InputSchema: objectSchema(map[string]any{"spec": specProperty}, "spec"),
+	},
+	{
+		Name:        "generate",
+		Description: "Generate a project from a spec into a directory named after it, running the post-generation tasks like go-app-gen create",
+		InputSchema: objectSchema(map[string]any{
+			"spec":       specProperty,
+			"output_dir": map[string]any{"type": "string", "description": "Existing directory to create the project directory in"},
+		}, "spec", "output_dir"),
+	},
+	{
+		Name:        "add_domain",
+		Description: "Add a domain to a generated project like go-app-gen add domain, merging its wiring into the shared files",
+		InputSchema: objectSchema(map[string]any{
+			"project_dir": map[string]any{"type": "string", "description": "Directory of the generated project"},
+			"domain":      map[string]any{"type": "string", "description": "Domain to add, such as invoice or billing.payment"},
+			"fields":      map[string]any{"type": "string", "description": "Fields of the domain's entity as name:type[?] pairs, default " + generator.DefaultFields},
+		}, "project_dir", "domain"),
+	},
+}
+
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// tools maps the tool names to their calls, which return the text of the result
+func (m *MCP) tools() map[string]func(args json.RawMessage) (string, error) {
+	return map[string]func(args json.RawMessage) (string, error){
+		"list_options":  m.listOptions,
+		"validate_spec": m.validateSpec,
+		"explain_spec":  m.explainSpec,
+		"generate":      m.generate,
+		"add_domain":    m.addDomain,
+	}
+}
+
+func (m *MCP) listOptions(json.RawMessage) (string, error) {
+	return marshalText(listOptions(m.version))
+}
+
+func (m *MCP) validateSpec(args json.RawMessage) (string, error) {
+	config, err := m.specConfig(args)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Valid spec: project %s (%s) with domains %s and features %s",
+		config.AppName, config.ModuleName,
+		strings.Join(append([]string{config.Domain}, config.Domains...), ", "),
+		listOrNone(config.Features)), nil
+}
+
+func (m *MCP) explainSpec(args json.RawMessage) (string, error) {
+	config, err := m.specConfig(args)
+	if err != nil {
+		return "", err
+	}
+	explanation, err := generator.Explain(config)
+	if err != nil {
+		return "", err
+	}
+
+	out := struct {
+		Files       []string `json:"files"`
+		Endpoints   []string `json:"endpoints"`
+		MakeTargets []string `json:"make_targets"`
+	}{}
+	for _, f := range explanation.Files {
+		out.Files = append(out.Files, f.Path)
+	}
+	for _, e := range explanation.Endpoints {
+		out.Endpoints = append(out.Endpoints, e.Method+" "+e.Path)
+	}
+	for _, t := range explanation.MakeTargets {
+		out.MakeTargets = append(out.MakeTargets, t.Target+": "+t.Description)
+	}
+	return marshalText(out)
+}
+
+func (m *MCP) generate(args json.RawMessage) (string, error) {
+	var params struct {
+		OutputDir string `json:"output_dir"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	if params.OutputDir == "" {
+		return "", fmt.Errorf("%w: output_dir is required", ErrInvalidSpec)
+	}
+	config, err := m.specConfig(args)
+	if err != nil {
+		return "", err
+	}
+
+	outputDir, err := filepath.Abs(params.OutputDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+	if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("output directory does not exist: %s", outputDir)
+	}
+	projectDir := filepath.Join(outputDir, config.AppName)
+	if entries, err := os.ReadDir(projectDir); err == nil && len(entries) > 0 {
+		return "", fmt.Errorf("directory %s already exists and contains files", projectDir)
+	}
+
+	gen := generator.New(outputDir)
+	if err := gen.Generate(config); err != nil {
+		return "", fmt.Errorf("failed to generate project: %w", err)
+	}
+	return fmt.Sprintf("Created project %s in %s: %d files, module %s",
+		config.AppName, projectDir, len(gen.Report().Files), config.ModuleName), nil
+}
+
+func (m *MCP) addDomain(args json.RawMessage) (string, error) {
+	var params struct {
+		ProjectDir string `json:"project_dir"`
+		Domain     string `json:"domain"`
+		Fields     string `json:"fields"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", err
+	}
+	if params.ProjectDir == "" || params.Domain == "" {
+		return "", errors.New("project_dir and domain are required")
+	}
+	projectDir, err := filepath.Abs(params.ProjectDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+
+	var fields []generator.FieldSpec
+	if params.Fields != "" {
+		if fields, err = generator.ParseFields(params.Fields); err != nil {
+			return "", fmt.Errorf("failed to add domain: %w", err)
+		}
+	}
+	result, err := generator.New(filepath.Dir(projectDir)).AddDomain(projectDir, params.Domain, fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to add domain: %w", err)
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Added domain %s: %d files created, %d merged", result.Domain, len(result.Created), len(result.Merged))
+	for _, p := range result.Merged {
+		fmt.Fprintf(&text, "\nmerged %s", p)
+	}
+	for _, p := range result.Conflicts {
+		fmt.Fprintf(&text, "\nconflict in %s: resolve the <<<<<<< markers by hand", p)
+	}
+	return text.String(), nil
+}
+
+// specConfig reads the spec argument, the text of a spec file or the spec as
+// an object, into the configuration of its project
+func (m *MCP) specConfig(args json.RawMessage) (*generator.ProjectConfig, error) {
+	var params struct {
+		Spec json.RawMessage `json:"spec"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	content := []byte(params.Spec)
+	if len(content) == 0 || string(content) == "null" {
+		return nil, fmt.Errorf("%w: spec is required", ErrInvalidSpec)
+	}
+	if content[0] == '"' {
+		var text string
+		if err := json.Unmarshal(content, &text); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+		}
+		content = []byte(text)
+	}
+
+	spec, err := generator.ParseSpec(content, "spec")
+	if err != nil {
+		return nil, err
+	}
+	return projectConfig(spec, m.version)
+}
+
+// marshalText formats v as indented JSON for a tool result
+func marshalText(v any) (string, error) {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func listOrNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,262 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// serve sends the messages to a new MCP server and returns its responses
+func serve(t *testing.T, messages ...string) []rpcResponse {
+	t.Helper()
+	var out bytes.Buffer
+	if err := NewMCP("v1.2.3").Serve(strings.NewReader(strings.Join(messages, "\n")+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	var responses []rpcResponse
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var resp rpcResponse
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+// callTool calls a tool with the arguments and returns the text of its result
+// and whether it is an error
+func callTool(t *testing.T, name string, arguments any) (string, bool) {
+	t.Helper()
+	params, err := json.Marshal(map[string]any{"name": name, "arguments": arguments})
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses := serve(t, fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": %s}`, params))
+	if len(responses) != 1 || responses[0].Error != nil {
+		t.Fatalf("tools/call %s = %+v", name, responses)
+	}
+	var result struct {
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	encoded, _ := json.Marshal(responses[0].Result)
+	if err := json.Unmarshal(encoded, &result); err != nil || len(result.Content) != 1 {
+		t.Fatalf("tools/call %s result = %s", name, encoded)
+	}
+	return result.Content[0].Text, result.IsError
+}
+
+func TestMCPProtocol(t *testing.T) {
+	responses := serve(t,
+		`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-06-18"}}`,
+		`{"jsonrpc": "2.0", "method": "notifications/initialized"}`,
+		``,
+		`{"jsonrpc": "2.0", "id": 2, "method": "ping"}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "tools/list"}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "resources/list"}`,
+		`{"jsonrpc": "1.0", "id": 5, "method": "ping"}`,
+		`{"jsonrpc": "2.0", "id": 6, "method": "tools/call", "params": {"name": "rm"}}`,
+		`{"jsonrpc": "2.0", "id": 7, "method": "tools/call", "params": "generate"}`,
+		`{not json`,
+	)
+
+	want := []struct {
+		id   string
+		code int // of the error, 0 for a result
+	}{
+		{id: "1"}, {id: "2"}, {id: "3"},
+		{id: "4", code: rpcMethodNotFound},
+		{id: "5", code: rpcInvalidRequest},
+		{id: "6", code: rpcInvalidParams},
+		{id: "7", code: rpcInvalidParams},
+		{id: "null", code: rpcParseError},
+	}
+	if len(responses) != len(want) {
+		t.Fatalf("Serve() = %d responses, want %d: %+v", len(responses), len(want), responses)
+	}
+	for i, w := range want {
+		resp := responses[i]
+		code := 0
+		if resp.Error != nil {
+			code = resp.Error.Code
+		}
+		if string(resp.ID) != w.id || code != w.code {
+			t.Errorf("response %d = id %s, error %+v, want id %s, code %d", i, resp.ID, resp.Error, w.id, w.code)
+		}
+	}
+
+	initialized, _ := json.Marshal(responses[0].Result)
+	if !strings.Contains(string(initialized), `"protocolVersion":"2025-06-18"`) || !strings.Contains(string(initialized), `"version":"v1.2.3"`) {
+		t.Errorf("initialize = %s", initialized)
+	}
+	tools, _ := json.Marshal(responses[2].Result)
+	for name := range NewMCP("").tools() {
+		if !strings.Contains(string(tools), `"name":"`+name+`"`) {
+			t.Errorf("tools/list has no %s", name)
+		}
+	}
+}
+
+func TestMCPValidateSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    any
+		isError bool
+		wants   string
+	}{
+		{name: "a spec file", spec: shopSpec, wants: "Valid spec: project shop (example.com/shop) with domains product, billing.invoice and features metrics"},
+		{name: "a spec object", spec: map[string]any{"name": "shop", "domains": []string{"order"}}, wants: "Valid spec: project shop (github.com/user/shop) with domains order and features none"},
+		{name: "no spec", isError: true, wants: "spec is required"},
+		{name: "a name climbing out", spec: "name: ../shop\n", isError: true, wants: "must be a plain directory name"},
+		{name: "an invalid domain", spec: map[string]any{"name": "shop", "domains": []string{"Product!"}}, isError: true, wants: "invalid domain"},
+		{name: "an unknown feature", spec: "name: shop\nfeatures: [teleport]\n", isError: true, wants: "teleport"},
+		{name: "a template of the server", spec: "name: shop\ntemplate: https://example.com/templates.git\n", isError: true, wants: "name files or repositories of the server"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]any{}
+			if tt.spec != nil {
+				args["spec"] = tt.spec
+			}
+			text, isError := callTool(t, "validate_spec", args)
+			if isError != tt.isError || !strings.Contains(text, tt.wants) {
+				t.Errorf("validate_spec = %q, error %v, want %q", text, isError, tt.wants)
+			}
+		})
+	}
+}
+
+func TestMCPExplainSpec(t *testing.T) {
+	text, isError := callTool(t, "explain_spec", map[string]any{"spec": shopSpec})
+	if isError {
+		t.Fatal(text)
+	}
+	var explanation struct {
+		Files     []string `json:"files"`
+		Endpoints []string `json:"endpoints"`
+	}
+	if err := json.Unmarshal([]byte(text), &explanation); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(explanation.Files, "\n"), "internal/billing/service/invoice.go") {
+		t.Errorf("files = %v", explanation.Files)
+	}
+	if !strings.Contains(strings.Join(explanation.Endpoints, "\n"), "POST /api/v1/billing/invoices") {
+		t.Errorf("endpoints = %v", explanation.Endpoints)
+	}
+}
+
+func TestMCPGenerateRejects(t *testing.T) {
+	outputDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(outputDir, "taken", "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		args  map[string]any
+		wants string
+	}{
+		{name: "no output directory", args: map[string]any{"spec": shopSpec}, wants: "output_dir is required"},
+		{name: "a missing output directory", args: map[string]any{"spec": shopSpec, "output_dir": filepath.Join(outputDir, "missing")}, wants: "output directory does not exist"},
+		{name: "a used project directory", args: map[string]any{"spec": "name: taken\n", "output_dir": outputDir}, wants: "already exists and contains files"},
+		{name: "a name climbing out", args: map[string]any{"spec": "name: ../shop\n", "output_dir": outputDir}, wants: "must be a plain directory name"},
+		{name: "an invalid domain", args: map[string]any{"spec": "name: shop\ndomains: [a.b.c]\n", "output_dir": outputDir}, wants: "may only be nested one level"},
+		{name: "an unknown feature", args: map[string]any{"spec": "name: shop\nfeatures: [teleport]\n", "output_dir": outputDir}, wants: "teleport"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isError := callTool(t, "generate", tt.args)
+			if !isError || !strings.Contains(text, tt.wants) {
+				t.Errorf("generate = %q, error %v, want %q", text, isError, tt.wants)
+			}
+		})
+	}
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("generate wrote %d entries into the output directory, want only the existing one", len(entries)-1)
+	}
+}
+
+func TestMCPAddDomainRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  map[string]any
+		wants string
+	}{
+		{name: "no domain", args: map[string]any{"project_dir": t.TempDir()}, wants: "project_dir and domain are required"},
+		{name: "not a project", args: map[string]any{"project_dir": t.TempDir(), "domain": "invoice"}, wants: "failed to add domain"},
+		{name: "invalid fields", args: map[string]any{"project_dir": t.TempDir(), "domain": "invoice", "fields": "total:blob"}, wants: "blob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isError := callTool(t, "add_domain", tt.args)
+			if !isError || !strings.Contains(text, tt.wants) {
+				t.Errorf("add_domain = %q, error %v, want %q", text, isError, tt.wants)
+			}
+		})
+	}
+}
+
+// fakeGo puts a go on PATH that initializes modules and succeeds at everything
+// else, so post-processing needs neither the network nor the dependencies
+func fakeGo(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake go is a shell script")
+	}
+	bin := t.TempDir()
+	script := "#!/bin/sh\nif [ \"$1 $2\" = \"mod init\" ]; then echo \"module $3\" > go.mod; fi\n"
+	if err := os.WriteFile(filepath.Join(bin, "go"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// TestMCPGenerateAndAddDomain generates a project, running its post-generation
+// tasks, and adds a domain to it
+func TestMCPGenerateAndAddDomain(t *testing.T) {
+	fakeGo(t)
+	// The generator prints its progress, which is not part of the results
+	stdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	defer func() { os.Stdout = stdout }()
+
+	outputDir := t.TempDir()
+	text, isError := callTool(t, "generate", map[string]any{"spec": shopSpec, "output_dir": outputDir})
+	if isError || !strings.HasPrefix(text, "Created project shop in "+filepath.Join(outputDir, "shop")) {
+		t.Fatalf("generate = %q, error %v", text, isError)
+	}
+	projectDir := filepath.Join(outputDir, "shop")
+	for _, rel := range []string{"go.mod", "internal/service/product.go", "internal/billing/service/invoice.go"} {
+		if _, err := os.Stat(filepath.Join(projectDir, filepath.FromSlash(rel))); err != nil {
+			t.Errorf("generate did not write %s: %v", rel, err)
+		}
+	}
+
+	text, isError = callTool(t, "add_domain", map[string]any{"project_dir": projectDir, "domain": "customer", "fields": "email:string"})
+	if isError || !strings.HasPrefix(text, "Added domain customer:") || strings.Contains(text, "conflict") {
+		t.Fatalf("add_domain = %q, error %v", text, isError)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "internal", "service", "customer.go")); err != nil {
+		t.Errorf("add_domain did not write the service: %v", err)
+	}
+	text, isError = callTool(t, "add_domain", map[string]any{"project_dir": projectDir, "domain": "product"})
+	if !isError || !strings.Contains(text, "product") {
+		t.Errorf("add_domain of an existing domain = %q, error %v", text, isError)
+	}
+}
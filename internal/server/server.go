@@ -0,0 +1,300 @@
+// Package server serves the go-app-gen web UI and its JSON API, and the
+// generator over the Model Context Protocol.
+//
+// A client posts a project spec, the YAML or JSON of a create --spec file, and
+// gets the generated project back as a zip archive, streamed as it is written.
+// Specs may only use the built-in templates: keys naming files or repositories
+// of the server are rejected.
+package server
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/nhalm/go-app-gen/internal/generator"
+)
+
+// ErrInvalidSpec is returned for a posted spec the server cannot generate
+var ErrInvalidSpec = errors.New("invalid spec")
+
+// maxSpecBytes bounds the size of a posted spec
+const maxSpecBytes = 1 << 20
+
+//go:embed index.html
+var indexHTML []byte
+
+// Options configure the server
+type Options struct {
+	// PostProcess runs the post-generation tasks (go mod tidy, sqlc, ...)
+	// before archiving; otherwise the archive's manifest leaves them to
+	// go-app-gen resume
+	PostProcess bool
+	// Version is the go-app-gen version recorded in the generated manifests
+	Version string
+	// Log receives a line per generated project and failure, discarded if nil
+	Log io.Writer
+}
+
+// Server handles the web UI and API requests
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+}
+
+// New returns a server with the routes of the web UI and API
+func New(opts Options) *Server {
+	if opts.Log == nil {
+		opts.Log = io.Discard
+	}
+	s := &Server{opts: opts, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.HandleFunc("GET /api/options", s.handleOptions)
+	s.mux.HandleFunc("POST /api/generate", s.handleGenerate)
+	return s
+}
+
+// ServeHTTP dispatches a request to its route
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(indexHTML)
+}
+
+// option is a choice of the web UI's form
+type option struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Experimental options may change between releases
+	Experimental bool `json:"experimental,omitempty"`
+}
+
+// options are the choices a spec can make
+type options struct {
+	Version       string   `json:"version"`
+	Features      []option `json:"features"`
+	Databases     []option `json:"databases"`
+	Routers       []option `json:"routers"`
+	Loggers       []option `json:"loggers"`
+	CIProviders   []option `json:"ci_providers"`
+	Archetypes    []option `json:"archetypes"`
+	DeployTargets []option `json:"deploy_targets"`
+	FieldTypes    []string `json:"field_types"`
+	DefaultFields string   `json:"default_fields"`
+}
+
+func (s *Server) handleOptions(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, listOptions(s.opts.Version))
+}
+
+// listOptions lists the features, databases, deploy targets and field types
+func listOptions(version string) options {
+	opts := options{
+		Version:       version,
+		FieldTypes:    generator.FieldTypeNames(),
+		DefaultFields: generator.DefaultFields,
+	}
+	for _, f := range generator.Features {
+		opts.Features = append(opts.Features, option{Name: f.Name, Description: f.Description})
+	}
+	for _, d := range generator.Databases {
+		opts.Databases = append(opts.Databases, option{Name: d.Name, Description: d.Description})
+	}
+	for _, r := range generator.Routers {
+		opts.Routers = append(opts.Routers, option{Name: r.Name, Description: r.Description})
+	}
+	for _, l := range generator.Loggers {
+		opts.Loggers = append(opts.Loggers, option{Name: l.Name, Description: l.Description})
+	}
+	for _, c := range generator.CIProviders {
+		opts.CIProviders = append(opts.CIProviders, option{Name: c.Name, Description: c.Description})
+	}
+	for _, a := range generator.Archetypes {
+		opts.Archetypes = append(opts.Archetypes, option{Name: a.Name, Description: a.Description})
+	}
+	for _, t := range generator.DeployTargets {
+		opts.DeployTargets = append(opts.DeployTargets, option{Name: t.Name, Description: t.Description, Experimental: t.Experimental})
+	}
+	return opts
+}
+
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSpecBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("failed to read spec: %w", err))
+		return
+	}
+	spec, err := generator.ParseSpec(content, "spec")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	config, err := projectConfig(spec, s.opts.Version)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// The headers only go out with the first byte of the archive, so a project
+	// that fails to render still gets an error response
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", config.AppName+".zip"))
+	out := &trackingWriter{w: w}
+	if err := generator.New("").Archive(config, s.opts.PostProcess, out); err != nil {
+		fmt.Fprintf(s.opts.Log, "❌ %s: %v\n", config.AppName, err)
+		if out.written {
+			return
+		}
+		w.Header().Del("Content-Disposition")
+		status := http.StatusInternalServerError
+		if errors.Is(err, generator.ErrUnsafePath) || errors.Is(err, generator.ErrSecretsFound) {
+			status = http.StatusBadRequest
+		}
+		writeError(w, status, err)
+		return
+	}
+	fmt.Fprintf(s.opts.Log, "📦 %s: generated with features %v\n", config.AppName, config.Features)
+}
+
+// projectConfig turns a posted spec into the configuration of a project,
+// filling in the settings it leaves out like create does
+func projectConfig(spec *generator.Spec, version string) (*generator.ProjectConfig, error) {
+	if spec.Template != "" || len(spec.TemplateDirs) > 0 || len(spec.TemplateKeys) > 0 || len(spec.Messages) > 0 {
+		return nil, fmt.Errorf("%w: template, template_dirs, template_keys and messages name files or repositories of the server", ErrInvalidSpec)
+	}
+	if spec.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidSpec)
+	}
+	if err := generator.ValidateAppName(spec.Name); err != nil {
+		return nil, err
+	}
+
+	config := &generator.ProjectConfig{
+		AppName:      spec.Name,
+		ModuleName:   spec.Module,
+		Description:  spec.Description,
+		Author:       spec.Author,
+		Features:     spec.Features,
+		DeployTarget: spec.DeployTarget,
+		Database:     spec.Database,
+		Router:       spec.Router,
+		Logger:       spec.Logger,
+		CI:           spec.CI,
+		Archetype:    spec.Archetype,
+		DomainPlural: spec.DomainPlural,
+		DomainTitle:  spec.DomainTitle,
+		MakeTargets:  spec.Makefile.Targets,
+		Secrets:      spec.Guardrails.Secrets,
+		Header:       spec.Header,
+		Lang:         spec.Lang,
+
+		GeneratorVersion: version,
+	}
+	if config.ModuleName == "" {
+		config.ModuleName = "github.com/user/" + config.AppName
+	}
+	domains := spec.Domains
+	if len(domains) == 0 {
+		domains = []string{"item"}
+	}
+	config.Domain, config.Domains = domains[0], domains[1:]
+	if config.Description == "" {
+		config.Description = fmt.Sprintf("A %s management API", config.Domain)
+	}
+	if config.Author == "" {
+		config.Author = "Developer"
+	}
+
+	if err := generator.ValidateDomains(domains, config.DomainPlural); err != nil {
+		return nil, err
+	}
+	if config.DomainTitle != "" {
+		if err := generator.ValidateDomainTitle(config.DomainTitle); err != nil {
+			return nil, err
+		}
+	}
+	var fields []string
+	for _, domain := range slices.Sorted(maps.Keys(spec.Fields)) {
+		fields = append(fields, domain+"="+spec.Fields[domain])
+	}
+	var err error
+	if config.Fields, err = generator.ParseDomainFields(fields, domains); err != nil {
+		return nil, err
+	}
+
+	layers, err := generator.LoadLayers(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := layers.ValidateFeatures(config.Features); err != nil {
+		return nil, err
+	}
+	if _, err := generator.LoadMessages(config.Lang, layers, nil); err != nil {
+		return nil, err
+	}
+	if err := generator.ValidateSecretsPolicy(config.Secrets); err != nil {
+		return nil, err
+	}
+	if err := generator.ValidateDatabase(config.Database); err != nil {
+		return nil, err
+	}
+	if err := generator.ValidateTables(domains, config.DomainPlural, config.Database); err != nil {
+		return nil, err
+	}
+	if err := generator.ValidateFeatureDatabases(config.Features, config.Database); err != nil {
+		return nil, err
+	}
+	if err := generator.ValidateRouter(config.Router); err != nil {
+		return nil, err
+	}
+	if err := generator.ValidateLogger(config.Logger); err != nil {
+		return nil, err
+	}
+	if err := generator.ValidateCIProvider(config.CI); err != nil {
+		return nil, err
+	}
+	if err := generator.ValidateArchetype(config.Archetype); err != nil {
+		return nil, err
+	}
+	if err := generator.ValidateDeployTarget(config.DeployTarget); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// trackingWriter records whether anything was written to the response
+type trackingWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	t.written = true
+	return t.w.Write(p)
+}
+
+// writeJSON writes v as the JSON body of a response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body.Bytes())
+}
+
+// writeError writes err as a JSON error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// shopSpec is a spec of a project with a namespaced domain and a feature
+const shopSpec = `name: shop
+module: example.com/shop
+domains: [product, billing.invoice]
+fields:
+  product: name:string,price:decimal
+features: [metrics]
+`
+
+// post sends a spec to the generate route of a new server
+func post(t *testing.T, spec string) *http.Response {
+	t.Helper()
+	srv := httptest.NewServer(New(Options{Version: "v1.2.3"}))
+	t.Cleanup(srv.Close)
+	resp, err := http.Post(srv.URL+"/api/generate", "application/yaml", strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestGenerateReturnsZip(t *testing.T) {
+	resp := post(t, shopSpec)
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, content)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/zip" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename="shop.zip"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+	for _, name := range []string{
+		"shop/.go-app-gen.yaml",
+		"shop/cmd/serve.go",
+		"shop/internal/service/product.go",
+		"shop/internal/billing/service/invoice.go",
+		"shop/internal/metrics/metrics.go",
+	} {
+		if files[name] == nil {
+			t.Errorf("the archive has no %s", name)
+		}
+	}
+	// The manifest leaves the post-generation tasks to go-app-gen resume
+	if f := files["shop/.go-app-gen.yaml"]; f != nil {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		if manifest, _ := io.ReadAll(r); !strings.Contains(string(manifest), "module: example.com/shop\n") {
+			t.Errorf("the manifest has no module:\n%s", manifest)
+		}
+	}
+}
+
+var generateRejectTests = []struct {
+	name   string
+	spec   string
+	status int
+	wants  string
+}{
+	{name: "malformed yaml", spec: "name: [shop\n", status: http.StatusBadRequest, wants: "failed to parse spec file"},
+	{name: "an unknown key", spec: "name: shop\nfeature: [metrics]\n", status: http.StatusBadRequest, wants: "field feature not found"},
+	{name: "no name", spec: "domains: [product]\n", status: http.StatusBadRequest, wants: "name is required"},
+	{name: "a name climbing out", spec: "name: ../shop\n", status: http.StatusBadRequest, wants: "must be a plain directory name"},
+	{name: "a nested name", spec: "name: apps/shop\n", status: http.StatusBadRequest, wants: "must be a plain directory name"},
+	{name: "an invalid domain", spec: "name: shop\ndomains: [Product!]\n", status: http.StatusBadRequest, wants: "invalid domain"},
+	{name: "a domain nested twice", spec: "name: shop\ndomains: [a.b.c]\n", status: http.StatusBadRequest, wants: "may only be nested one level"},
+	{name: "invalid fields", spec: "name: shop\nfields:\n  item: name:blob\n", status: http.StatusBadRequest, wants: "blob"},
+	{name: "an unknown feature", spec: "name: shop\nfeatures: [teleport]\n", status: http.StatusBadRequest, wants: "teleport"},
+	{name: "an unknown database", spec: "name: shop\ndatabase: oracle\n", status: http.StatusBadRequest, wants: "oracle"},
+	{name: "a template of the server", spec: "name: shop\ntemplate_dirs: [/etc]\n", status: http.StatusBadRequest, wants: "name files or repositories of the server"},
+	{name: "a spec too large", spec: "name: shop\ndescription: " + strings.Repeat("x", maxSpecBytes) + "\n", status: http.StatusRequestEntityTooLarge, wants: "failed to read spec"},
+}
+
+func TestGenerateRejects(t *testing.T) {
+	for _, tt := range generateRejectTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := post(t, tt.spec)
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := resp.Header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want an error response", got)
+			}
+			if resp.Header.Get("Content-Disposition") != "" {
+				t.Error("the error response is sent as an attachment")
+			}
+			var body struct {
+				Error string `json:"error"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(body.Error, tt.wants) {
+				t.Errorf("error = %q, want it to mention %q", body.Error, tt.wants)
+			}
+		})
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	srv := httptest.NewServer(New(Options{Version: "v1.2.3"}))
+	defer srv.Close()
+
+	tests := []struct {
+		method, path string
+		status       int
+		contentType  string
+	}{
+		{method: http.MethodGet, path: "/", status: http.StatusOK, contentType: "text/html; charset=utf-8"},
+		{method: http.MethodGet, path: "/api/options", status: http.StatusOK, contentType: "application/json"},
+		{method: http.MethodGet, path: "/api/generate", status: http.StatusMethodNotAllowed},
+		{method: http.MethodGet, path: "/index.php", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.contentType != "" && resp.Header.Get("Content-Type") != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", resp.Header.Get("Content-Type"), tt.contentType)
+			}
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New(Options{Version: "v1.2.3"}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/options", nil))
+
+	var got options
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != "v1.2.3" || got.DefaultFields == "" || len(got.FieldTypes) == 0 {
+		t.Errorf("options = %+v", got)
+	}
+	names := func(opts []option) []string {
+		var names []string
+		for _, o := range opts {
+			names = append(names, o.Name)
+		}
+		return names
+	}
+	for _, want := range []struct {
+		list []option
+		name string
+	}{
+		{got.Features, "metrics"},
+		{got.Databases, "mysql"},
+		{got.Routers, "chi"},
+		{got.Archetypes, "gateway"},
+	} {
+		if !slices.Contains(names(want.list), want.name) {
+			t.Errorf("options have no %s: %v", want.name, names(want.list))
+		}
+	}
+}
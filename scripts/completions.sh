@@ -0,0 +1,11 @@
+#!/bin/bash
+
+# Writes the bash, zsh and fish completions of go-app-gen to build/completions,
+# for the release archives and packages (run by goreleaser before building)
+
+set -e
+
+mkdir -p build/completions
+go run ./cmd/go-app-gen completion bash > build/completions/go-app-gen.bash
+go run ./cmd/go-app-gen completion zsh > build/completions/_go-app-gen
+go run ./cmd/go-app-gen completion fish > build/completions/go-app-gen.fish